@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/botwayorg/railway-api/entity"
+	"github.com/botwayorg/railway-api/fake"
+	"github.com/botwayorg/railway-api/ui"
+)
+
+// MockBackend serves an in-memory fake of the API until interrupted, for
+// trying out the CLI offline
+func (h *Handler) MockBackend(ctx context.Context, req *entity.CommandRequest) error {
+	addr, err := req.Cmd.Flags().GetString("addr")
+	if err != nil {
+		return err
+	}
+
+	noIncremental, err := req.Cmd.Flags().GetBool("no-incremental")
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	backend := fake.New()
+	if noIncremental {
+		backend.DisableIncrementalUploads()
+	}
+
+	server := &http.Server{Handler: backend}
+	url := fmt.Sprintf("http://%s", listener.Addr())
+
+	fmt.Printf("🚂 Fake backend listening on %s\n", ui.MagentaText(url))
+	fmt.Printf("Point the CLI at it with\n    %s\n", ui.GreenText("export RAILWAY_API_URL="+url))
+	fmt.Printf("Then log in with %s, or use the demo project with\n    %s\n",
+		ui.GreenText("railway login --browserless"),
+		ui.GreenText("export RAILWAY_TOKEN="+fake.ProjectToken))
+	fmt.Println(ui.GrayText("Everything is kept in memory and lost on exit. Press Ctrl+C to stop"))
+
+	served := make(chan error, 1)
+
+	go func() {
+		served <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	// The command context is done, give requests in flight a moment
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/pflag"
+
+	"github.com/botwayorg/railway-api/entity"
+)
+
+func downFlags(flags *pflag.FlagSet) {
+	flags.StringP("environment", "e", "", "")
+	flags.BoolP("yes", "y", false, "")
+}
+
+func TestDown(t *testing.T) {
+	h, backend := newFakeHandler(t)
+	linkDemo(t, h)
+
+	req := newRequest(t, downFlags, []string{"--yes"})
+
+	if err := h.Down(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	demo := backend.Projects()[0]
+	deployments := backend.Deployments(demo.Id, demo.Environments[0].Id)
+
+	if len(deployments) == 0 || deployments[0].Status != entity.STATUS_REMOVED {
+		t.Errorf("the latest deployment wasn't removed: %v", deployments)
+	}
+}
+
+func TestDownCassette(t *testing.T) {
+	h := newCassetteHandler(t, "down")
+	linkDemo(t, h)
+
+	if err := h.Down(context.Background(), newRequest(t, downFlags, []string{"--yes"})); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -16,7 +16,13 @@ func (h *Handler) Environment(ctx context.Context, req *entity.CommandRequest) e
 		return err
 	}
 
-	project, err := h.ctrl.GetProject(ctx, projectID)
+	getProject := h.ctrl.GetProject
+	if len(req.Args) == 0 {
+		// Only the picker can do with environments fetched a while ago
+		getProject = h.ctrl.GetCachedProject
+	}
+
+	project, err := getProject(ctx, projectID)
 
 	if err != nil {
 		return err
@@ -10,6 +10,7 @@ import (
 
 	"github.com/botwayorg/railway-api/entity"
 	CLIErrors "github.com/botwayorg/railway-api/errors"
+	"github.com/botwayorg/railway-api/gateway"
 	"github.com/botwayorg/railway-api/ui"
 )
 
@@ -186,10 +187,15 @@ func (h *Handler) initFromTemplate(ctx context.Context, req *entity.CommandReque
 	})
 
 	for {
-		time.Sleep(2 * time.Second)
+		if err := gateway.Sleep(ctx, 2*time.Second); err != nil {
+			ui.StopSpinner("")
+			return err
+		}
+
 		workflowStatus, err := h.ctrl.GetWorkflowStatus(ctx, creationResult.WorkflowID)
 
 		if err != nil {
+			ui.StopSpinner("")
 			return err
 		}
 
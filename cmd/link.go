@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/botwayorg/railway-api/entity"
 	"github.com/botwayorg/railway-api/ui"
 	"github.com/botwayorg/railway-api/uuid"
+	"github.com/spf13/cobra"
 )
 
 func (h *Handler) Link(ctx context.Context, req *entity.CommandRequest) error {
@@ -44,8 +46,31 @@ func (h *Handler) Link(ctx context.Context, req *entity.CommandRequest) error {
 	}
 }
 
+// CompleteProjects completes project names for shell completion, from the
+// cache when possible
+func (h *Handler) CompleteProjects(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	projects, err := h.ctrl.GetCachedProjects(cmd.Context())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	names := make([]string, 0, len(projects))
+
+	for _, project := range projects {
+		if strings.HasPrefix(project.Name, toComplete) {
+			names = append(names, project.Name)
+		}
+	}
+
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
 func (h *Handler) linkFromAccount(ctx context.Context, _ *entity.CommandRequest) error {
-	projects, err := h.ctrl.GetProjects(ctx)
+	projects, err := h.ctrl.GetCachedProjects(ctx)
 	if err != nil {
 		return err
 	}
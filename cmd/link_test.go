@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+)
+
+// linkDemo links the working directory to the demo project of the backend
+func linkDemo(t *testing.T, h *Handler) {
+	if err := h.Link(context.Background(), newRequest(t, nil, nil, "demo")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLink(t *testing.T) {
+	h, backend := newFakeHandler(t)
+	linkDemo(t, h)
+
+	demo := backend.Projects()[0]
+
+	projectConfig, err := h.cfg.GetProjectConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if projectConfig.Project != demo.Id {
+		t.Errorf("project = %q, want %q", projectConfig.Project, demo.Id)
+	}
+
+	if projectConfig.Environment != demo.Environments[0].Id {
+		t.Errorf("environment = %q, want %q", projectConfig.Environment, demo.Environments[0].Id)
+	}
+}
+
+func TestLinkUnknownProject(t *testing.T) {
+	h, _ := newFakeHandler(t)
+
+	if err := h.Link(context.Background(), newRequest(t, nil, nil, "unknown")); err == nil {
+		t.Error("linked a project that doesn't exist")
+	}
+
+	if _, err := h.cfg.GetProjectConfigs(); err == nil {
+		t.Error("a project config was written")
+	}
+}
+
+func TestLinkCassette(t *testing.T) {
+	h := newCassetteHandler(t, "link")
+	linkDemo(t, h)
+
+	projectConfig, err := h.cfg.GetProjectConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if projectConfig.Project == "" || projectConfig.Environment == "" {
+		t.Errorf("linked project %q and environment %q", projectConfig.Project, projectConfig.Environment)
+	}
+}
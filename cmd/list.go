@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/botwayorg/railway-api/entity"
+	"github.com/botwayorg/railway-api/ui"
+)
+
+func (h *Handler) List(ctx context.Context, req *entity.CommandRequest) error {
+	limit, err := req.Cmd.Flags().GetInt("limit")
+	if err != nil {
+		return err
+	}
+
+	projects := h.ctrl.IterateProjects(ctx).Limit(limit)
+	count := 0
+
+	for projects.Next(ctx) {
+		project := projects.Value()
+
+		team := ""
+		if project.Team != nil {
+			team = *project.Team
+		}
+
+		fmt.Printf("%s %s\n", ui.Bold(project.Name), ui.GrayText(team))
+
+		count++
+	}
+
+	if err := projects.Err(); err != nil {
+		return err
+	}
+
+	if count == 0 {
+		fmt.Print(ui.AlertWarning("No projects found"))
+		fmt.Printf("Create one with %s\n", ui.GreenText("railway init"))
+	}
+
+	return nil
+}
+
+func (h *Handler) Deployments(ctx context.Context, req *entity.CommandRequest) error {
+	limit, err := req.Cmd.Flags().GetInt("limit")
+	if err != nil {
+		return err
+	}
+
+	deployments, err := h.ctrl.IterateDeployments(ctx)
+	if err != nil {
+		return err
+	}
+
+	deployments.Limit(limit)
+	count := 0
+
+	for deployments.Next(ctx) {
+		deployment := deployments.Value()
+
+		message := ""
+		if deployment.Meta != nil {
+			message = ui.Truncate(deployment.Meta.CommitMessage, 60)
+		}
+
+		fmt.Printf("%s %s %s\n", ui.GrayText(deployment.ID), ui.Bold(deployment.Status), message)
+
+		count++
+	}
+
+	if err := deployments.Err(); err != nil {
+		return err
+	}
+
+	if count == 0 {
+		fmt.Print(ui.AlertWarning("No deployments found"))
+	}
+
+	return nil
+}
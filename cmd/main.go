@@ -1,8 +1,14 @@
 package cmd
 
 import (
+	"errors"
+	"io"
+	"os"
+
 	"github.com/botwayorg/railway-api/configs"
 	"github.com/botwayorg/railway-api/controller"
+	"github.com/botwayorg/railway-api/gateway"
+	"github.com/spf13/pflag"
 )
 
 type Handler struct {
@@ -11,8 +17,93 @@ type Handler struct {
 }
 
 func New() *Handler {
+	ctrl := controller.New()
+
+	term := &terminal{}
+
+	ctrl.SetReporter(term)
+	ctrl.SetPrompter(term)
+
 	return &Handler{
-		ctrl: controller.New(),
+		ctrl: ctrl,
 		cfg:  configs.New(),
 	}
 }
+
+// Configure applies the global flags, once they are parsed and before any
+// command runs
+func (h *Handler) Configure(flags *pflag.FlagSet) error {
+	trace, err := flags.GetBool("trace")
+	if err != nil {
+		return err
+	}
+
+	harPath, err := flags.GetString("har")
+	if err != nil {
+		return err
+	}
+
+	refresh, err := flags.GetBool("refresh")
+	if err != nil {
+		return err
+	}
+
+	h.ctrl.Refresh(refresh)
+
+	requestTimeout, err := flags.GetDuration("request-timeout")
+	if err != nil {
+		return err
+	}
+
+	h.ctrl.RequestTimeout(requestTimeout)
+
+	if err := h.ctrl.ConfigureTransport(); err != nil {
+		return err
+	}
+
+	cassette, err := openCassette(flags)
+	if err != nil {
+		return err
+	}
+
+	if cassette != nil {
+		h.ctrl.UseCassette(cassette)
+	}
+
+	if trace || harPath != "" {
+		var out io.Writer
+
+		if trace {
+			out = os.Stderr
+		}
+
+		h.ctrl.Trace(gateway.NewTracer(out, harPath))
+	}
+
+	return nil
+}
+
+// openCassette opens the cassette given with --record or --replay, falling
+// back to RAILWAY_RECORD and RAILWAY_REPLAY
+func openCassette(flags *pflag.FlagSet) (*gateway.Cassette, error) {
+	recordPath, err := flags.GetString("record")
+	if err != nil {
+		return nil, err
+	}
+
+	replayPath, err := flags.GetString("replay")
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case recordPath != "" && replayPath != "":
+		return nil, errors.New("--record and --replay can't be used together")
+	case recordPath != "":
+		return gateway.OpenCassette(recordPath, gateway.CassetteRecord)
+	case replayPath != "":
+		return gateway.OpenCassette(replayPath, gateway.CassetteReplay)
+	}
+
+	return gateway.NewCassetteFromEnv()
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/botwayorg/railway-api/configs"
+	"github.com/botwayorg/railway-api/controller"
+	"github.com/botwayorg/railway-api/entity"
+	"github.com/botwayorg/railway-api/fake"
+	"github.com/botwayorg/railway-api/gateway"
+)
+
+var update = flag.Bool("update", false, "record the cassettes of testdata against the fake backend")
+
+// newFakeHandler returns a handler logged in to a new fake backend, with a
+// home directory of its own and a project directory as working directory
+func newFakeHandler(t *testing.T) (*Handler, *fake.Backend) {
+	backend := fake.New()
+
+	return newHandler(t, backend.Client()), backend
+}
+
+// newCassetteHandler returns a handler replaying the cassette of testdata
+// with the given name, or recording it against a new fake backend with
+// -update
+func newCassetteHandler(t *testing.T, name string) *Handler {
+	path, err := filepath.Abs(filepath.Join("testdata", name+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mode := gateway.CassetteReplay
+	httpClient := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("replaying a cassette sent a request")
+	})}
+
+	if *update {
+		mode = gateway.CassetteRecord
+		httpClient = fake.New().Client()
+	}
+
+	cassette, err := gateway.OpenCassette(path, mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := newHandler(t, httpClient)
+	h.ctrl.UseCassette(cassette)
+
+	return h
+}
+
+// newHandler returns a handler logged in with the user token of the fake
+// backend, sending requests with httpClient
+func newHandler(t *testing.T, httpClient *http.Client) *Handler {
+	t.Setenv("HOME", t.TempDir())
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Error(err)
+		}
+	})
+
+	gtwy := gateway.NewClient(
+		gateway.WithHost(fake.Host),
+		gateway.WithToken(fake.UserToken),
+		gateway.WithHTTPClient(httpClient),
+	)
+
+	return &Handler{ctrl: controller.NewWithGateway(gtwy), cfg: configs.New()}
+}
+
+// newRequest returns the request of a command with the flags define adds,
+// parsed from flags
+func newRequest(t *testing.T, define func(flags *pflag.FlagSet), flags []string, args ...string) *entity.CommandRequest {
+	cmd := &cobra.Command{}
+	cmd.Flags().BoolP("verbose", "v", false, "")
+
+	if define != nil {
+		define(cmd.Flags())
+	}
+
+	if err := cmd.Flags().Parse(flags); err != nil {
+		t.Fatal(err)
+	}
+
+	return &entity.CommandRequest{Cmd: cmd, Args: args}
+}
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/botwayorg/railway-api/errors"
@@ -19,8 +20,16 @@ func (h *Handler) Panic(ctx context.Context, panicErr string, stacktrace string,
 		}
 	}
 
+	fmt.Printf("🚨 Looks like something derailed, Press Enter to send error logs (^C to quit)")
+	fmt.Fscanln(os.Stdin)
+
+	ui.StartSpinner(&ui.SpinnerCfg{
+		Message: "Taking notes...",
+	})
+
 	success, err := h.ctrl.SendPanic(ctx, panicErr, stacktrace, cmd)
 	if err != nil {
+		ui.StopSpinner("")
 		return err
 	}
 
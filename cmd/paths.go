@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/botwayorg/railway-api/entity"
+	CLIErrors "github.com/botwayorg/railway-api/errors"
+	"github.com/botwayorg/railway-api/ui"
+)
+
+// Paths shows the directories uploaded for the services of the linked project
+func (h *Handler) Paths(ctx context.Context, req *entity.CommandRequest) error {
+	projectConfig, err := h.cfg.GetProjectConfigs()
+	if err != nil {
+		return err
+	}
+
+	if len(projectConfig.Services) == 0 {
+		fmt.Print(ui.AlertInfo(fmt.Sprintf("No service paths configured, every service is uploaded from %s", projectConfig.ProjectPath)))
+		return nil
+	}
+
+	paths := make(map[string]string)
+
+	for service, servicePaths := range projectConfig.Services {
+		paths[service] = describeServicePaths(servicePaths)
+	}
+
+	fmt.Print(ui.Heading("Service Paths"))
+	fmt.Print(ui.KeyValues(paths, false))
+
+	return nil
+}
+
+func (h *Handler) PathsSet(ctx context.Context, req *entity.CommandRequest) error {
+	service, dir := req.Args[0], req.Args[1]
+
+	shared, err := req.Cmd.Flags().GetStringArray("shared")
+	if err != nil {
+		return err
+	}
+
+	projectConfig, err := h.cfg.GetProjectConfigs()
+	if err != nil {
+		return err
+	}
+
+	project, err := h.ctrl.GetProject(ctx, projectConfig.Project)
+	if err != nil {
+		return err
+	}
+
+	if !hasService(project, service) {
+		return CLIErrors.ServiceNotFound
+	}
+
+	servicePaths := &entity.ServicePaths{}
+
+	if servicePaths.Path, err = projectRelativePath(projectConfig.ProjectPath, dir); err != nil {
+		return err
+	}
+
+	for _, sharedDir := range shared {
+		rel, err := projectRelativePath(projectConfig.ProjectPath, sharedDir)
+		if err != nil {
+			return err
+		}
+
+		// Overlapping directories would upload the same files twice
+		for _, other := range append([]string{servicePaths.Path}, servicePaths.Shared...) {
+			if pathContains(other, rel) || pathContains(rel, other) {
+				return fmt.Errorf("%s can't be shared, it overlaps with %s", rel, other)
+			}
+		}
+
+		servicePaths.Shared = append(servicePaths.Shared, rel)
+	}
+
+	if err := h.cfg.SetServicePaths(service, servicePaths); err != nil {
+		return err
+	}
+
+	fmt.Printf("🎉 Service %s is now uploaded from %s\n", ui.MagentaText(service), describeServicePaths(*servicePaths))
+
+	return nil
+}
+
+func (h *Handler) PathsUnset(ctx context.Context, req *entity.CommandRequest) error {
+	service := req.Args[0]
+
+	if err := h.cfg.SetServicePaths(service, nil); err != nil {
+		return err
+	}
+
+	fmt.Printf("🎉 Service %s is now uploaded from the project path\n", ui.MagentaText(service))
+
+	return nil
+}
+
+func hasService(project *entity.Project, name string) bool {
+	for _, service := range project.Services {
+		if service.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// projectRelativePath returns dir relative to the project path, with forward
+// slashes. It must be a directory inside the project
+func projectRelativePath(projectPath, dir string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(absDir)
+	if err != nil {
+		return "", err
+	}
+
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s isn't a directory", dir)
+	}
+
+	rel, err := filepath.Rel(projectPath, absDir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s isn't inside the project at %s", dir, projectPath)
+	}
+
+	return filepath.ToSlash(rel), nil
+}
+
+// pathContains tells whether the slash-separated path is dir or inside it
+func pathContains(dir, path string) bool {
+	return dir == "." || path == dir || strings.HasPrefix(path, dir+"/")
+}
+
+func describeServicePaths(servicePaths entity.ServicePaths) string {
+	if len(servicePaths.Shared) == 0 {
+		return servicePaths.Path
+	}
+
+	return fmt.Sprintf("%s, with %s", servicePaths.Path, strings.Join(servicePaths.Shared, ", "))
+}
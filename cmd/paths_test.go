@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/spf13/pflag"
+
+	CLIErrors "github.com/botwayorg/railway-api/errors"
+)
+
+func pathsSetFlags(flags *pflag.FlagSet) {
+	flags.StringArray("shared", nil, "")
+}
+
+func TestPathsSet(t *testing.T) {
+	h, _ := newFakeHandler(t)
+	linkDemo(t, h)
+
+	for _, dir := range []string{"web", "libs"} {
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := newRequest(t, pathsSetFlags, []string{"--shared", "libs"}, "web", "web")
+	if err := h.PathsSet(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	projectConfig, err := h.cfg.GetProjectConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	servicePaths := projectConfig.Services["web"]
+	if servicePaths.Path != "web" || len(servicePaths.Shared) != 1 || servicePaths.Shared[0] != "libs" {
+		t.Errorf("paths of web = %+v, want web with libs", servicePaths)
+	}
+}
+
+func TestPathsSetUnknownService(t *testing.T) {
+	h, _ := newFakeHandler(t)
+	linkDemo(t, h)
+
+	if err := os.Mkdir("worker", 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := h.PathsSet(context.Background(), newRequest(t, pathsSetFlags, nil, "worker", "worker")); err != CLIErrors.ServiceNotFound {
+		t.Fatalf("err = %v, want ServiceNotFound", err)
+	}
+
+	projectConfig, err := h.cfg.GetProjectConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := projectConfig.Services["worker"]; ok {
+		t.Error("paths were set for a service the project doesn't have")
+	}
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/botwayorg/railway-api/entity"
+	"github.com/botwayorg/railway-api/ui"
+)
+
+func (h *Handler) Profile(ctx context.Context, req *entity.CommandRequest) error {
+	rootCfg, err := h.cfg.GetRootConfigs()
+	if err != nil {
+		rootCfg = &entity.RootConfig{}
+	}
+
+	if len(rootCfg.Profiles) == 0 {
+		fmt.Print(ui.AlertInfo("No endpoint profiles configured, using the default API"))
+		return nil
+	}
+
+	urls := make(map[string]string)
+
+	for name, profile := range rootCfg.Profiles {
+		if name == rootCfg.Profile {
+			name = fmt.Sprintf("%s (active)", name)
+		}
+
+		urls[name] = profile.APIURL
+	}
+
+	fmt.Print(ui.Heading("Endpoint Profiles"))
+	fmt.Print(ui.KeyValues(urls, false))
+
+	return nil
+}
+
+func (h *Handler) ProfileSet(ctx context.Context, req *entity.CommandRequest) error {
+	name, apiURL := req.Args[0], req.Args[1]
+
+	err := h.cfg.SetEndpointProfile(name, &entity.EndpointProfile{
+		APIURL: apiURL,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("🎉 Saved profile %s pointing at %s\n", ui.MagentaText(name), ui.GrayText(apiURL))
+
+	return nil
+}
+
+func (h *Handler) ProfileUse(ctx context.Context, req *entity.CommandRequest) error {
+	name := ""
+
+	if len(req.Args) > 0 {
+		name = req.Args[0]
+	}
+
+	err := h.cfg.UseEndpointProfile(name)
+	if err != nil {
+		return err
+	}
+
+	if name == "" {
+		fmt.Printf("🎉 Using the default API\n")
+	} else {
+		fmt.Printf("🎉 Using profile %s\n", ui.MagentaText(name))
+	}
+
+	return nil
+}
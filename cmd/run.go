@@ -12,6 +12,7 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/botwayorg/railway-api/entity"
 	"github.com/botwayorg/railway-api/errors"
@@ -20,6 +21,9 @@ import (
 
 var RAIL_PORT = 4411
 
+// teardownTimeout bounds the cleanup done after a command was interrupted
+const teardownTimeout = 30 * time.Second
+
 func (h *Handler) getEnvironment(ctx context.Context, environmentName string) (*entity.Environment, error) {
 	if environmentName == "" {
 		return h.ctrl.GetCurrentEnvironment(ctx)
@@ -99,7 +103,36 @@ func (h *Handler) Run(ctx context.Context, req *entity.CommandRequest) error {
 		fmt.Println("Done!")
 	}
 
-	envs, err := h.ctrl.GetEnvs(ctx, environment, targetServiceName)
+	err = h.runInEnvironment(ctx, environment, targetServiceName, parsedArgs)
+
+	if isEphemeral {
+		// Tear down even when the command was interrupted, so the environment
+		// doesn't outlive the run
+		if err := h.teardownEphemeralEnvironment(projectCfg.Project, environment.Id); err != nil {
+			return err
+		}
+	}
+
+	if err != nil {
+		var exitError *exec.ExitError
+
+		if !goErr.As(err, &exitError) {
+			return err
+		}
+
+		fmt.Println(err.Error())
+		os.Exit(exitError.ExitCode())
+	}
+
+	printLooksGood()
+
+	return nil
+}
+
+// runInEnvironment runs the command, or the Dockerfile of the current
+// directory when there is none, with the variables of the environment
+func (h *Handler) runInEnvironment(ctx context.Context, environment *entity.Environment, serviceName *string, args []string) error {
+	envs, err := h.ctrl.GetEnvs(ctx, environment, serviceName)
 
 	if err != nil {
 		return err
@@ -116,13 +149,15 @@ func (h *Handler) Run(ctx context.Context, req *entity.CommandRequest) error {
 		hasDockerfile = false
 	}
 
-	if len(parsedArgs) == 0 && hasDockerfile {
+	if len(args) == 0 && hasDockerfile {
 		return h.runInDocker(ctx, pwd, envs)
-	} else if len(parsedArgs) == 0 {
+	} else if len(args) == 0 {
 		return errors.CommandNotSpecified
 	}
 
-	cmd := exec.CommandContext(ctx, parsedArgs[0], parsedArgs[1:]...)
+	// Not bound to the context: the child gets the signals we get and decides
+	// itself how to shut down
+	cmd := exec.Command(args[0], args[1:]...)
 	cmd.Env = os.Environ()
 
 	// Inject railway envs
@@ -135,34 +170,26 @@ func (h *Handler) Run(ctx context.Context, req *entity.CommandRequest) error {
 	cmd.Stdin = os.Stdin
 	catchSignals(ctx, cmd, nil)
 
-	err = cmd.Run()
+	return cmd.Run()
+}
 
-	if isEphemeral {
-		// Teardown Environment
-		fmt.Println("Tearing down ephemeral environment...")
-		err := h.ctrl.DeleteEnvironment(ctx, &entity.DeleteEnvironmentRequest{
-			EnvironmentId: environment.Id,
-			ProjectID:     projectCfg.Project,
-		})
+func (h *Handler) teardownEphemeralEnvironment(projectID, environmentID string) error {
+	// The command context is likely done by now
+	ctx, cancel := context.WithTimeout(context.Background(), teardownTimeout)
+	defer cancel()
 
-		if err != nil {
-			return err
-		}
+	fmt.Println("Tearing down ephemeral environment...")
 
-		fmt.Println("Done!")
-	}
+	err := h.ctrl.DeleteEnvironment(ctx, &entity.DeleteEnvironmentRequest{
+		EnvironmentId: environmentID,
+		ProjectID:     projectID,
+	})
 
 	if err != nil {
-		fmt.Println(err.Error())
-
-		if exitError, ok := err.(*exec.ExitError); ok {
-			os.Exit(exitError.ExitCode())
-		}
-
-		os.Exit(1)
+		return err
 	}
 
-	printLooksGood()
+	fmt.Println("Done!")
 
 	return nil
 }
@@ -258,20 +285,24 @@ func (h *Handler) runInDocker(ctx context.Context, pwd string, envs *entity.Envs
 	if err != nil {
 		return err
 	}
-	// Listen for cancel to remove the container
-	catchSignals(ctx, logCmd, func() {
-		err = exec.Command("docker", "rm", "-f", string(containerId)).Run()
-	})
 
 	err = logCmd.Wait()
 
+	// Following the logs stops when the command is interrupted, remove the
+	// container before returning
+	if ctx.Err() != nil {
+		if err := exec.Command("docker", "rm", "-f", containerId).Run(); err != nil {
+			fmt.Println("Failed to remove the container: \n", err)
+		}
+
+		return ctx.Err()
+	}
+
 	if err != nil && !strings.Contains(err.Error(), "255") {
 		// 255 is a graceeful exit with ctrl + c
 		return err
 	}
 
-	printLooksGood()
-
 	return nil
 }
 
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/botwayorg/railway-api/entity"
+	"github.com/botwayorg/railway-api/ui"
+)
+
+// Symlinks shows how uploads of the linked project treat symlinks, or sets it
+// when a policy is given
+func (h *Handler) Symlinks(ctx context.Context, req *entity.CommandRequest) error {
+	projectConfig, err := h.cfg.GetProjectConfigs()
+	if err != nil {
+		return err
+	}
+
+	if len(req.Args) == 0 {
+		policy, isDefault := projectConfig.Symlinks, ""
+		if policy == "" {
+			policy, isDefault = entity.SymlinkPolicies[0], " (default)"
+		}
+
+		fmt.Printf("Uploads of this project %s%s\n", describeSymlinkPolicy(policy), ui.GrayText(isDefault))
+
+		return nil
+	}
+
+	policy, err := parseSymlinkPolicy(req.Args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := h.cfg.SetSymlinkPolicy(policy); err != nil {
+		return err
+	}
+
+	fmt.Printf("🎉 Uploads of this project now %s\n", describeSymlinkPolicy(policy))
+
+	return nil
+}
+
+// symlinkPolicy returns the policy given with --symlinks, or else the one of
+// the linked project. Empty means the default
+func (h *Handler) symlinkPolicy(req *entity.CommandRequest) (entity.SymlinkPolicy, error) {
+	flag, err := req.Cmd.Flags().GetString("symlinks")
+	if err != nil {
+		return "", err
+	}
+
+	if flag != "" {
+		return parseSymlinkPolicy(flag)
+	}
+
+	if projectConfig, err := h.cfg.GetProjectConfigs(); err == nil {
+		return projectConfig.Symlinks, nil
+	}
+
+	return "", nil
+}
+
+func parseSymlinkPolicy(name string) (entity.SymlinkPolicy, error) {
+	names := make([]string, len(entity.SymlinkPolicies))
+
+	for i, policy := range entity.SymlinkPolicies {
+		if string(policy) == name {
+			return policy, nil
+		}
+
+		names[i] = string(policy)
+	}
+
+	return "", fmt.Errorf("%s\nUse one of %s", ui.RedText(fmt.Sprintf("Unknown symlink policy %q", name)), strings.Join(names, ", "))
+}
+
+func describeSymlinkPolicy(policy entity.SymlinkPolicy) string {
+	switch policy {
+	case entity.SymlinksPreserve:
+		return fmt.Sprintf("keep symlinks as links (%s)", ui.MagentaText(string(policy)))
+	case entity.SymlinksFollow:
+		return fmt.Sprintf("follow every symlink, even out of the project (%s)", ui.MagentaText(string(policy)))
+	case entity.SymlinksError:
+		return fmt.Sprintf("fail on symlinks that aren't ignored (%s)", ui.MagentaText(string(policy)))
+	default:
+		return fmt.Sprintf("follow symlinks within the project and leave out the others (%s)", ui.MagentaText(string(policy)))
+	}
+}
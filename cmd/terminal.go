@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abdfnx/botway/constants"
+	"github.com/botwayorg/railway-api/entity"
+	"github.com/botwayorg/railway-api/ui"
+)
+
+// uploadProgressInterval is how often upload progress is printed when the
+// output isn't a terminal, where lines can't be redrawn
+const uploadProgressInterval = 5 * time.Second
+
+// terminal renders the events of the controller and asks its questions on the
+// terminal
+type terminal struct {
+	mu sync.Mutex
+	// lastUpload is when upload progress was last printed on a line of its own
+	lastUpload      time.Time
+	lastUploadPhase entity.UploadPhase
+}
+
+func (t *terminal) Report(event entity.Event) {
+	switch e := event.(type) {
+	case entity.ProgressEvent:
+		if e.Done {
+			ui.StopSpinner(e.Message)
+		} else {
+			ui.StartSpinner(&ui.SpinnerCfg{Message: e.Message})
+		}
+
+	case entity.LogLinesEvent:
+		fmt.Println(strings.Join(e.Lines, "\n"))
+
+	case entity.NoticeEvent:
+		switch e.Level {
+		case entity.NoticeWarning:
+			fmt.Print(ui.AlertWarning(e.Message))
+		case entity.NoticeError:
+			fmt.Println(ui.RedText(e.Message))
+		default:
+			fmt.Println(e.Message)
+		}
+
+	case entity.LoginCodeEvent:
+		fmt.Printf(constants.HEADING+"Your pairing code is: %s\n", constants.PRIMARY_FOREGROUND.Render(e.Code))
+		fmt.Printf("To authenticate with Railway, please go to \n    %s\n", e.URL)
+
+	case entity.UploadProgressEvent:
+		t.reportUpload(e)
+
+	case entity.UploadFindingsEvent:
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		// The findings come while the progress of the scan is drawn
+		if ui.SupportsANSICodes() {
+			fmt.Print("\r\033[K")
+		}
+
+		title := fmt.Sprintf("%s flagged as likely secrets or too large to deploy", plural(len(e.Findings), "file"))
+		if e.Strict {
+			fmt.Print(ui.AlertDanger(title))
+		} else {
+			fmt.Print(ui.AlertWarning(title))
+		}
+
+		printFindings(e.Findings)
+
+		// Strict uploads fail with the same advice
+		if !e.Strict {
+			fmt.Println(ui.GrayText("Leave them out in .railwayignore, or upload them anyway with a \"!\" pattern there or with --allow"))
+		}
+	}
+}
+
+// printFindings lists flagged files with their size and what was found
+func printFindings(findings []entity.UploadFinding) {
+	width := len("PATH")
+
+	for _, finding := range findings {
+		if len(finding.Path) > width {
+			width = len(finding.Path)
+		}
+	}
+
+	fmt.Printf("%10s  %-*s  %s\n", "SIZE", width, "PATH", "WHY")
+
+	for _, finding := range findings {
+		reason := finding.Reason
+		if finding.AllowedBy != "" {
+			reason += ui.GrayText(", allowed by " + finding.AllowedBy).String()
+		}
+
+		fmt.Printf("%10s  %-*s  %s\n", ui.HumanBytes(finding.Size), width, finding.Path, reason)
+	}
+}
+
+// reportUpload redraws a progress bar on terminals, and prints a line every
+// few seconds elsewhere, like in CI logs
+func (t *terminal) reportUpload(e entity.UploadProgressEvent) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	final := e.Phase == entity.UploadFinished || e.Phase == entity.UploadFailed
+	line := uploadProgressLine(e, ui.SupportsANSICodes())
+
+	if ui.SupportsANSICodes() {
+		// Clear the line, the progress may have shrunk
+		fmt.Print("\r\033[K")
+
+		if e.Phase != entity.UploadFailed {
+			fmt.Print(line)
+		}
+
+		if final && e.Phase != entity.UploadFailed {
+			fmt.Println()
+		}
+
+		return
+	}
+
+	if e.Phase == entity.UploadFailed {
+		return
+	}
+
+	if final || e.Phase != t.lastUploadPhase || time.Since(t.lastUpload) >= uploadProgressInterval {
+		fmt.Println(line)
+
+		t.lastUpload = time.Now()
+		t.lastUploadPhase = e.Phase
+	}
+}
+
+func uploadProgressLine(e entity.UploadProgressEvent, bar bool) string {
+	switch e.Phase {
+	case entity.UploadScanning:
+		return fmt.Sprintf("Scanning project: %s to upload, %d ignored", plural(e.FilesIncluded, "file"), e.FilesIgnored)
+
+	case entity.UploadFinished:
+		if e.Incremental {
+			return fmt.Sprintf("☁️ Uploaded %s (%d ignored), %d unchanged, %s sent in %s",
+				plural(e.FilesIncluded, "file"), e.FilesIgnored, e.FilesReused, ui.HumanBytes(e.UploadedBytes),
+				e.Elapsed.Round(100*time.Millisecond))
+		}
+
+		return fmt.Sprintf("☁️ Uploaded %s (%d ignored), %s archived and sent as %s in %s",
+			plural(e.FilesIncluded, "file"), e.FilesIgnored, ui.HumanBytes(e.TotalBytes), ui.HumanBytes(e.UploadedBytes),
+			e.Elapsed.Round(100*time.Millisecond))
+	}
+
+	line := fmt.Sprintf("%3.0f%%  %s of %s archived, %s sent at %s/s",
+		e.Fraction()*100, ui.HumanBytes(e.ArchivedBytes), ui.HumanBytes(e.TotalBytes),
+		ui.HumanBytes(e.UploadedBytes), ui.HumanBytes(int64(e.Throughput())))
+
+	if e.Incremental {
+		line = fmt.Sprintf("%3.0f%%  %s of %s changed sent at %s/s, %d unchanged",
+			e.Fraction()*100, ui.HumanBytes(e.UploadedBytes), ui.HumanBytes(e.TotalBytes),
+			ui.HumanBytes(int64(e.Throughput())), e.FilesReused)
+	}
+
+	if eta := e.ETA().Round(time.Second); eta > 0 {
+		line += fmt.Sprintf(", %s left", eta)
+	}
+
+	if bar {
+		return ui.ProgressBar(e.Fraction(), 30) + " " + line
+	}
+
+	return "Uploading: " + line
+}
+
+func (t *terminal) Confirm(_ context.Context, req *entity.ConfirmationRequest) (bool, error) {
+	if req.Reason != "" {
+		fmt.Println(ui.Bold(ui.RedText(req.Reason).String()))
+	}
+
+	return ui.PromptYesNo(req.Question)
+}
+
+func (t *terminal) SelectService(_ context.Context, services []*entity.Service) (*entity.Service, error) {
+	return ui.PromptServices(services)
+}
+
+func (t *terminal) Acknowledge(_ context.Context, message string) error {
+	fmt.Print(message)
+	fmt.Fscanln(os.Stdin)
+
+	return nil
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+
+	return fmt.Sprintf("%d %ss", n, noun)
+}
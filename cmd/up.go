@@ -4,10 +4,16 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/botwayorg/railway-api/controller"
 	"github.com/botwayorg/railway-api/entity"
 	CLIErrors "github.com/botwayorg/railway-api/errors"
+	"github.com/botwayorg/railway-api/gateway"
 	"github.com/botwayorg/railway-api/ui"
 )
 
@@ -25,18 +31,41 @@ func (h *Handler) Up(ctx context.Context, req *entity.CommandRequest) error {
 
 	fmt.Print(ui.VerboseInfo(isVerbose, "Using verbose mode"))
 
-	projectConfig, err := h.linkAndGetProjectConfigs(ctx, req)
+	dryRun, err := req.Cmd.Flags().GetBool("dry-run")
 	if err != nil {
 		return err
 	}
 
-	src := projectConfig.ProjectPath
-	if src == "" {
-		// When deploying with a project token, the project path is empty
-		src = "."
+	explain, err := req.Cmd.Flags().GetBool("explain")
+	if err != nil {
+		return err
+	}
+
+	symlinks, err := h.symlinkPolicy(req)
+	if err != nil {
+		return err
+	}
+
+	scan, err := uploadScan(req)
+	if err != nil {
+		return err
+	}
+
+	incremental, err := req.Cmd.Flags().GetBool("incremental")
+	if err != nil {
+		return err
 	}
 
-	fmt.Print(ui.VerboseInfo(isVerbose, fmt.Sprintf("Uploading directory %s", src)))
+	upload := &entity.UploadRequest{Symlinks: symlinks, Scan: scan, Incremental: incremental}
+
+	if dryRun || explain {
+		return h.upDryRun(req, explain, serviceName, upload)
+	}
+
+	projectConfig, err := h.linkAndGetProjectConfigs(ctx, req)
+	if err != nil {
+		return err
+	}
 
 	fmt.Print(ui.VerboseInfo(isVerbose, "Loading environment"))
 
@@ -88,33 +117,40 @@ func (h *Handler) Up(ctx context.Context, req *entity.CommandRequest) error {
 
 		if service != nil {
 			serviceId = service.ID
+			serviceName = service.Name
 		}
 	}
 
-	_, err = ioutil.ReadFile(".railwayignore")
+	if err := uploadPaths(req, projectConfig, serviceName, upload); err != nil {
+		return err
+	}
+
+	fmt.Print(ui.VerboseInfo(isVerbose, fmt.Sprintf("Uploading directory %s", upload.RootDir)))
+
+	for _, shared := range upload.Shared {
+		fmt.Print(ui.VerboseInfo(isVerbose, fmt.Sprintf("Uploading shared directory %s", shared.Name)))
+	}
+
+	_, err = ioutil.ReadFile(filepath.Join(upload.RootDir, ".railwayignore"))
 
 	if err == nil {
 		fmt.Print(ui.VerboseInfo(isVerbose, "Using ignore file .railwayignore"))
 	}
 
-	ui.StartSpinner(&ui.SpinnerCfg{
-		Message: "Laying tracks in the clouds...",
-	})
+	// The controller reports the progress of the upload
+	upload.ProjectID = projectConfig.Project
+	upload.EnvironmentID = environment.Id
+	upload.ServiceID = serviceId
 
-	res, err := h.ctrl.Upload(ctx, &entity.UploadRequest{
-		ProjectID:     projectConfig.Project,
-		EnvironmentID: environment.Id,
-		ServiceID:     serviceId,
-		RootDir:       src,
-	})
+	res, err := h.ctrl.Upload(ctx, upload)
 
 	if err != nil {
-		ui.StopSpinner("")
 		return err
-	} else {
-		ui.StopSpinner(fmt.Sprintf("☁️ Build logs available at %s\n", ui.GrayText(res.URL)))
 	}
 
+	fmt.Printf("☁️ Source digest %s\n", ui.GrayText(res.Digest))
+	fmt.Printf("☁️ Build logs available at %s\n\n", ui.GrayText(res.URL))
+
 	detach, err := req.Cmd.Flags().GetBool("detach")
 
 	if err != nil {
@@ -131,7 +167,13 @@ func (h *Handler) Up(ctx context.Context, req *entity.CommandRequest) error {
 			break
 		}
 
-		time.Sleep(time.Duration(i) * 250 * time.Millisecond)
+		if err := gateway.Sleep(ctx, time.Duration(i)*250*time.Millisecond); err != nil {
+			return err
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
 	}
 
 	fmt.Printf("\n\n======= Build Completed ======\n\n")
@@ -154,6 +196,172 @@ func (h *Handler) Up(ctx context.Context, req *entity.CommandRequest) error {
 	return nil
 }
 
+// upDryRun lists the files up would upload, without needing a linked project
+// or the network. With explain, it also lists the ignored paths and the rule
+// that ignored each of them
+func (h *Handler) upDryRun(req *entity.CommandRequest, explain bool, serviceName string, upload *entity.UploadRequest) error {
+	// Upload from the root of the linked project, or the directory of the
+	// service, like up does
+	projectConfig, err := h.cfg.GetProjectConfigs()
+	if err != nil {
+		projectConfig = &entity.ProjectConfig{}
+	}
+
+	if err := uploadPaths(req, projectConfig, serviceName, upload); err != nil {
+		return err
+	}
+
+	plan, err := h.ctrl.PlanUpload(upload)
+	if err != nil {
+		return err
+	}
+
+	var total int64
+
+	fmt.Printf("Files that would be uploaded from %s\n", ui.Bold(plan.RootDir))
+
+	for _, shared := range plan.Shared {
+		fmt.Printf("%s\n", ui.GrayText(fmt.Sprintf("and %s from %s", shared.Name, shared.Path)))
+	}
+
+	for _, file := range plan.Included {
+		fmt.Printf("%10s  %s\n", ui.HumanBytes(file.Size), file.Path)
+		total += file.Size
+	}
+
+	fmt.Printf("\n%s (%s), %d ignored\n", plural(len(plan.Included), "file"), ui.HumanBytes(total), len(plan.Ignored))
+
+	if len(plan.Findings) > 0 {
+		fmt.Printf("\nFlagged files\n")
+		printFindings(plan.Findings)
+	}
+
+	if explain && len(plan.Ignored) > 0 {
+		fmt.Printf("\nIgnored paths\n")
+
+		for _, file := range plan.Ignored {
+			size, name := ui.HumanBytes(file.Size), file.Path
+			if file.Dir {
+				size, name = "-", name+"/"
+			}
+
+			reason := fmt.Sprintf("%s:%d %s", file.IgnoreFile, file.Line, file.Pattern)
+
+			switch {
+			case file.Reason != "":
+				reason = file.Reason
+			case file.Pattern == "":
+				reason = "never uploaded"
+			case file.IgnoreFile == "":
+				reason = "default " + file.Pattern
+			}
+
+			fmt.Printf("%10s  %s  %s\n", size, name, ui.GrayText(reason))
+		}
+	} else if len(plan.Ignored) > 0 {
+		fmt.Println(ui.GrayText("Run with --explain to see why paths are ignored"))
+	}
+
+	// Strict dry runs fail like the upload would, for CI to check
+	if flagged := controller.FlaggedFindings(plan.Findings); upload.Scan.Strict && len(flagged) > 0 {
+		return &controller.FlaggedFilesError{Count: len(flagged)}
+	}
+
+	return nil
+}
+
+// uploadPaths sets the directories of an upload: the one given with --path,
+// or else the one of the service in the project config, or else the project
+// path. The shared directories of the service are uploaded along with it
+func uploadPaths(req *entity.CommandRequest, projectConfig *entity.ProjectConfig, serviceName string, upload *entity.UploadRequest) error {
+	path, err := req.Cmd.Flags().GetString("path")
+	if err != nil {
+		return err
+	}
+
+	projectPath := projectConfig.ProjectPath
+	if projectPath == "" {
+		// When deploying with a project token, the project path is empty
+		projectPath = "."
+	}
+
+	upload.RootDir, upload.ProjectDir = projectPath, projectPath
+
+	if servicePaths, ok := projectConfig.Services[serviceName]; ok && serviceName != "" {
+		upload.RootDir = filepath.Join(projectPath, filepath.FromSlash(servicePaths.Path))
+
+		for _, shared := range servicePaths.Shared {
+			upload.Shared = append(upload.Shared, entity.SharedDir{
+				Path: filepath.Join(projectPath, filepath.FromSlash(shared)),
+				Name: shared,
+			})
+		}
+	}
+
+	if path != "" {
+		upload.RootDir = path
+	}
+
+	info, err := os.Stat(upload.RootDir)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("%s isn't a directory", upload.RootDir)
+	}
+
+	return nil
+}
+
+// uploadScan reads the flags configuring the scan of uploads
+func uploadScan(req *entity.CommandRequest) (entity.UploadScan, error) {
+	var scan entity.UploadScan
+	var err error
+
+	if scan.Strict, err = req.Cmd.Flags().GetBool("strict"); err != nil {
+		return scan, err
+	}
+
+	if scan.Allow, err = req.Cmd.Flags().GetStringArray("allow"); err != nil {
+		return scan, err
+	}
+
+	maxFileSize, err := req.Cmd.Flags().GetString("max-file-size")
+	if err != nil {
+		return scan, err
+	}
+
+	if maxFileSize != "" {
+		if scan.MaxFileSize, err = parseBytes(maxFileSize); err != nil {
+			return scan, err
+		}
+	}
+
+	return scan, nil
+}
+
+// parseBytes reads a size like 512KB, 100MB or 2GB, in binary units like
+// ui.HumanBytes writes them
+func parseBytes(size string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(size))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "B"), "I")
+
+	multiplier := int64(1)
+
+	if i := strings.IndexAny(s, "KMGT"); i >= 0 && i == len(s)-1 {
+		multiplier = 1 << (10 * (strings.IndexByte("KMGT", s[i]) + 1))
+		s = strings.TrimSpace(s[:i])
+	}
+
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q, expected something like 100MB", size)
+	}
+
+	return int64(n * float64(multiplier)), nil
+}
+
 func (h *Handler) linkAndGetProjectConfigs(ctx context.Context, req *entity.CommandRequest) (*entity.ProjectConfig, error) {
 	projectConfig, err := h.ctrl.GetProjectConfigs(ctx)
 	if err == CLIErrors.ProjectConfigNotFound {
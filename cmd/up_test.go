@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/spf13/pflag"
+
+	"github.com/botwayorg/railway-api/fake"
+)
+
+func upFlags(flags *pflag.FlagSet) {
+	flags.BoolP("detach", "d", false, "")
+	flags.StringP("environment", "e", "", "")
+	flags.StringP("service", "s", "", "")
+	flags.Bool("dry-run", false, "")
+	flags.Bool("explain", false, "")
+	flags.String("symlinks", "", "")
+	flags.Bool("strict", false, "")
+	flags.StringArray("allow", nil, "")
+	flags.String("max-file-size", "", "")
+	flags.Bool("incremental", false, "")
+	flags.String("path", "", "")
+}
+
+func TestUp(t *testing.T) {
+	for _, test := range []struct {
+		name          string
+		flags         []string
+		noIncremental bool
+		// archive is whether the project should be sent as a whole archive
+		archive bool
+	}{
+		{name: "archive", archive: true},
+		{name: "incremental", flags: []string{"--incremental"}},
+		{name: "incremental fallback", flags: []string{"--incremental"}, noIncremental: true, archive: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			h, backend := newFakeHandler(t)
+			if test.noIncremental {
+				backend.DisableIncrementalUploads()
+			}
+
+			linkDemo(t, h)
+
+			if err := os.WriteFile("main.go", []byte("package main\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			flags := append([]string{"--detach", "--service", "web"}, test.flags...)
+			if err := h.Up(context.Background(), newRequest(t, upFlags, flags)); err != nil {
+				t.Fatal(err)
+			}
+
+			uploads := backend.Uploads()
+			if len(uploads) != 1 {
+				t.Fatalf("got %d uploads, want 1", len(uploads))
+			}
+
+			upload := uploads[0]
+			demo := backend.Projects()[0]
+
+			if upload.ProjectID != demo.Id || upload.ServiceID != demo.Services[0].ID {
+				t.Errorf("uploaded to project %q and service %q, want %q and %q", upload.ProjectID, upload.ServiceID, demo.Id, demo.Services[0].ID)
+			}
+
+			if archive := len(upload.Body) > 0; archive != test.archive {
+				t.Errorf("archive = %t, want %t", archive, test.archive)
+			}
+
+			if !test.archive {
+				assertManifest(t, backend, upload, "main.go")
+			}
+		})
+	}
+}
+
+// assertManifest checks that an incremental upload holds the files, whose
+// blobs are on the backend
+func assertManifest(t *testing.T, backend *fake.Backend, upload *fake.Upload, paths ...string) {
+	t.Helper()
+
+	if len(upload.Manifest) != len(paths) {
+		t.Fatalf("the manifest has %d files, want %d", len(upload.Manifest), len(paths))
+	}
+
+	for i, file := range upload.Manifest {
+		if file.Path != paths[i] {
+			t.Errorf("file %d = %q, want %q", i, file.Path, paths[i])
+		}
+
+		if _, ok := backend.Blob(upload.ProjectID, file.SHA256); !ok {
+			t.Errorf("no blob for %s", file.Path)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func variablesSetFlags(flags *pflag.FlagSet) {
+	flags.StringP("service", "s", "", "")
+	flags.Bool("skip-redeploy", false, "")
+	flags.Bool("replace", false, "")
+	flags.BoolP("yes", "y", false, "")
+}
+
+func TestVariablesSet(t *testing.T) {
+	h, backend := newFakeHandler(t)
+	linkDemo(t, h)
+
+	req := newRequest(t, variablesSetFlags, []string{"--service", "web"}, "DEBUG=1", "URL=https://example.com/?a=b")
+	if err := h.VariablesSet(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	demo := backend.Projects()[0]
+	envs := backend.Variables(demo.Id, demo.Environments[0].Id, demo.Services[0].ID)
+
+	for key, want := range map[string]string{"DEBUG": "1", "URL": "https://example.com/?a=b", "PORT": "3000"} {
+		if got := envs[key]; got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestVariablesSetReplace(t *testing.T) {
+	h, backend := newFakeHandler(t)
+	linkDemo(t, h)
+
+	req := newRequest(t, variablesSetFlags, []string{"--service", "web", "--replace", "--yes", "--skip-redeploy"}, "DEBUG=1")
+	if err := h.VariablesSet(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	demo := backend.Projects()[0]
+	envs := backend.Variables(demo.Id, demo.Environments[0].Id, demo.Services[0].ID)
+
+	if _, ok := envs["PORT"]; ok || envs["DEBUG"] != "1" {
+		t.Errorf("variables = %v, want only DEBUG", envs)
+	}
+}
+
+func TestVariablesSetInvalid(t *testing.T) {
+	h, _ := newFakeHandler(t)
+	linkDemo(t, h)
+
+	if err := h.VariablesSet(context.Background(), newRequest(t, variablesSetFlags, nil, "DEBUG")); err == nil {
+		t.Error("set a variable without a value")
+	}
+}
+
+func TestVariablesSetCassette(t *testing.T) {
+	h := newCassetteHandler(t, "variables-set")
+	linkDemo(t, h)
+
+	req := newRequest(t, variablesSetFlags, []string{"--service", "web"}, "DEBUG=1")
+	if err := h.VariablesSet(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,6 +1,7 @@
 package configs
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path"
@@ -43,6 +44,22 @@ func GetRailwayURL() string {
 	return url
 }
 
+// GetRailwayAPIURL returns the API URL override set with RAILWAY_API_URL, if any
+func GetRailwayAPIURL() (string, bool) {
+	url, exists := os.LookupEnv("RAILWAY_API_URL")
+
+	if !exists || url == "" {
+		return "", false
+	}
+
+	return url, true
+}
+
+// GetProfileName returns the endpoint profile selected with RAILWAY_PROFILE, if any
+func GetProfileName() string {
+	return os.Getenv("RAILWAY_PROFILE")
+}
+
 func (c *Configs) CreatePathIfNotExist(path string) error {
 	dir := filepath.Dir(path)
 
@@ -56,7 +73,31 @@ func (c *Configs) CreatePathIfNotExist(path string) error {
 	return nil
 }
 
+// CacheDir returns the directory cached API responses are stored in, next to
+// the root config, or "" when there is no root config
+func (c *Configs) CacheDir() string {
+	if c.rootConfigs.configPath == "" {
+		return ""
+	}
+
+	return filepath.Join(filepath.Dir(c.rootConfigs.configPath), "railway-cache")
+}
+
+// UploadsLogPath returns the file the digests of uploads are recorded in, next
+// to the root config, or "" when there is no root config
+func (c *Configs) UploadsLogPath() string {
+	if c.rootConfigs.configPath == "" {
+		return ""
+	}
+
+	return filepath.Join(filepath.Dir(c.rootConfigs.configPath), "railway-uploads.jsonl")
+}
+
 func (c *Configs) marshalConfig(config *Config, cfg interface{}) error {
+	if config.configPath == "" {
+		return errors.New("no home directory to store the config in")
+	}
+
 	reflectCfg := reflect.ValueOf(cfg)
 
 	for i := 0; i < reflectCfg.NumField(); i++ {
@@ -89,21 +130,22 @@ func New() *Configs {
 		rootConfigPartialPath = ".botway/railway-staging-config.json"
 	}
 
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		panic(err)
-	}
+	// Without a home directory, e.g. in some containers, there is no root
+	// config: reading it finds nothing and writing it fails
+	rootConfigPath := ""
 
-	rootConfigPath := path.Join(homeDir, rootConfigPartialPath)
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		rootConfigPath = path.Join(homeDir, rootConfigPartialPath)
 
-	rootViper.SetConfigFile(rootConfigPath)
+		rootViper.SetConfigFile(rootConfigPath)
 
-	err = rootViper.ReadInConfig()
+		err = rootViper.ReadInConfig()
 
-	if os.IsNotExist(err) {
-		// That's okay, configs are created as needed
-	} else if err != nil {
-		fmt.Printf("Unable to parse railway config! %s\n", err)
+		if os.IsNotExist(err) {
+			// That's okay, configs are created as needed
+		} else if err != nil {
+			fmt.Printf("Unable to parse railway config! %s\n", err)
+		}
 	}
 
 	rootConfig := &Config{
@@ -115,7 +157,7 @@ func New() *Configs {
 	// Includes projectId, environmentId, etc
 	projectDir, err := filepath.Abs("./.botway")
 	if err != nil {
-		panic(err)
+		projectDir = "./.botway"
 	}
 
 	projectViper := viper.New()
@@ -0,0 +1,74 @@
+package configs
+
+import (
+	"fmt"
+
+	"github.com/botwayorg/railway-api/entity"
+)
+
+// GetEndpointProfile returns the active endpoint profile. RAILWAY_PROFILE takes
+// precedence over the profile stored in the root config. A nil profile means
+// the default endpoints should be used
+func (c *Configs) GetEndpointProfile() (*entity.EndpointProfile, error) {
+	rootCfg, err := c.GetRootConfigs()
+
+	name := GetProfileName()
+
+	if err != nil {
+		if name != "" {
+			return nil, fmt.Errorf("endpoint profile %q not found", name)
+		}
+
+		// No root config yet, so no profiles either
+		return nil, nil
+	}
+
+	if name == "" {
+		name = rootCfg.Profile
+	}
+
+	if name == "" {
+		return nil, nil
+	}
+
+	profile, found := rootCfg.Profiles[name]
+
+	if !found {
+		return nil, fmt.Errorf("endpoint profile %q not found", name)
+	}
+
+	return &profile, nil
+}
+
+// SetEndpointProfile stores a named endpoint profile in the root config
+func (c *Configs) SetEndpointProfile(name string, profile *entity.EndpointProfile) error {
+	rootCfg, err := c.GetRootConfigs()
+	if err != nil {
+		rootCfg = &entity.RootConfig{}
+	}
+
+	if rootCfg.Profiles == nil {
+		rootCfg.Profiles = make(map[string]entity.EndpointProfile)
+	}
+
+	rootCfg.Profiles[name] = *profile
+
+	return c.SetRootConfig(rootCfg)
+}
+
+// UseEndpointProfile marks a stored profile as the active one. An empty name
+// switches back to the default endpoints
+func (c *Configs) UseEndpointProfile(name string) error {
+	rootCfg, err := c.GetRootConfigs()
+	if err != nil {
+		rootCfg = &entity.RootConfig{}
+	}
+
+	if _, found := rootCfg.Profiles[name]; name != "" && !found {
+		return fmt.Errorf("endpoint profile %q not found", name)
+	}
+
+	rootCfg.Profile = name
+
+	return c.SetRootConfig(rootCfg)
+}
@@ -160,3 +160,37 @@ func (c *Configs) GetCurrentEnvironment() (string, error) {
 
 	return projectCfg.Environment, nil
 }
+
+// SetSymlinkPolicy sets how uploads of the linked project treat symlinks, an
+// empty policy going back to the default
+func (c *Configs) SetSymlinkPolicy(policy entity.SymlinkPolicy) error {
+	projectCfg, err := c.GetProjectConfigs()
+	if err != nil {
+		return err
+	}
+
+	projectCfg.Symlinks = policy
+
+	return c.SetProjectConfigs(projectCfg)
+}
+
+// SetServicePaths sets the directories uploaded for a service of the linked
+// project, or forgets them when paths is nil
+func (c *Configs) SetServicePaths(service string, paths *entity.ServicePaths) error {
+	projectCfg, err := c.GetProjectConfigs()
+	if err != nil {
+		return err
+	}
+
+	if paths == nil {
+		delete(projectCfg.Services, service)
+	} else {
+		if projectCfg.Services == nil {
+			projectCfg.Services = make(map[string]entity.ServicePaths)
+		}
+
+		projectCfg.Services[service] = *paths
+	}
+
+	return c.SetProjectConfigs(projectCfg)
+}
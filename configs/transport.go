@@ -0,0 +1,39 @@
+package configs
+
+import (
+	"os"
+
+	"github.com/botwayorg/railway-api/entity"
+)
+
+// transportEnv maps the environment variables overriding the transport
+// settings of the root config to the fields they set
+var transportEnv = []struct {
+	name  string
+	field func(cfg *entity.TransportConfig) *string
+}{
+	{"RAILWAY_CA_FILE", func(cfg *entity.TransportConfig) *string { return &cfg.CAFile }},
+	{"RAILWAY_CLIENT_CERT", func(cfg *entity.TransportConfig) *string { return &cfg.ClientCert }},
+	{"RAILWAY_CLIENT_KEY", func(cfg *entity.TransportConfig) *string { return &cfg.ClientKey }},
+	{"RAILWAY_PROXY", func(cfg *entity.TransportConfig) *string { return &cfg.ProxyURL }},
+	{"RAILWAY_NO_PROXY", func(cfg *entity.TransportConfig) *string { return &cfg.NoProxy }},
+}
+
+// GetTransportConfig returns the transport settings of the root config, with
+// each RAILWAY_CA_FILE, RAILWAY_CLIENT_CERT, RAILWAY_CLIENT_KEY, RAILWAY_PROXY
+// and RAILWAY_NO_PROXY that is set taking precedence over its field
+func (c *Configs) GetTransportConfig() *entity.TransportConfig {
+	cfg := &entity.TransportConfig{}
+
+	if rootCfg, err := c.GetRootConfigs(); err == nil && rootCfg.Transport != nil {
+		*cfg = *rootCfg.Transport
+	}
+
+	for _, env := range transportEnv {
+		if value, ok := os.LookupEnv(env.name); ok {
+			*env.field(cfg) = value
+		}
+	}
+
+	return cfg
+}
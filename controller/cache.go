@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"sync"
+
+	"github.com/botwayorg/railway-api/entity"
+)
+
+// sessionCache holds what was fetched during a single command, so the project,
+// its environments and its services are only requested once. Anything that
+// mutates a project invalidates it
+type sessionCache struct {
+	mu             sync.Mutex
+	projectConfigs *entity.ProjectConfig
+	projects       map[string]*entity.Project
+}
+
+func newSessionCache() *sessionCache {
+	return &sessionCache{
+		projects: make(map[string]*entity.Project),
+	}
+}
+
+func (s *sessionCache) getProject(projectID string) (*entity.Project, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project, ok := s.projects[projectID]
+
+	return project, ok
+}
+
+func (s *sessionCache) setProject(project *entity.Project) {
+	if project == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.projects[project.Id] = project
+}
+
+func (s *sessionCache) getProjectConfigs() (*entity.ProjectConfig, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.projectConfigs, s.projectConfigs != nil
+}
+
+func (s *sessionCache) setProjectConfigs(cfg *entity.ProjectConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.projectConfigs = cfg
+}
+
+// invalidate drops every cached project. Project configs resolved from a
+// project token can't change during a command, so they are kept
+func (s *sessionCache) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.projects = make(map[string]*entity.Project)
+}
+
+// invalidateCaches drops everything cached about projects, in memory and on
+// disk
+func (c *Controller) invalidateCaches() {
+	c.cache.invalidate()
+	c.clearDiskCache()
+}
@@ -2,14 +2,16 @@ package controller
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/botwayorg/railway-api/entity"
-	"github.com/botwayorg/railway-api/ui"
 )
 
 func (c *Controller) GetProjectConfigs(ctx context.Context) (*entity.ProjectConfig, error) {
 	if c.cfg.RailwayProductionToken != "" {
+		if projectCfg, ok := c.cache.getProjectConfigs(); ok {
+			return projectCfg, nil
+		}
+
 		// Get project config from api
 		projectToken, err := c.gtwy.GetProjectToken(ctx)
 
@@ -18,11 +20,15 @@ func (c *Controller) GetProjectConfigs(ctx context.Context) (*entity.ProjectConf
 		}
 
 		if projectToken != nil {
-			return &entity.ProjectConfig{
+			projectCfg := &entity.ProjectConfig{
 				Project:         projectToken.ProjectId,
 				Environment:     projectToken.EnvironmentId,
 				LockedEnvsNames: map[string]bool{},
-			}, nil
+			}
+
+			c.cache.setProjectConfigs(projectCfg)
+
+			return projectCfg, nil
 		}
 	}
 
@@ -37,18 +43,19 @@ func (c *Controller) PromptIfProtectedEnvironment(ctx context.Context) error {
 	}
 
 	if val, ok := projectCfg.LockedEnvsNames[projectCfg.Environment]; ok && val {
-		fmt.Println(ui.Bold(ui.RedText("Protected Environment Detected!").String()))
-
-		confirm, err := ui.PromptYesNo("Continue?")
-
-		if err != nil {
+		if _, err := c.confirmProtectedEnvironment(ctx, "Continue?"); err != nil {
 			return err
 		}
-
-		if !confirm {
-			return nil
-		}
 	}
 
 	return nil
 }
+
+// confirmProtectedEnvironment asks before touching a protected environment,
+// and returns the answer
+func (c *Controller) confirmProtectedEnvironment(ctx context.Context, question string) (bool, error) {
+	return c.prompter.Confirm(ctx, &entity.ConfirmationRequest{
+		Reason:   "Protected Environment Detected!",
+		Question: question,
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/botwayorg/railway-api/entity"
+	"github.com/botwayorg/railway-api/gateway"
 )
 
 func (c *Controller) GetDeployments(ctx context.Context) ([]*entity.Deployment, error) {
@@ -16,6 +17,18 @@ func (c *Controller) GetDeployments(ctx context.Context) ([]*entity.Deployment,
 	return c.gtwy.GetDeploymentsForEnvironment(ctx, projectConfig.Project, projectConfig.Environment)
 }
 
+// IterateDeployments walks the deployments of the current environment, newest
+// first
+func (c *Controller) IterateDeployments(ctx context.Context) (*gateway.Iterator[*entity.Deployment], error) {
+	projectConfig, err := c.GetProjectConfigs(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return c.gtwy.IterateDeploymentsForEnvironment(projectConfig.Project, projectConfig.Environment), nil
+}
+
 func (c *Controller) GetActiveDeployment(ctx context.Context) (*entity.Deployment, error) {
 	projectConfig, err := c.GetProjectConfigs(ctx)
 
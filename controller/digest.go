@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/botwayorg/railway-api/entity"
+)
+
+// archiveModTime is the modification time of every file in archives, so that
+// the same sources give the same archive on any machine
+var archiveModTime = time.Unix(0, 0)
+
+// archiveMode is the mode files are archived and deployed with. Only whether
+// they are executable is kept, as umasks differ between machines
+func archiveMode(fileInfo fs.FileInfo) int64 {
+	switch {
+	case isSymlink(fileInfo):
+		return 0777
+	case fileInfo.Mode().Perm()&0111 != 0:
+		return 0755
+	default:
+		return 0644
+	}
+}
+
+// sourceDigest returns the SHA-256 of what an upload deploys: for every file
+// in byte order of path, its octal mode, the SHA-256 of its content or
+// "symlink:" and its target, and its path, separated by spaces and ended by a
+// NUL byte. It isn't the digest of the archive, whose bytes depend on the
+// gzip implementation, so archives and incremental uploads of the same
+// sources have the same digest
+func sourceDigest(files []entity.ManifestFile) string {
+	sorted := append([]entity.ManifestFile{}, files...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Path < sorted[j].Path
+	})
+
+	hash := sha256.New()
+
+	for _, file := range sorted {
+		content := file.SHA256
+		if file.Symlink != "" {
+			content = "symlink:" + file.Symlink
+		}
+
+		fmt.Fprintf(hash, "%o %s %s\x00", file.Mode, content, file.Path)
+	}
+
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil))
+}
+
+// recordUpload appends the digest of an upload to the local log of uploads,
+// so that deployments can later be matched with the sources they came from
+func (c *Controller) recordUpload(req *entity.UploadRequest, res *entity.UpResponse, files int) {
+	path := c.cfg.UploadsLogPath()
+	if path == "" {
+		return
+	}
+
+	rootDir, err := filepath.Abs(req.RootDir)
+	if err != nil {
+		rootDir = req.RootDir
+	}
+
+	var shared []string
+	for _, dir := range req.Shared {
+		shared = append(shared, dir.Name)
+	}
+
+	line, err := json.Marshal(&entity.UploadRecord{
+		Time:          time.Now().UTC(),
+		ProjectID:     req.ProjectID,
+		EnvironmentID: req.EnvironmentID,
+		ServiceID:     req.ServiceID,
+		RootDir:       rootDir,
+		Shared:        shared,
+		Files:         files,
+		Digest:        res.Digest,
+		URL:           res.URL,
+	})
+
+	if err != nil {
+		return
+	}
+
+	if err := appendLine(path, line); err != nil {
+		c.notice(entity.NoticeWarning, fmt.Sprintf("Couldn't record the digest of the upload in %s: %s", path, err))
+	}
+}
+
+func appendLine(path string, line []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,23 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/botwayorg/railway-api/entity"
+)
+
+func TestSourceDigest(t *testing.T) {
+	// The digest of a.txt holding "hi\n", as given by the shell recipe of
+	// CONTRIBUTING.md
+	const want = "sha256:76b53bf22a954012a2ba0f14b8def1bbd33654161836b334da39ae058f78701a"
+
+	got := sourceDigest([]entity.ManifestFile{{
+		Path:   "a.txt",
+		Mode:   0644,
+		SHA256: "98ea6e4f216f2fb4b69fff9b3a44842c38686ca685f3f55dc48c5d3fb1107be4",
+	}})
+
+	if got != want {
+		t.Errorf("digest = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	projectsCacheTTL = 5 * time.Minute
+	projectCacheTTL  = 2 * time.Minute
+	startersCacheTTL = 24 * time.Hour
+)
+
+// diskCache persists API responses between commands, so pickers and shell
+// completion can render without waiting on the network. Entries are kept per
+// API host and credentials, so switching accounts or endpoints never shows
+// someone else's data
+type diskCache struct {
+	dir     string
+	refresh bool
+}
+
+type diskCacheEntry struct {
+	StoredAt time.Time       `json:"storedAt"`
+	Data     json.RawMessage `json:"data"`
+}
+
+func newDiskCache(dir string) *diskCache {
+	return &diskCache{
+		dir: dir,
+	}
+}
+
+// cacheNamespace returns the directory holding entries for the current host and
+// credentials, or "" if there are no credentials to key them by
+func (c *Controller) cacheNamespace() string {
+	if c.disk.dir == "" {
+		return ""
+	}
+
+	token := c.cfg.RailwayProductionToken
+
+	if token == "" {
+		user, err := c.cfg.GetUserConfigs()
+		if err != nil {
+			return ""
+		}
+
+		token = user.Token
+	}
+
+	host, err := c.gtwy.Host()
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(host + "\n" + token))
+
+	return filepath.Join(c.disk.dir, hex.EncodeToString(sum[:8]))
+}
+
+// readDiskCache loads key into v if it was stored less than ttl ago
+func (c *Controller) readDiskCache(key string, ttl time.Duration, v interface{}) bool {
+	if c.disk.refresh {
+		return false
+	}
+
+	namespace := c.cacheNamespace()
+	if namespace == "" {
+		return false
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(namespace, key+".json"))
+	if err != nil {
+		return false
+	}
+
+	var entry diskCacheEntry
+
+	if err := json.Unmarshal(b, &entry); err != nil || time.Since(entry.StoredAt) > ttl {
+		return false
+	}
+
+	return json.Unmarshal(entry.Data, v) == nil
+}
+
+// writeDiskCache stores v under key. Failing to write the cache is not worth
+// failing the command over, so errors are ignored
+func (c *Controller) writeDiskCache(key string, v interface{}) {
+	namespace := c.cacheNamespace()
+	if namespace == "" {
+		return
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	b, err := json.Marshal(&diskCacheEntry{
+		StoredAt: time.Now(),
+		Data:     data,
+	})
+
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(namespace, 0700); err != nil {
+		return
+	}
+
+	_ = ioutil.WriteFile(filepath.Join(namespace, key+".json"), b, 0600)
+}
+
+// clearDiskCache drops every entry for the current host and credentials
+func (c *Controller) clearDiskCache() {
+	if namespace := c.cacheNamespace(); namespace != "" {
+		_ = os.RemoveAll(namespace)
+	}
+}
+
+// Refresh makes the controller ignore cached responses, fetching and caching
+// everything again
+func (c *Controller) Refresh(refresh bool) {
+	c.disk.refresh = refresh
+}
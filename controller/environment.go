@@ -52,13 +52,19 @@ func (c *Controller) GetEnvironmentByName(ctx context.Context, environmentName s
 }
 
 func (c *Controller) CreateEnvironment(ctx context.Context, req *entity.CreateEnvironmentRequest) (*entity.Environment, error) {
+	defer c.invalidateCaches()
+
 	return c.gtwy.CreateEnvironment(ctx, req)
 }
 
 func (c *Controller) CreateEphemeralEnvironment(ctx context.Context, req *entity.CreateEphemeralEnvironmentRequest) (*entity.Environment, error) {
+	defer c.invalidateCaches()
+
 	return c.gtwy.CreateEphemeralEnvironment(ctx, req)
 }
 
 func (c *Controller) DeleteEnvironment(ctx context.Context, req *entity.DeleteEnvironmentRequest) error {
+	defer c.invalidateCaches()
+
 	return c.gtwy.DeleteEnvironment(ctx, req)
 }
@@ -9,7 +9,6 @@ import (
 
 	"github.com/botwayorg/railway-api/entity"
 	CLIErrors "github.com/botwayorg/railway-api/errors"
-	"github.com/botwayorg/railway-api/ui"
 	"github.com/joho/godotenv"
 )
 
@@ -49,7 +48,7 @@ func (c *Controller) GetEnvs(ctx context.Context, environment *entity.Environmen
 	}
 
 	if serviceId == "" {
-		service, err := ui.PromptServices(project.Services)
+		service, err := c.prompter.SelectService(ctx, project.Services)
 		if err != nil {
 			return nil, err
 		}
@@ -60,10 +59,7 @@ func (c *Controller) GetEnvs(ctx context.Context, environment *entity.Environmen
 	}
 
 	if val, ok := projectCfg.LockedEnvsNames[environment.Id]; ok && val {
-		fmt.Println(ui.Bold(ui.RedText("Protected Environment Detected!").String()))
-
-		confirm, err := ui.PromptYesNo("Continue fetching variables?")
-
+		confirm, err := c.confirmProtectedEnvironment(ctx, "Continue fetching variables?")
 		if err != nil {
 			return nil, err
 		}
@@ -91,7 +87,10 @@ func (c *Controller) AutoImportDotEnv(ctx context.Context) error {
 
 	if _, err := os.Stat(envFileLocation); err == nil {
 		// path/to/whatever does not exist
-		shouldImportEnvs, err := ui.PromptYesNo("\n.env detected!\nImport your variables into Railway?")
+		shouldImportEnvs, err := c.prompter.Confirm(ctx, &entity.ConfirmationRequest{
+			Reason:   ".env detected!",
+			Question: "Import your variables into Railway?",
+		})
 
 		if err != nil {
 			return err
@@ -179,7 +178,7 @@ func (c *Controller) UpdateEnvs(ctx context.Context, envs *entity.Envs, serviceN
 	}
 
 	if serviceID == "" {
-		service, err := ui.PromptServices(project.Services)
+		service, err := c.prompter.SelectService(ctx, project.Services)
 		if err != nil {
 			return err
 		}
@@ -242,7 +241,7 @@ func (c *Controller) DeleteEnvs(ctx context.Context, names []string, serviceName
 	}
 
 	if serviceID == "" {
-		service, err := ui.PromptServices(project.Services)
+		service, err := c.prompter.SelectService(ctx, project.Services)
 		if err != nil {
 			return err
 		}
@@ -3,12 +3,12 @@ package controller
 import (
 	"context"
 	"errors"
-	"fmt"
 	"math"
 	"strings"
 	"time"
 
 	"github.com/botwayorg/railway-api/entity"
+	"github.com/botwayorg/railway-api/gateway"
 )
 
 const (
@@ -52,15 +52,12 @@ Logs for state will get logs for a current state (Either building or not buildin
 	It does this by capturing the initial state of the deploy, and looping while it stays in that state
 	The loop captures the previous deploy as well as the current and does log diffing on the unified state
 	When the state transitions from building to not building, the loop breaks
+	The backboard schema has no subscriptions to push logs yet, so they are polled
 */
 func (c *Controller) logsForState(ctx context.Context, req *entity.DeploymentLogsRequest) error {
 	// Stream on building -> Building until !Building then break
 	// Stream on not building -> !Building until Failed then break
-	deploy, err := c.gtwy.GetDeploymentByID(ctx, &entity.DeploymentByIDRequest{
-		DeploymentID: req.DeploymentID,
-		ProjectID:    req.ProjectID,
-		GQL:          c.getQuery(ctx, ""),
-	})
+	deploy, err := c.gtwy.GetDeploymentByID(ctx, deploymentRequest(req, ""))
 
 	if err != nil {
 		return err
@@ -82,28 +79,31 @@ func (c *Controller) logsForState(ctx context.Context, req *entity.DeploymentLog
 	}
 
 	// Output Initial Logs
-	currLogs := strings.Join(logLines[int(offset):], "\n")
-
-	if len(currLogs) > 0 {
-		fmt.Println(currLogs)
+	if initialLogs := logLines[int(offset):]; len(strings.Join(initialLogs, "\n")) > 0 {
+		c.reportLogLines(initialLogs)
 	}
 
 	if deploy.Status == entity.STATUS_FAILED {
 		return errors.New("build Failed! Please see output for more information")
 	}
 
-	prevDeploy := deploy
+	if req.NumLines != 0 {
+		return nil
+	}
+
 	logState := deploy.Status
-	deltaState := hasTransitioned(nil, deploy)
+	// Logs end with a newline, so the last entry is never a complete line
+	seen := len(logLines) - 1
+
+	prevDeploy := deploy
+	deltaState := false
 
-	for !deltaState && req.NumLines == 0 {
-		time.Sleep(2 * time.Second)
+	for !deltaState {
+		if err := gateway.Sleep(ctx, 2*time.Second); err != nil {
+			return err
+		}
 
-		currDeploy, err := c.gtwy.GetDeploymentByID(ctx, &entity.DeploymentByIDRequest{
-			DeploymentID: req.DeploymentID,
-			ProjectID:    req.ProjectID,
-			GQL:          c.getQuery(ctx, logState),
-		})
+		currDeploy, err := c.gtwy.GetDeploymentByID(ctx, deploymentRequest(req, logState))
 
 		if err != nil {
 			return err
@@ -111,34 +111,44 @@ func (c *Controller) logsForState(ctx context.Context, req *entity.DeploymentLog
 
 		// Current Logs fetched from server
 		currLogs := strings.Split(logsForState(ctx, logState, currDeploy), "\n")
-		// Previous logs fetched from prevDeploy variable
-		prevLogs := strings.Split(logsForState(ctx, logState, prevDeploy), "\n")
-		// Diff logs using the line numbers as references
-		logDiff := currLogs[len(prevLogs)-1 : len(currLogs)-1]
-		// If no changes we continue
-		if len(logDiff) == 0 {
+		deltaState = hasTransitioned(prevDeploy, currDeploy)
+		prevDeploy = currDeploy
+
+		// Diff logs using the number of lines seen so far
+		if len(currLogs)-1 <= seen {
 			continue
 		}
 
 		// Output logs
-		fmt.Println(strings.Join(logDiff, "\n"))
+		c.reportLogLines(currLogs[seen : len(currLogs)-1])
 		// Set out walk pointer forward using the newest logs
-		deltaState = hasTransitioned(prevDeploy, currDeploy)
-		prevDeploy = currDeploy
+		seen = len(currLogs) - 1
 	}
 
 	return nil
 }
 
+// reportLogLines reports log lines, unless there are none
+func (c *Controller) reportLogLines(lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+
+	c.reporter.Report(entity.LogLinesEvent{Lines: lines})
+}
+
 func hasTransitioned(prev *entity.Deployment, curr *entity.Deployment) bool {
 	return prev != nil && curr != nil && prev.Status != curr.Status
 }
 
-func (c *Controller) getQuery(ctx context.Context, status string) entity.DeploymentGQL {
-	return entity.DeploymentGQL{
-		BuildLogs:  status == entity.STATUS_BUILDING || status == "",
-		DeployLogs: status != entity.STATUS_BUILDING || status == "",
-		Status:     true,
+// deploymentRequest fetches the logs for the given status, or all logs when
+// the status isn't known yet
+func deploymentRequest(req *entity.DeploymentLogsRequest, status string) *entity.DeploymentByIDRequest {
+	return &entity.DeploymentByIDRequest{
+		DeploymentID: req.DeploymentID,
+		ProjectID:    req.ProjectID,
+		BuildLogs:    status == entity.STATUS_BUILDING || status == "",
+		DeployLogs:   status != entity.STATUS_BUILDING || status == "",
 	}
 }
 
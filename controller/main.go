@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"time"
+
 	"github.com/botwayorg/railway-api/configs"
 	"github.com/botwayorg/railway-api/gateway"
 	"github.com/botwayorg/railway-api/random"
@@ -12,13 +14,57 @@ type Controller struct {
 	cfg        *configs.Configs
 	randomizer *random.Randomizer
 	ghc        *github.Client
+	cache      *sessionCache
+	disk       *diskCache
+	reporter   Reporter
+	prompter   Prompter
 }
 
 func New() *Controller {
+	return NewWithGateway(gateway.New())
+}
+
+// NewWithGateway returns a controller sending its requests through gtwy, for
+// programs that configure the gateway themselves, like tests against the fake
+// backend
+func NewWithGateway(gtwy *gateway.Gateway) *Controller {
+	cfg := configs.New()
+
 	return &Controller{
-		gtwy:       gateway.New(),
-		cfg:        configs.New(),
+		gtwy:       gtwy,
+		cfg:        cfg,
 		randomizer: random.New(),
 		ghc:        github.NewClient(nil),
+		cache:      newSessionCache(),
+		disk:       newDiskCache(cfg.CacheDir()),
+		reporter:   discardReporter{},
+		prompter:   noPrompter{},
+	}
+}
+
+// Trace records every gateway request with the given tracer, or stops when nil
+func (c *Controller) Trace(tracer *gateway.Tracer) {
+	c.gtwy.SetTracer(tracer)
+}
+
+// UseCassette records or replays the gateway traffic with cassette, or stops
+// when nil. Cached projects and templates are ignored meanwhile, so that every
+// request ends up in the cassette
+func (c *Controller) UseCassette(cassette *gateway.Cassette) {
+	c.gtwy.SetCassette(cassette)
+
+	if cassette != nil {
+		c.Refresh(true)
 	}
 }
+
+// ConfigureTransport applies the TLS and proxy settings of the environment and
+// the root config to the gateway
+func (c *Controller) ConfigureTransport() error {
+	return c.gtwy.ConfigureTransport(c.cfg.GetTransportConfig())
+}
+
+// RequestTimeout bounds every single gateway request, zero meaning no limit
+func (c *Controller) RequestTimeout(timeout time.Duration) {
+	c.gtwy.SetRequestTimeout(timeout)
+}
@@ -0,0 +1,345 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/botwayorg/railway-api/entity"
+	"github.com/botwayorg/railway-api/gateway"
+)
+
+const (
+	// uploadManifestTTL is how long the hashes of the last upload of a
+	// service are trusted
+	uploadManifestTTL = 30 * 24 * time.Hour
+	// uploadBlobWorkers is how many files are sent at once
+	uploadBlobWorkers = 4
+)
+
+// uploadManifest remembers the files last uploaded to a service, so that
+// those that didn't change since aren't hashed again
+type uploadManifest struct {
+	Files map[string]uploadManifestEntry `json:"files"`
+}
+
+type uploadManifestEntry struct {
+	Size int64 `json:"size"`
+	// ModTime and HashedAt are in Unix nanoseconds
+	ModTime  int64  `json:"modTime"`
+	HashedAt int64  `json:"hashedAt"`
+	SHA256   string `json:"sha256"`
+}
+
+// manifestFile is a file of the project to upload
+type manifestFile struct {
+	entity.ManifestFile
+
+	// path is where the file is read from, symlinks being resolved
+	path    string
+	modTime time.Time
+	// hashedAt is when the file was read to hash it
+	hashedAt time.Time
+}
+
+func uploadManifestKey(req *entity.UploadRequest) string {
+	serviceID := req.ServiceID
+	if serviceID == "" {
+		serviceID = "default"
+	}
+
+	return fmt.Sprintf("upload-%s-%s-%s", req.ProjectID, req.EnvironmentID, serviceID)
+}
+
+// sendManifest hashes the files of the upload, sends those the server is
+// missing, then deploys the manifest of all of them
+func (c *Controller) sendManifest(ctx context.Context, req *entity.UploadRequest, uploadFiles []uploadFile, progress *uploadProgress) (*entity.UpResponse, error) {
+	var previous uploadManifest
+	c.readDiskCache(uploadManifestKey(req), uploadManifestTTL, &previous)
+
+	files, err := hashProject(ctx, uploadFiles, previous)
+	if err != nil {
+		return nil, err
+	}
+
+	// Files with the same content share a blob
+	byHash := make(map[string]*manifestFile)
+	hashes := []string{}
+
+	for _, file := range files {
+		// Preserved symlinks have no content
+		if file.Symlink != "" {
+			continue
+		}
+
+		if _, ok := byHash[file.SHA256]; !ok {
+			byHash[file.SHA256] = file
+			hashes = append(hashes, file.SHA256)
+		}
+	}
+
+	missing, err := c.gtwy.MissingBlobs(ctx, &entity.MissingBlobsRequest{
+		ProjectID:     req.ProjectID,
+		EnvironmentID: req.EnvironmentID,
+		Hashes:        hashes,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	missingFiles, err := blobFiles(byHash, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	var totalBytes int64
+
+	reused := 0
+	missingHashes := make(map[string]bool)
+
+	for _, file := range missingFiles {
+		totalBytes += file.Size
+		missingHashes[file.SHA256] = true
+	}
+
+	for _, file := range files {
+		if file.Symlink == "" && !missingHashes[file.SHA256] {
+			reused++
+		}
+	}
+
+	progress.startSendingMissing(reused, totalBytes)
+
+	if err := c.uploadBlobs(ctx, req, missingFiles, progress); err != nil {
+		return nil, err
+	}
+
+	manifest := &entity.UpManifestRequest{
+		ProjectID:     req.ProjectID,
+		EnvironmentID: req.EnvironmentID,
+		ServiceID:     req.ServiceID,
+		Files:         make([]entity.ManifestFile, len(files)),
+	}
+
+	for i, file := range files {
+		manifest.Files[i] = file.ManifestFile
+	}
+
+	res, err := c.gtwy.UpManifest(ctx, manifest)
+
+	// Blobs can expire between asking and deploying, send them again once
+	var missingErr *gateway.MissingBlobsError
+	if errors.As(err, &missingErr) {
+		missingFiles, blobErr := blobFiles(byHash, missingErr.Hashes)
+		if blobErr != nil {
+			return nil, blobErr
+		}
+
+		if err := c.uploadBlobs(ctx, req, missingFiles, progress); err != nil {
+			return nil, err
+		}
+
+		res, err = c.gtwy.UpManifest(ctx, manifest)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	c.writeDiskCache(uploadManifestKey(req), newUploadManifest(files))
+
+	res.Digest = sourceDigest(manifest.Files)
+	c.recordUpload(req, res, len(files))
+
+	return res, nil
+}
+
+// hashProject returns the files of the upload with their hashes. Files whose
+// size and modification time didn't change since the previous manifest
+// aren't read again, unless they were modified right before being hashed,
+// when a later change could have kept the same modification time
+func hashProject(ctx context.Context, uploadFiles []uploadFile, previous uploadManifest) ([]*manifestFile, error) {
+	files := make([]*manifestFile, 0, len(uploadFiles))
+
+	for _, listed := range uploadFiles {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		path, name, fileInfo := listed.path, listed.name, listed.fileInfo
+
+		if isSymlink(fileInfo) {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return nil, err
+			}
+
+			files = append(files, &manifestFile{
+				ManifestFile: entity.ManifestFile{Path: name, Mode: archiveMode(fileInfo), Symlink: target},
+				path:         path,
+			})
+
+			continue
+		}
+
+		file := &manifestFile{
+			ManifestFile: entity.ManifestFile{
+				Path: name,
+				Size: fileInfo.Size(),
+				Mode: archiveMode(fileInfo),
+			},
+			path:    path,
+			modTime: fileInfo.ModTime(),
+		}
+
+		entry, ok := previous.Files[name]
+		if ok && entry.Size == file.Size && entry.ModTime == file.modTime.UnixNano() && entry.HashedAt-entry.ModTime > int64(time.Second) {
+			file.SHA256 = entry.SHA256
+			file.hashedAt = time.Unix(0, entry.HashedAt)
+		} else {
+			file.hashedAt = time.Now()
+
+			hash, err := hashFile(path)
+			if err != nil {
+				return nil, err
+			}
+
+			file.SHA256 = hash
+		}
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+
+	defer f.Close()
+
+	hash := sha256.New()
+
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func newUploadManifest(files []*manifestFile) *uploadManifest {
+	manifest := &uploadManifest{Files: make(map[string]uploadManifestEntry, len(files))}
+
+	for _, file := range files {
+		if file.Symlink != "" {
+			continue
+		}
+
+		manifest.Files[file.Path] = uploadManifestEntry{
+			Size:     file.Size,
+			ModTime:  file.modTime.UnixNano(),
+			HashedAt: file.hashedAt.UnixNano(),
+			SHA256:   file.SHA256,
+		}
+	}
+
+	return manifest
+}
+
+// blobFiles returns a file holding the content of each hash
+func blobFiles(byHash map[string]*manifestFile, hashes []string) ([]*manifestFile, error) {
+	files := make([]*manifestFile, 0, len(hashes))
+
+	for _, hash := range hashes {
+		file, ok := byHash[hash]
+		if !ok {
+			return nil, fmt.Errorf("the server asked for %s, which isn't part of the upload", hash)
+		}
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// uploadBlobs sends the content of files a few at a time, stopping at the
+// first failure
+func (c *Controller) uploadBlobs(ctx context.Context, req *entity.UploadRequest, files []*manifestFile, progress *uploadProgress) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	work := make(chan *manifestFile)
+	errs := make(chan error, uploadBlobWorkers)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < uploadBlobWorkers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for file := range work {
+				if err := c.uploadBlob(ctx, req, file, progress); err != nil {
+					errs <- err
+					cancel()
+
+					return
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, file := range files {
+		select {
+		case work <- file:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+
+	close(work)
+	wg.Wait()
+	close(errs)
+
+	// The first error caused the others
+	if err, ok := <-errs; ok {
+		return err
+	}
+
+	return ctx.Err()
+}
+
+func (c *Controller) uploadBlob(ctx context.Context, req *entity.UploadRequest, file *manifestFile, progress *uploadProgress) error {
+	f, err := os.Open(file.path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	err = c.gtwy.UploadBlob(ctx, &entity.UploadBlobRequest{
+		ProjectID:     req.ProjectID,
+		EnvironmentID: req.EnvironmentID,
+		SHA256:        file.SHA256,
+		Size:          file.Size,
+		Data:          progress.blobReader(io.LimitReader(f, file.Size)),
+	})
+
+	if err != nil {
+		return fmt.Errorf("uploading %s: %w", file.Path, err)
+	}
+
+	return nil
+}
@@ -2,17 +2,12 @@ package controller
 
 import (
 	"context"
-	"fmt"
-	"os"
 
 	"github.com/botwayorg/railway-api/constants"
 	"github.com/botwayorg/railway-api/entity"
-	"github.com/botwayorg/railway-api/ui"
 )
 
 func (c *Controller) SendPanic(ctx context.Context, panicErr string, stacktrace string, command string) (bool, error) {
-	confirmSendPanic()
-
 	projectCfg, err := c.cfg.GetProjectConfigs()
 
 	if err != nil {
@@ -36,12 +31,3 @@ func (c *Controller) SendPanic(ctx context.Context, panicErr string, stacktrace
 	})
 
 }
-
-func confirmSendPanic() {
-	fmt.Printf("🚨 Looks like something derailed, Press Enter to send error logs (^C to quit)")
-	fmt.Fscanln(os.Stdin)
-
-	ui.StartSpinner(&ui.SpinnerCfg{
-		Message: "Taking notes...",
-	})
-}
@@ -7,6 +7,8 @@ import (
 )
 
 func (c *Controller) CreatePlugin(ctx context.Context, req *entity.CreatePluginRequest) (*entity.Plugin, error) {
+	defer c.invalidateCaches()
+
 	return c.gtwy.CreatePlugin(ctx, req)
 }
 
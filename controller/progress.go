@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/botwayorg/railway-api/entity"
+)
+
+// uploadProgressInterval is how often the progress of an upload is reported
+const uploadProgressInterval = 200 * time.Millisecond
+
+// uploadProgress counts what an upload went through so far. The archiving
+// goroutine and the HTTP client update it while reportUploadProgress reads
+// it, so every field is accessed atomically
+type uploadProgress struct {
+	phase         int32
+	incremental   int32
+	filesIncluded int64
+	filesIgnored  int64
+	filesReused   int64
+	// filesLeftOut are the ignored files no pattern ignores, like symlinks
+	// leaving the project
+	filesLeftOut  int64
+	totalBytes    int64
+	archivedBytes int64
+	uploadedBytes int64
+	// sendingSince is the UnixNano time the sending phase started at
+	sendingSince int64
+}
+
+func newUploadProgress() *uploadProgress {
+	return &uploadProgress{phase: int32(entity.UploadScanning)}
+}
+
+func (p *uploadProgress) setPhase(phase entity.UploadPhase) {
+	if phase == entity.UploadSending {
+		atomic.StoreInt64(&p.sendingSince, time.Now().UnixNano())
+	}
+
+	atomic.StoreInt32(&p.phase, int32(phase))
+}
+
+func (p *uploadProgress) fileIncluded(size int64) {
+	atomic.AddInt64(&p.filesIncluded, 1)
+	atomic.AddInt64(&p.totalBytes, size)
+}
+
+func (p *uploadProgress) fileIgnored(file entity.UploadPlanFile) {
+	atomic.AddInt64(&p.filesIgnored, 1)
+
+	if file.Reason != "" {
+		atomic.AddInt64(&p.filesLeftOut, 1)
+	}
+}
+
+// startSendingMissing switches to sending the files the server is missing,
+// the total being their size
+func (p *uploadProgress) startSendingMissing(reused int, totalBytes int64) {
+	atomic.StoreInt32(&p.incremental, 1)
+	atomic.StoreInt64(&p.filesReused, int64(reused))
+	atomic.StoreInt64(&p.totalBytes, totalBytes)
+	p.setPhase(entity.UploadSending)
+}
+
+// archiveWriter counts the bytes of files written to w
+func (p *uploadProgress) archiveWriter(w io.Writer) io.Writer {
+	return &countingWriter{w: w, n: &p.archivedBytes}
+}
+
+// uploadReader counts the bytes of the archive read from r by the HTTP client
+func (p *uploadProgress) uploadReader(r io.Reader) io.Reader {
+	return &countingReader{r: r, n: &p.uploadedBytes}
+}
+
+// blobReader counts the bytes of a file read from r by the HTTP client, which
+// are sent as they are
+func (p *uploadProgress) blobReader(r io.Reader) io.Reader {
+	return &countingReader{r: &countingReader{r: r, n: &p.archivedBytes}, n: &p.uploadedBytes}
+}
+
+func (p *uploadProgress) event() entity.UploadProgressEvent {
+	event := entity.UploadProgressEvent{
+		Phase:         entity.UploadPhase(atomic.LoadInt32(&p.phase)),
+		FilesIncluded: int(atomic.LoadInt64(&p.filesIncluded)),
+		FilesIgnored:  int(atomic.LoadInt64(&p.filesIgnored)),
+		Incremental:   atomic.LoadInt32(&p.incremental) == 1,
+		FilesReused:   int(atomic.LoadInt64(&p.filesReused)),
+		TotalBytes:    atomic.LoadInt64(&p.totalBytes),
+		ArchivedBytes: atomic.LoadInt64(&p.archivedBytes),
+		UploadedBytes: atomic.LoadInt64(&p.uploadedBytes),
+	}
+
+	if since := atomic.LoadInt64(&p.sendingSince); since != 0 {
+		event.Elapsed = time.Since(time.Unix(0, since))
+	}
+
+	return event
+}
+
+// reportUploadProgress reports the progress of an upload until the returned
+// function is called, which reports how the upload ended
+func (c *Controller) reportUploadProgress(progress *uploadProgress) func(succeeded bool) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(uploadProgressInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				c.reporter.Report(progress.event())
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func(succeeded bool) {
+		close(done)
+		<-stopped
+
+		if succeeded {
+			progress.setPhase(entity.UploadFinished)
+		} else {
+			progress.setPhase(entity.UploadFailed)
+		}
+
+		c.reporter.Report(progress.event())
+
+		if leftOut := atomic.LoadInt64(&progress.filesLeftOut); succeeded && leftOut > 0 {
+			c.notice(entity.NoticeWarning, fmt.Sprintf("%d paths no ignore rule covers were left out of the upload, like symlinks leading out of the project or back into it. Run up --dry-run --explain to see why", leftOut))
+		}
+	}
+}
+
+type countingWriter struct {
+	w io.Writer
+	n *int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	atomic.AddInt64(w.n, int64(n))
+
+	return n, err
+}
+
+type countingReader struct {
+	r io.Reader
+	n *int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	atomic.AddInt64(r.n, int64(n))
+
+	return n, err
+}
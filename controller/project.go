@@ -3,9 +3,9 @@ package controller
 import (
 	"context"
 	CLIErrors "github.com/botwayorg/railway-api/errors"
-	"github.com/botwayorg/railway-api/ui"
 
 	"github.com/botwayorg/railway-api/entity"
+	"github.com/botwayorg/railway-api/gateway"
 )
 
 // GetCurrentProject returns the currently active project
@@ -23,9 +23,39 @@ func (c *Controller) GetCurrentProject(ctx context.Context) (*entity.Project, er
 	return project, nil
 }
 
-// GetProject returns a project of id projectId, error otherwise
+// GetProject returns a project of id projectId, error otherwise. It is fetched
+// once per command, and never read from the disk cache, as commands acting on
+// its services and environments need them up to date
 func (c *Controller) GetProject(ctx context.Context, projectId string) (*entity.Project, error) {
-	return c.gtwy.GetProject(ctx, projectId)
+	if project, ok := c.cache.getProject(projectId); ok {
+		return project, nil
+	}
+
+	project, err := c.gtwy.GetProject(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+
+	c.writeDiskCache("project-"+projectId, project)
+	c.cache.setProject(project)
+
+	return project, nil
+}
+
+// GetCachedProject is GetProject for prompts and shell completion, which
+// rather show what was fetched a little while ago than wait on the network
+func (c *Controller) GetCachedProject(ctx context.Context, projectId string) (*entity.Project, error) {
+	if project, ok := c.cache.getProject(projectId); ok {
+		return project, nil
+	}
+
+	var project *entity.Project
+
+	if c.readDiskCache("project-"+projectId, projectCacheTTL, &project) && project != nil {
+		return project, nil
+	}
+
+	return c.GetProject(ctx, projectId)
 }
 
 // GetProjectByName returns a project for the user of name projectName, error otherwise
@@ -65,7 +95,7 @@ func (c *Controller) GetServiceIdByName(ctx context.Context, serviceName *string
 	}
 
 	if serviceID == "" {
-		service, err := ui.PromptServices(project.Services)
+		service, err := c.prompter.SelectService(ctx, project.Services)
 
 		if err != nil {
 			return nil, err
@@ -81,22 +111,53 @@ func (c *Controller) GetServiceIdByName(ctx context.Context, serviceName *string
 
 // CreateProject creates a project specified by the project request, error otherwise
 func (c *Controller) CreateProject(ctx context.Context, req *entity.CreateProjectRequest) (*entity.Project, error) {
+	defer c.invalidateCaches()
+
 	return c.gtwy.CreateProject(ctx, req)
 }
 
 // CreateProjectFromTemplate creates a project from template specified by the project request, error otherwise
 func (c *Controller) CreateProjectFromTemplate(ctx context.Context, req *entity.CreateProjectFromTemplateRequest) (*entity.CreateProjectFromTemplateResult, error) {
+	defer c.invalidateCaches()
+
 	return c.gtwy.CreateProjectFromTemplate(ctx, req)
 }
 
 // UpdateProject updates a project specified by the project request, error otherwise
 func (c *Controller) UpdateProject(ctx context.Context, req *entity.UpdateProjectRequest) (*entity.Project, error) {
+	defer c.invalidateCaches()
+
 	return c.gtwy.UpdateProject(ctx, req)
 }
 
 // GetProjects returns all projects associated with the user, error otherwise
 func (c *Controller) GetProjects(ctx context.Context) ([]*entity.Project, error) {
-	return c.gtwy.GetProjects(ctx)
+	projects, err := c.gtwy.GetProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.writeDiskCache("projects", projects)
+
+	return projects, nil
+}
+
+// GetCachedProjects is GetProjects for prompts and shell completion, reading
+// the disk cache first
+func (c *Controller) GetCachedProjects(ctx context.Context) ([]*entity.Project, error) {
+	var projects []*entity.Project
+
+	if c.readDiskCache("projects", projectsCacheTTL, &projects) {
+		return projects, nil
+	}
+
+	return c.GetProjects(ctx)
+}
+
+// IterateProjects walks the projects of the user. Unlike GetCachedProjects it
+// never reads from the cache
+func (c *Controller) IterateProjects(ctx context.Context) *gateway.Iterator[*entity.Project] {
+	return c.gtwy.IterateProjects()
 }
 
 // OpenProjectInBrowser opens the provided projectId in the browser
@@ -134,5 +195,7 @@ func (c *Controller) OpenStaticUrlInBrowser(staticUrl string) error {
 }
 
 func (c *Controller) DeleteProject(ctx context.Context, projectID string) error {
+	defer c.invalidateCaches()
+
 	return c.gtwy.DeleteProject(ctx, projectID)
 }
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"context"
+	"errors"
+
+	"github.com/botwayorg/railway-api/entity"
+)
+
+// Reporter renders the events of controller operations. It may be called from
+// several goroutines
+type Reporter interface {
+	Report(event entity.Event)
+}
+
+// Prompter answers the questions controller operations can't answer on their
+// own
+type Prompter interface {
+	// Confirm asks a yes/no question
+	Confirm(ctx context.Context, req *entity.ConfirmationRequest) (bool, error)
+	// SelectService picks one of the services of a project, or none with a
+	// zero value service
+	SelectService(ctx context.Context, services []*entity.Service) (*entity.Service, error)
+	// Acknowledge waits for the user to be ready for what the message
+	// announces
+	Acknowledge(ctx context.Context, message string) error
+}
+
+// ErrNoPrompter is returned by operations needing an answer from the user when
+// the controller has no prompter
+var ErrNoPrompter = errors.New("the operation needs an answer from the user, but there is no prompter")
+
+// SetReporter sends the events of controller operations to reporter. Events
+// are dropped when it is nil
+func (c *Controller) SetReporter(reporter Reporter) {
+	if reporter == nil {
+		reporter = discardReporter{}
+	}
+
+	c.reporter = reporter
+}
+
+// SetPrompter lets controller operations ask the user through prompter. When
+// it is nil, operations that must ask fail with ErrNoPrompter
+func (c *Controller) SetPrompter(prompter Prompter) {
+	if prompter == nil {
+		prompter = noPrompter{}
+	}
+
+	c.prompter = prompter
+}
+
+func (c *Controller) notice(level entity.NoticeLevel, message string) {
+	c.reporter.Report(entity.NoticeEvent{Level: level, Message: message})
+}
+
+type discardReporter struct{}
+
+func (discardReporter) Report(entity.Event) {}
+
+type noPrompter struct{}
+
+func (noPrompter) Confirm(context.Context, *entity.ConfirmationRequest) (bool, error) {
+	return false, ErrNoPrompter
+}
+
+// SelectService only picks when there is nothing to choose from
+func (noPrompter) SelectService(_ context.Context, services []*entity.Service) (*entity.Service, error) {
+	switch len(services) {
+	case 0:
+		return &entity.Service{}, nil
+	case 1:
+		return services[0], nil
+	}
+
+	return nil, ErrNoPrompter
+}
+
+func (noPrompter) Acknowledge(context.Context, string) error {
+	return nil
+}
@@ -0,0 +1,316 @@
+package controller
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/botwayorg/railway-api/entity"
+	"github.com/botwayorg/railway-api/lib/gitignore"
+)
+
+const (
+	// defaultMaxFileSize is the size above which files are flagged, unless
+	// another one is given
+	defaultMaxFileSize = 100 << 20
+	// secretScanLimit is how much of a file is searched for secrets
+	secretScanLimit = 1 << 20
+	// binaryScanLimit is how much of a file is looked at to tell whether it
+	// is binary, like git does
+	binaryScanLimit = 8000
+	// allowFile is the ignore file whose "!" patterns allow flagged files
+	allowFile = ".railwayignore"
+)
+
+// secretFiles are ignore-style patterns of files that usually hold secrets,
+// the last matching one deciding like in ignore files
+var secretFiles = []struct {
+	pattern string
+	reason  string
+}{
+	{".env", "environment file"},
+	{".env.*", "environment file"},
+	{"!.env.example", ""},
+	{"!.env.sample", ""},
+	{"!.env.template", ""},
+	{"*.pem", "PEM certificate or key"},
+	{"*.key", "private key file"},
+	{"*.p12", "PKCS #12 key store"},
+	{"*.pfx", "PKCS #12 key store"},
+	{"*.jks", "Java key store"},
+	{"*.keystore", "key store"},
+	{"id_rsa", "SSH private key"},
+	{"id_dsa", "SSH private key"},
+	{"id_ecdsa", "SSH private key"},
+	{"id_ed25519", "SSH private key"},
+	{".aws/credentials", "AWS credentials"},
+	{"credentials.json", "cloud credentials"},
+	{"*-credentials.json", "cloud credentials"},
+	{"service-account*.json", "Google service account key"},
+	{".npmrc", "npm configuration, which can hold tokens"},
+	{".pypirc", "PyPI credentials"},
+	{".netrc", "netrc credentials"},
+	{".git-credentials", "git credentials"},
+	{".htpasswd", "password file"},
+	{".docker/config.json", "Docker credentials"},
+	{"*.tfstate", "Terraform state, which holds secrets"},
+	{"*.tfstate.backup", "Terraform state, which holds secrets"},
+	{"kubeconfig", "Kubernetes credentials"},
+}
+
+// secretContents are what secrets look like inside files
+var secretContents = []struct {
+	re     *regexp.Regexp
+	reason string
+}{
+	{regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`), "private key"},
+	{regexp.MustCompile(`\bA(?:KIA|SIA)[0-9A-Z]{16}\b`), "AWS access key ID"},
+	{regexp.MustCompile(`(?i)aws_secret_access_key\s*[=:]\s*\S{20,}`), "AWS secret access key"},
+	{regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`), "GitHub token"},
+	{regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{60,}\b`), "GitHub token"},
+	{regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`), "Slack token"},
+	{regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`), "Google API key"},
+	{regexp.MustCompile(`"type"\s*:\s*"service_account"`), "Google service account key"},
+	{regexp.MustCompile(`\b[rs]k_live_[0-9A-Za-z]{24,}\b`), "Stripe secret key"},
+	{regexp.MustCompile(`\bnpm_[A-Za-z0-9]{36}\b`), "npm token"},
+}
+
+// projectScanner flags the files of an upload that look like secrets or are
+// too large, as they are walked
+type projectScanner struct {
+	maxFileSize int64
+	secretFiles []*gitignore.Pattern
+	reasons     map[*gitignore.Pattern]string
+	allow       []*gitignore.Pattern
+	dirs        []scannedDir
+
+	findings []entity.UploadFinding
+}
+
+// scannedDir is a directory of the upload, with the .railwayignore files
+// that can allow its flagged files
+type scannedDir struct {
+	prefix       string
+	allowMatcher *gitignore.Matcher
+}
+
+func newProjectScanner(req *entity.UploadRequest) (*projectScanner, error) {
+	s := &projectScanner{
+		maxFileSize: req.Scan.MaxFileSize,
+		reasons:     make(map[*gitignore.Pattern]string),
+		allow:       gitignore.ParsePatterns([]byte(strings.Join(req.Scan.Allow, "\n")), "--allow", ""),
+	}
+
+	if s.maxFileSize <= 0 {
+		s.maxFileSize = defaultMaxFileSize
+	}
+
+	for _, secretFile := range secretFiles {
+		pattern := gitignore.ParsePatterns([]byte(secretFile.pattern), "secret", "")[0]
+
+		s.secretFiles = append(s.secretFiles, pattern)
+		s.reasons[pattern] = secretFile.reason
+	}
+
+	for _, dir := range uploadDirs(req) {
+		allowMatcher, err := gitignore.NewInProject(req.ProjectDir, dir.path, []string{allowFile}, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		s.dirs = append(s.dirs, scannedDir{prefix: dir.prefix, allowMatcher: allowMatcher})
+	}
+
+	return s, nil
+}
+
+// scan records what is wrong with a file of the walk, if anything
+func (s *projectScanner) scan(filePath, name string, fileInfo fs.FileInfo) error {
+	// Preserved symlinks have no content of their own
+	if isSymlink(fileInfo) {
+		return nil
+	}
+
+	finding, err := s.scanFile(filePath, name, fileInfo)
+	if err != nil || finding == nil {
+		return err
+	}
+
+	dir := s.dirOf(name)
+
+	if finding.AllowedBy, err = s.allowedBy(dir.allowMatcher, name, strings.TrimPrefix(name, dir.prefix)); err != nil {
+		return err
+	}
+
+	s.findings = append(s.findings, *finding)
+
+	return nil
+}
+
+// dirOf returns the directory of the upload a file comes from: the shared
+// directory whose name is the longest prefix of the name of the file, or else
+// the root
+func (s *projectScanner) dirOf(name string) scannedDir {
+	dir := s.dirs[0]
+
+	for _, shared := range s.dirs[1:] {
+		if strings.HasPrefix(name, shared.prefix) && len(shared.prefix) > len(dir.prefix) {
+			dir = shared
+		}
+	}
+
+	return dir
+}
+
+// scanFile returns what is wrong with a file, or nil
+func (s *projectScanner) scanFile(filePath, name string, fileInfo fs.FileInfo) (*entity.UploadFinding, error) {
+	finding := &entity.UploadFinding{Path: name, Size: fileInfo.Size(), Kind: entity.UploadFindingSecret}
+
+	if reason := s.secretFileReason(name); reason != "" {
+		finding.Reason = reason
+		return finding, nil
+	}
+
+	reason, err := secretContentReason(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if reason != "" {
+		finding.Reason = reason
+		return finding, nil
+	}
+
+	if fileInfo.Size() > s.maxFileSize {
+		finding.Kind = entity.UploadFindingLarge
+		finding.Reason = "larger than the size limit"
+
+		return finding, nil
+	}
+
+	return nil, nil
+}
+
+func (s *projectScanner) secretFileReason(name string) string {
+	for i := len(s.secretFiles) - 1; i >= 0; i-- {
+		pattern := s.secretFiles[i]
+
+		// Patterns with a slash match the end of the path, wherever the
+		// directory they name is
+		matched := pattern.Matches(name, false)
+		for dir := name; !matched && strings.Contains(dir, "/"); {
+			dir = dir[strings.Index(dir, "/")+1:]
+			matched = pattern.Matches(dir, false)
+		}
+
+		if matched {
+			return s.reasons[pattern]
+		}
+	}
+
+	return ""
+}
+
+// secretContentReason searches the start of a text file for secrets, and
+// returns what it found with the line it is on
+func secretContentReason(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	defer f.Close()
+
+	content, err := io.ReadAll(io.LimitReader(f, secretScanLimit))
+	if err != nil {
+		return "", err
+	}
+
+	head := content
+	if len(head) > binaryScanLimit {
+		head = head[:binaryScanLimit]
+	}
+
+	if bytes.IndexByte(head, 0) >= 0 {
+		return "", nil
+	}
+
+	for _, secret := range secretContents {
+		if loc := secret.re.FindIndex(content); loc != nil {
+			line := bytes.Count(content[:loc[0]], []byte("\n")) + 1
+			return fmt.Sprintf("%s on line %d", secret.reason, line), nil
+		}
+	}
+
+	return "", nil
+}
+
+// allowedBy returns the pattern allowing a flagged file to be uploaded: an
+// --allow pattern matching its name in the upload, or a negated pattern of a
+// .railwayignore read by allowMatcher matching its path there. The pattern
+// can match one of the directories of the file too
+func (s *projectScanner) allowedBy(allowMatcher *gitignore.Matcher, name, dirName string) (string, error) {
+	for p, isDir := name, false; p != "."; p, isDir = path.Dir(p), true {
+		for i := len(s.allow) - 1; i >= 0; i-- {
+			if s.allow[i].Matches(p, isDir) {
+				if s.allow[i].Negated() {
+					return "", nil
+				}
+
+				return "--allow " + s.allow[i].Text, nil
+			}
+		}
+	}
+
+	for p, isDir := dirName, false; p != "."; p, isDir = path.Dir(p), true {
+		pattern, err := allowMatcher.Explain(p, isDir)
+		if err != nil {
+			return "", err
+		}
+
+		if pattern == nil {
+			continue
+		}
+
+		if pattern.Negated() && path.Base(pattern.Source) == allowFile {
+			return fmt.Sprintf("%s:%d %s", pattern.Source, pattern.Line, pattern.Text), nil
+		}
+
+		return "", nil
+	}
+
+	return "", nil
+}
+
+// FlaggedFilesError stops strict uploads holding flagged files that nothing
+// allows
+type FlaggedFilesError struct {
+	Count int
+}
+
+func (e *FlaggedFilesError) Error() string {
+	files := "files"
+	if e.Count == 1 {
+		files = "file"
+	}
+
+	return fmt.Sprintf("Refusing to upload %d flagged %s in strict mode. Leave them out, or allow them with a \"!\" pattern in .railwayignore or with --allow", e.Count, files)
+}
+
+// FlaggedFindings returns the findings nothing allows
+func FlaggedFindings(findings []entity.UploadFinding) []entity.UploadFinding {
+	var flagged []entity.UploadFinding
+
+	for _, finding := range findings {
+		if finding.AllowedBy == "" {
+			flagged = append(flagged, finding)
+		}
+	}
+
+	return flagged
+}
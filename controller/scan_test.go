@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/botwayorg/railway-api/entity"
+)
+
+func TestPlanUploadFindings(t *testing.T) {
+	c, backend := newFakeController(t)
+	req := newUploadRequest(t, backend, map[string]string{
+		"app/main.go":           "package main\n",
+		"app/.env":              "TOKEN=1\n",
+		"app/config.json":       `{"type": "service_account"}`,
+		"libs/.railwayignore":   "!id_rsa\n",
+		"libs/id_rsa":           "key\n",
+		"libs/certs/server.pem": "cert\n",
+	})
+
+	root := req.RootDir
+	req.RootDir = filepath.Join(root, "app")
+	req.ProjectDir = root
+	req.Shared = []entity.SharedDir{{Path: filepath.Join(root, "libs"), Name: "libs"}}
+	req.Scan.Allow = []string{"libs/certs/"}
+
+	plan, err := c.PlanUpload(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		".env":                  "",
+		"config.json":           "",
+		"libs/id_rsa":           ".railwayignore:1 !id_rsa",
+		"libs/certs/server.pem": "--allow libs/certs/",
+	}
+
+	if len(plan.Findings) != len(want) {
+		t.Fatalf("got %d findings, want %d: %+v", len(plan.Findings), len(want), plan.Findings)
+	}
+
+	for _, finding := range plan.Findings {
+		allowedBy, ok := want[finding.Path]
+		if !ok {
+			t.Errorf("%s was flagged", finding.Path)
+			continue
+		}
+
+		if finding.AllowedBy != allowedBy {
+			t.Errorf("%s is allowed by %q, want %q", finding.Path, finding.AllowedBy, allowedBy)
+		}
+	}
+
+	if flagged := FlaggedFindings(plan.Findings); len(flagged) != 2 {
+		t.Errorf("%d findings aren't allowed, want 2", len(flagged))
+	}
+}
+
+func TestUploadStrict(t *testing.T) {
+	c, backend := newFakeController(t)
+	req := newUploadRequest(t, backend, map[string]string{
+		"main.go": "package main\n",
+		".env":    "TOKEN=1\n",
+	})
+	req.Scan.Strict = true
+
+	var flaggedErr *FlaggedFilesError
+	if _, err := c.Upload(context.Background(), req); !errors.As(err, &flaggedErr) || flaggedErr.Count != 1 {
+		t.Fatalf("err = %v, want 1 flagged file", err)
+	}
+
+	if uploads := backend.Uploads(); len(uploads) != 0 {
+		t.Fatalf("sent %d uploads in spite of the flagged file", len(uploads))
+	}
+
+	req.Scan.Allow = []string{".env"}
+
+	if _, err := c.Upload(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -8,5 +8,18 @@ import (
 
 // GetStarters returns all available starters
 func (c *Controller) GetStarters(ctx context.Context) ([]*entity.Starter, error) {
-	return c.gtwy.GetStarters(ctx)
+	var starters []*entity.Starter
+
+	if c.readDiskCache("starters", startersCacheTTL, &starters) {
+		return starters, nil
+	}
+
+	starters, err := c.gtwy.GetStarters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.writeDiskCache("starters", starters)
+
+	return starters, nil
 }
@@ -2,206 +2,301 @@ package controller
 
 import (
 	"archive/tar"
-	"bytes"
 	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/fs"
 	"os"
-	"path/filepath"
-	"strings"
 
 	"github.com/botwayorg/railway-api/entity"
-	gitignore "github.com/botwayorg/railway-api/gateway"
+	"github.com/botwayorg/railway-api/gateway"
 )
 
-var validIgnoreFile = map[string]bool{
-	".gitignore":     true,
-	".railwayignore": true,
+// uploadFile is a file of the upload, as walked by listUpload
+type uploadFile struct {
+	// path is where the file is read from, the target of a followed link
+	path     string
+	name     string
+	fileInfo fs.FileInfo
 }
 
-var skipDirs = []string{
-	".git",
-	"node_modules",
-}
-
-type ignoreFile struct {
-	prefix string
-	ignore *gitignore.GitIgnore
-}
-
-func scanIgnoreFiles(src string) ([]ignoreFile, error) {
-	ignoreFiles := []ignoreFile{}
+// listUpload walks the upload once, scanning every file on the way, and
+// returns the files to upload in the order of the walk, with the findings of
+// the scan. onIncluded and onIgnored are called as files are walked, for the
+// progress of the upload to be reported meanwhile
+func listUpload(req *entity.UploadRequest, onIgnored func(entity.UploadPlanFile), onIncluded func(uploadFile)) ([]uploadFile, []entity.UploadFinding, error) {
+	scanner, err := newProjectScanner(req)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	if err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
+	var files []uploadFile
 
-		if d.IsDir() {
-			// no sense scanning for ignore files in skipped dirs
-			for _, s := range skipDirs {
-				if filepath.Base(path) == s {
-					return filepath.SkipDir
-				}
-			}
+	err = walkUpload(req, onIgnored, func(path, name string, fileInfo fs.FileInfo) error {
+		file := uploadFile{path: path, name: name, fileInfo: fileInfo}
 
-			return nil
-		}
+		files = append(files, file)
+		onIncluded(file)
 
-		fname := filepath.Base(path)
-		if validIgnoreFile[fname] {
-			igf, err := gitignore.CompileIgnoreFile(path)
-			if err != nil {
-				return err
-			}
-
-			prefix := filepath.Dir(path)
-			if prefix == "." {
-				prefix = "" // Handle root dir properly.
-			}
-
-			ignoreFiles = append(ignoreFiles, ignoreFile{
-				prefix: prefix,
-				ignore: igf,
-			})
-		}
+		return scanner.scan(path, name, fileInfo)
+	})
 
-		return nil
-	}); err != nil {
-		return nil, err
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return ignoreFiles, nil
+	return files, scanner.findings, nil
 }
 
-func compress(src string, buf io.Writer) error {
+// compress writes the files of the upload as a gzipped tarball to buf, and
+// returns the digest of its sources. The files were counted as they were
+// listed, so the progress has its total already.
+//
+// Archives are reproducible: files come in the order of the walk, with the
+// same modification time and owner, and a mode telling only whether they are
+// executable
+func compress(files []uploadFile, buf io.Writer, progress *uploadProgress) (string, error) {
 	// tar > gzip > buf
 	zr := gzip.NewWriter(buf)
 	tw := tar.NewWriter(zr)
 
-	// find all ignore files, including those in subdirs
-	ignoreFiles, err := scanIgnoreFiles(src)
-	if err != nil {
-		return err
-	}
+	progress.setPhase(entity.UploadSending)
 
-	// walk through every file in the folder
-	err = filepath.WalkDir(src, func(absoluteFile string, de os.DirEntry, passedErr error) error {
-		relativeFile, err := filepath.Rel(src, absoluteFile)
-		if err != nil {
-			return err
-		}
+	archived := make([]entity.ManifestFile, 0, len(files))
 
-		if passedErr != nil {
-			return err
-		}
+	for _, file := range files {
+		var archivedFile entity.ManifestFile
+		var err error
 
-		// follow symlinks by default
-		resolvedFilePath, err := filepath.EvalSymlinks(absoluteFile)
-		if err != nil {
-			return err
+		if isSymlink(file.fileInfo) {
+			archivedFile, err = addSymlink(tw, file.path, file.name, file.fileInfo)
+		} else {
+			archivedFile, err = addFile(tw, file.path, file.name, progress)
 		}
 
-		// get info about the file the link points at
-		fileInfo, err := os.Lstat(resolvedFilePath)
 		if err != nil {
-			return err
+			return "", err
 		}
 
-		if fileInfo.IsDir() {
-			// skip directories if we can (for perf)
-			// e.g., want to avoid walking node_modules dir
-			for _, s := range skipDirs {
-				if filepath.Base(relativeFile) == s {
-					return filepath.SkipDir
-				}
-			}
+		archived = append(archived, archivedFile)
+	}
 
-			return nil
-		}
+	// produce tar
+	if err := tw.Close(); err != nil {
+		return "", err
+	}
+	// produce gzip
+	if err := zr.Close(); err != nil {
+		return "", err
+	}
+	return sourceDigest(archived), nil
+}
 
-		for _, ignoredFile := range ignoreFiles {
-			if strings.HasPrefix(absoluteFile, ignoredFile.prefix) { // if ignore file applicable
-				trimmed := strings.TrimPrefix(absoluteFile, ignoredFile.prefix)
-				if ignoredFile.ignore.MatchesPath(trimmed) {
-					return nil
-				}
-			}
-		}
+// addFile streams a file into the archive, hashing it on the way. Its size is
+// taken from the open file, and exactly that many bytes are copied, so a file
+// growing meanwhile can't corrupt the archive
+func addFile(tw *tar.Writer, path string, name string, progress *uploadProgress) (entity.ManifestFile, error) {
+	file := entity.ManifestFile{Path: name}
 
-		// read file into a buffer to prevent tar overwrites
-		data := bytes.NewBuffer(nil)
-		f, err := os.Open(resolvedFilePath)
-		if err != nil {
-			return err
-		}
-		_, err = io.Copy(data, f)
-		if err != nil {
-			return err
-		}
+	f, err := os.Open(path)
+	if err != nil {
+		return file, err
+	}
 
-		// close the file to avoid hitting fd limit
-		if err := f.Close(); err != nil {
-			return err
-		}
+	// close the file to avoid hitting fd limit
+	defer f.Close()
 
-		// generate tar headers
-		header, err := tar.FileInfoHeader(fileInfo, resolvedFilePath)
-		if err != nil {
-			return err
-		}
+	fileInfo, err := f.Stat()
+	if err != nil {
+		return file, err
+	}
 
-		// must provide real name
-		// (see https://golang.org/src/archive/tar/common.go?#L626)
-		header.Name = filepath.ToSlash(relativeFile)
-		// size when we first observed the file
-		header.Size = int64(data.Len())
+	file.Size, file.Mode = fileInfo.Size(), archiveMode(fileInfo)
 
-		// write header
-		if err := tw.WriteHeader(header); err != nil {
-			return err
-		}
-		// not a dir, write file content
-		if _, err := io.Copy(tw, data); err != nil {
-			return err
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     file.Size,
+		Mode:     file.Mode,
+		ModTime:  archiveModTime,
+	}
+
+	if err := tw.WriteHeader(header); err != nil {
+		return file, err
+	}
+
+	hash := sha256.New()
+
+	if _, err := io.CopyN(io.MultiWriter(progress.archiveWriter(tw), hash), f, header.Size); err != nil {
+		if err == io.EOF {
+			return file, fmt.Errorf("%s shrank while it was being uploaded", name)
 		}
 
-		return err
-	})
+		return file, err
+	}
+
+	file.SHA256 = hex.EncodeToString(hash.Sum(nil))
 
+	return file, nil
+}
+
+// addSymlink writes a link as it is into the archive, for the preserve policy.
+// The walk only passes links that stay inside the project
+func addSymlink(tw *tar.Writer, path string, name string, fileInfo fs.FileInfo) (entity.ManifestFile, error) {
+	file := entity.ManifestFile{Path: name, Mode: archiveMode(fileInfo)}
+
+	target, err := os.Readlink(path)
 	if err != nil {
-		return err
+		return file, err
 	}
 
-	// produce tar
-	if err := tw.Close(); err != nil {
-		return err
-	}
-	// produce gzip
-	if err := zr.Close(); err != nil {
-		return err
+	file.Symlink = target
+
+	header := &tar.Header{
+		Typeflag: tar.TypeSymlink,
+		Name:     name,
+		Linkname: target,
+		Mode:     file.Mode,
+		ModTime:  archiveModTime,
 	}
-	return nil
+
+	return file, tw.WriteHeader(header)
 }
 
+// Upload sends the project directory to be deployed as a whole archive. With
+// req.Incremental, only the files the server doesn't have yet are sent when
+// it supports it. Either way, the digest of the sources is recorded
+// locally.
+//
+// The project is scanned while it is walked for files that look like secrets
+// or are too large, which are reported, and stop the upload in strict mode
+// unless they are allowed
 func (c *Controller) Upload(
 	ctx context.Context,
 	req *entity.UploadRequest,
 ) (*entity.UpResponse, error) {
-	var buf bytes.Buffer
+	progress := newUploadProgress()
+	stopReporting := c.reportUploadProgress(progress)
+
+	res, err := c.upload(ctx, req, progress)
+	stopReporting(err == nil)
 
-	if err := compress(req.RootDir, &buf); err != nil {
+	return res, err
+}
+
+func (c *Controller) upload(ctx context.Context, req *entity.UploadRequest, progress *uploadProgress) (*entity.UpResponse, error) {
+	files, findings, err := listUpload(req, progress.fileIgnored, func(file uploadFile) {
+		progress.fileIncluded(fileSize(file.fileInfo))
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	return c.gtwy.Up(ctx, &entity.UpRequest{
-		Data:          buf,
+	if flagged := FlaggedFindings(findings); len(flagged) > 0 {
+		c.reporter.Report(entity.UploadFindingsEvent{Findings: flagged, Strict: req.Scan.Strict})
+
+		if req.Scan.Strict {
+			return nil, &FlaggedFilesError{Count: len(flagged)}
+		}
+	}
+
+	if !req.Incremental {
+		return c.uploadArchive(ctx, req, files, progress)
+	}
+
+	// Asking about no files tells whether the server takes manifests, before
+	// hashing the project for nothing
+	_, err = c.gtwy.MissingBlobs(ctx, &entity.MissingBlobsRequest{
+		ProjectID:     req.ProjectID,
+		EnvironmentID: req.EnvironmentID,
+	})
+
+	if err == gateway.ErrIncrementalUploadUnsupported {
+		return c.uploadArchive(ctx, req, files, progress)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return c.sendManifest(ctx, req, files, progress)
+}
+
+// uploadArchive archives the project directory and uploads it as it is being
+// written, so that memory use doesn't grow with the size of the project
+func (c *Controller) uploadArchive(
+	ctx context.Context,
+	req *entity.UploadRequest,
+	files []uploadFile,
+	progress *uploadProgress,
+) (*entity.UpResponse, error) {
+	archive, writer := io.Pipe()
+	compressed := make(chan error, 1)
+
+	var digest string
+
+	go func() {
+		var err error
+
+		digest, err = compress(files, writer, progress)
+		writer.CloseWithError(err)
+		compressed <- err
+	}()
+
+	res, err := c.gtwy.Up(ctx, &entity.UpRequest{
+		Data:          progress.uploadReader(archive),
 		ProjectID:     req.ProjectID,
 		EnvironmentID: req.EnvironmentID,
 		ServiceID:     req.ServiceID,
 	})
+
+	// Stop the archiving if the upload ended before reading all of it
+	archive.Close()
+
+	// A failure to archive breaks the upload too, but tells more about what
+	// went wrong
+	compressErr := <-compressed
+	if compressErr == io.ErrClosedPipe {
+		compressErr = nil
+	}
+
+	if compressErr != nil {
+		return nil, compressErr
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	res.Digest = digest
+	c.recordUpload(req, res, progress.event().FilesIncluded)
+
+	return res, nil
+}
+
+// PlanUpload walks the project like Upload does, without archiving or sending
+// anything, to tell which files would be uploaded and why the others wouldn't
+func (c *Controller) PlanUpload(req *entity.UploadRequest) (*entity.UploadPlan, error) {
+	plan := &entity.UploadPlan{RootDir: req.RootDir, Shared: req.Shared}
+
+	onIgnored := func(ignored entity.UploadPlanFile) {
+		plan.Ignored = append(plan.Ignored, ignored)
+	}
+
+	onIncluded := func(file uploadFile) {
+		plan.Included = append(plan.Included, entity.UploadPlanFile{Path: file.name, Size: fileSize(file.fileInfo)})
+	}
+
+	var err error
+
+	if _, plan.Findings, err = listUpload(req, onIgnored, onIncluded); err != nil {
+		return nil, err
+	}
+
+	return plan, nil
 }
 
 func (c *Controller) GetFullUrlFromStaticUrl(staticUrl string) string {
@@ -0,0 +1,215 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/botwayorg/railway-api/entity"
+	"github.com/botwayorg/railway-api/fake"
+	"github.com/botwayorg/railway-api/gateway"
+)
+
+// newFakeController returns a controller logged in to a new fake backend,
+// with a home directory of its own
+func newFakeController(t *testing.T) (*Controller, *fake.Backend) {
+	t.Setenv("HOME", t.TempDir())
+
+	backend := fake.New()
+	gtwy := gateway.NewClient(
+		gateway.WithHost(fake.Host),
+		gateway.WithToken(fake.UserToken),
+		gateway.WithHTTPClient(backend.Client()),
+	)
+
+	return NewWithGateway(gtwy), backend
+}
+
+// newUploadRequest writes files into a new directory and returns a request
+// uploading it to the web service of the demo project
+func newUploadRequest(t *testing.T, backend *fake.Backend, files map[string]string) *entity.UploadRequest {
+	dir := t.TempDir()
+
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	demo := backend.Projects()[0]
+
+	return &entity.UploadRequest{
+		ProjectID:     demo.Id,
+		EnvironmentID: demo.Environments[0].Id,
+		ServiceID:     demo.Services[0].ID,
+		RootDir:       dir,
+		Symlinks:      entity.SymlinksFollowWithinRoot,
+	}
+}
+
+// lastProgress keeps the last upload progress reported
+type lastProgress struct {
+	event entity.UploadProgressEvent
+}
+
+func (r *lastProgress) Report(event entity.Event) {
+	if e, ok := event.(entity.UploadProgressEvent); ok {
+		r.event = e
+	}
+}
+
+func TestUploadArchive(t *testing.T) {
+	c, backend := newFakeController(t)
+	req := newUploadRequest(t, backend, map[string]string{
+		"main.go":    "package main\n",
+		".gitignore": "*.log\n",
+		"debug.log":  "ignored\n",
+	})
+
+	progress := &lastProgress{}
+	c.SetReporter(progress)
+
+	res, err := c.Upload(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uploads := backend.Uploads()
+	if len(uploads) != 1 {
+		t.Fatalf("got %d uploads, want 1", len(uploads))
+	}
+
+	if len(uploads[0].Body) == 0 || uploads[0].Manifest != nil {
+		t.Errorf("the upload isn't a whole archive: %d bytes, %d manifest files", len(uploads[0].Body), len(uploads[0].Manifest))
+	}
+
+	if uploads[0].ServiceID != req.ServiceID {
+		t.Errorf("service = %q, want %q", uploads[0].ServiceID, req.ServiceID)
+	}
+
+	if res.Digest == "" {
+		t.Error("no digest")
+	}
+
+	want := entity.UploadProgressEvent{Phase: entity.UploadFinished, FilesIncluded: 2, FilesIgnored: 1, TotalBytes: 19}
+	if got := progress.event; got.Phase != want.Phase || got.FilesIncluded != want.FilesIncluded || got.FilesIgnored != want.FilesIgnored || got.TotalBytes != want.TotalBytes {
+		t.Errorf("last progress = %+v, want %+v", got, want)
+	}
+}
+
+// blobCounter counts the blobs sent through a fake backend
+type blobCounter struct {
+	base  http.RoundTripper
+	blobs int
+}
+
+func (c *blobCounter) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodPut && strings.Contains(req.URL.Path, "/blobs/") {
+		c.blobs++
+	}
+
+	return c.base.RoundTrip(req)
+}
+
+func TestUploadIncremental(t *testing.T) {
+	_, backend := newFakeController(t)
+
+	counter := &blobCounter{base: backend.Client().Transport}
+	c := NewWithGateway(gateway.NewClient(
+		gateway.WithHost(fake.Host),
+		gateway.WithToken(fake.UserToken),
+		gateway.WithHTTPClient(&http.Client{Transport: counter}),
+	))
+
+	req := newUploadRequest(t, backend, map[string]string{
+		"main.go":     "package main\n",
+		"lib/copy.go": "package main\n",
+		"README.md":   "# demo\n",
+	})
+	req.Incremental = true
+
+	first, err := c.Upload(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// lib/copy.go has the content of main.go, so it is sent once
+	if counter.blobs != 2 {
+		t.Errorf("the first upload sent %d blobs, want 2", counter.blobs)
+	}
+
+	counter.blobs = 0
+	progress := &lastProgress{}
+	c.SetReporter(progress)
+
+	second, err := c.Upload(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if counter.blobs != 0 {
+		t.Errorf("the second upload of the same files sent %d blobs, want none", counter.blobs)
+	}
+
+	if got := progress.event; !got.Incremental || got.FilesReused != 3 || got.UploadedBytes != 0 {
+		t.Errorf("last progress = %+v, want 3 files reused and nothing uploaded", got)
+	}
+
+	uploads := backend.Uploads()
+	if len(uploads) != 2 {
+		t.Fatalf("got %d uploads, want 2", len(uploads))
+	}
+
+	for _, upload := range uploads {
+		if upload.Body != nil || len(upload.Manifest) != 3 {
+			t.Fatalf("the upload isn't a manifest of 3 files: %d bytes, %d manifest files", len(upload.Body), len(upload.Manifest))
+		}
+
+		for _, file := range upload.Manifest {
+			if _, ok := backend.Blob(req.ProjectID, file.SHA256); !ok {
+				t.Errorf("no blob for %s", file.Path)
+			}
+		}
+	}
+
+	if first.Digest != second.Digest {
+		t.Errorf("the digest changed from %s to %s without changes", first.Digest, second.Digest)
+	}
+
+	req.Incremental = false
+
+	archived, err := c.Upload(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if archived.Digest != first.Digest {
+		t.Errorf("the digest of the archive is %s, want %s like the incremental upload", archived.Digest, first.Digest)
+	}
+}
+
+func TestUploadIncrementalFallback(t *testing.T) {
+	c, backend := newFakeController(t)
+	backend.DisableIncrementalUploads()
+
+	req := newUploadRequest(t, backend, map[string]string{"main.go": "package main\n"})
+	req.Incremental = true
+
+	if _, err := c.Upload(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	uploads := backend.Uploads()
+	if len(uploads) != 1 || len(uploads[0].Body) == 0 {
+		t.Fatalf("the upload didn't fall back to a whole archive: %d uploads", len(uploads))
+	}
+}
@@ -12,10 +12,9 @@ import (
 	"time"
 
 	configs "github.com/botwayorg/railway-api/configs"
-	"github.com/abdfnx/botway/constants"
 	"github.com/botwayorg/railway-api/entity"
 	"github.com/botwayorg/railway-api/errors"
-	"github.com/botwayorg/railway-api/ui"
+	"github.com/botwayorg/railway-api/gateway"
 	"github.com/pkg/browser"
 )
 
@@ -82,14 +81,14 @@ func (c *Controller) browserBasedLogin(ctx context.Context) (*entity.User, error
 					byteRes, err := json.Marshal(&res)
 
 					if err != nil {
-						fmt.Println(err)
+						c.notice(entity.NoticeError, err.Error())
 					}
 
 					w.WriteHeader(400)
 
 					_, err = w.Write(byteRes)
 					if err != nil {
-						fmt.Println("Invalid login response failed to serialize!")
+						c.notice(entity.NoticeError, "Invalid login response failed to serialize!")
 					}
 
 					return
@@ -99,7 +98,7 @@ func (c *Controller) browserBasedLogin(ctx context.Context) (*entity.User, error
 				byteRes, err := json.Marshal(&res)
 
 				if err != nil {
-					fmt.Println(err)
+					c.notice(entity.NoticeError, err.Error())
 				}
 
 				w.WriteHeader(200)
@@ -107,7 +106,7 @@ func (c *Controller) browserBasedLogin(ctx context.Context) (*entity.User, error
 				_, err = w.Write(byteRes)
 
 				if err != nil {
-					fmt.Println("Valid login response failed to serialize!")
+					c.notice(entity.NoticeError, "Valid login response failed to serialize!")
 				}
 			} else if r.Method == http.MethodOptions {
 				w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, PUT, PATCH, POST, DELETE")
@@ -121,26 +120,39 @@ func (c *Controller) browserBasedLogin(ctx context.Context) (*entity.User, error
 			wg.Done()
 
 			if err := srv.Shutdown(ctx); err != nil {
-				fmt.Println(err)
+				c.notice(entity.NoticeError, err.Error())
 			}
 		})
 
 		if err := http.ListenAndServe(fmt.Sprintf("localhost:%d", port), nil); err != nil {
-			fmt.Println("Login server handshake failed!")
+			c.notice(entity.NoticeError, "Login server handshake failed!")
 		}
 	}()
 
 	url := getBrowserBasedLoginURL(port, code)
-	err = c.ConfirmBrowserOpen("Logging in...", url)
+	err = c.ConfirmBrowserOpen(ctx, "Logging in...", url)
 
 	if err != nil {
 		// Opening the browser failed. Try browserless login
 		return c.browserlessLogin(ctx)
 	}
 
-	fmt.Println("No dice? Try botway login railway --browserless")
+	c.notice(entity.NoticeInfo, "No dice? Try botway login railway --browserless")
 
-	wg.Wait()
+	loggedIn := make(chan struct{})
+
+	go func() {
+		wg.Wait()
+		close(loggedIn)
+	}()
+
+	select {
+	case <-loggedIn:
+		c.reporter.Report(entity.ProgressEvent{Done: true})
+	case <-ctx.Done():
+		c.reporter.Report(entity.ProgressEvent{Done: true})
+		return nil, ctx.Err()
+	}
 
 	if code != returnedCode {
 		return nil, errors.LoginFailed
@@ -169,6 +181,10 @@ func (c *Controller) pollForToken(ctx context.Context, code string) (string, err
 		token, err := c.gtwy.ConsumeLoginSession(ctx, code)
 
 		if err != nil {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+
 			return "", errors.LoginFailed
 		}
 
@@ -177,7 +193,10 @@ func (c *Controller) pollForToken(ctx context.Context, code string) (string, err
 		}
 
 		count++
-		time.Sleep(pollInterval)
+
+		if err := gateway.Sleep(ctx, pollInterval); err != nil {
+			return "", err
+		}
 	}
 
 	return "", errors.LoginTimeout
@@ -192,8 +211,7 @@ func (c *Controller) browserlessLogin(ctx context.Context) (*entity.User, error)
 
 	url := getBrowserlessLoginURL(wordCode)
 
-	fmt.Printf(constants.HEADING + "Your pairing code is: %s\n", constants.PRIMARY_FOREGROUND.Render(wordCode))
-	fmt.Printf("To authenticate with Railway, please go to \n    %s\n", url)
+	c.reporter.Report(entity.LoginCodeEvent{Code: wordCode, URL: url})
 
 	token, err := c.pollForToken(ctx, wordCode)
 
@@ -218,6 +236,9 @@ func (c *Controller) browserlessLogin(ctx context.Context) (*entity.User, error)
 }
 
 func (c *Controller) Login(ctx context.Context, isBrowserless bool) (*entity.User, error) {
+	// Whatever was fetched belongs to the previous session
+	defer c.invalidateCaches()
+
 	// Invalidate current session if it exists
 	if loggedIn, _ := c.IsLoggedIn(ctx); loggedIn {
 		if err := c.gtwy.Logout(ctx); err != nil {
@@ -232,12 +253,17 @@ func (c *Controller) Login(ctx context.Context, isBrowserless bool) (*entity.Use
 	return c.browserBasedLogin(ctx)
 }
 
+// Logout ends the session of the user. Without a session to end, it warns
+// and returns nil
 func (c *Controller) Logout(ctx context.Context) error {
 	if loggedIn, _ := c.IsLoggedIn(ctx); !loggedIn {
-		fmt.Printf("🚪  %s\n", ui.YellowText("Already logged out"))
+		c.notice(entity.NoticeWarning, "Already logged out")
 		return nil
 	}
 
+	// Drop the cache while we still know which credentials it belongs to
+	c.invalidateCaches()
+
 	err := c.gtwy.Logout(ctx)
 	if err != nil {
 		return err
@@ -248,7 +274,7 @@ func (c *Controller) Logout(ctx context.Context) error {
 		return err
 	}
 
-	fmt.Printf("👋 %s\n", ui.YellowText("Logged out"))
+	c.notice(entity.NoticeInfo, "👋 Logged out")
 
 	return nil
 }
@@ -265,18 +291,17 @@ func (c *Controller) IsLoggedIn(ctx context.Context) (bool, error) {
 	return isLoggedIn, nil
 }
 
-func (c *Controller) ConfirmBrowserOpen(spinnerMsg string, url string) error {
-	fmt.Printf("Press Enter to open the browser (^C to quit)")
-	fmt.Fscanln(os.Stdin)
+func (c *Controller) ConfirmBrowserOpen(ctx context.Context, spinnerMsg string, url string) error {
+	if err := c.prompter.Acknowledge(ctx, "Press Enter to open the browser (^C to quit)"); err != nil {
+		return err
+	}
 
-	ui.StartSpinner(&ui.SpinnerCfg{
-		Message: spinnerMsg,
-	})
+	c.reporter.Report(entity.ProgressEvent{Message: spinnerMsg})
 
 	err := browser.OpenURL(url)
 
 	if err != nil {
-		ui.StopSpinner("Failed to open browser, attempting browserless login.")
+		c.reporter.Report(entity.ProgressEvent{Done: true, Message: "Failed to open browser, attempting browserless login."})
 		return err
 	}
 
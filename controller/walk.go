@@ -0,0 +1,391 @@
+package controller
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/botwayorg/railway-api/entity"
+	"github.com/botwayorg/railway-api/lib/gitignore"
+)
+
+// ignoreFiles are read in every directory of a project, .railwayignore taking
+// precedence over .gitignore
+var ignoreFiles = []string{".gitignore", ".railwayignore"}
+
+// defaultIgnores leave out what builders install anyway. Ignore files can
+// include it again, with !node_modules/ for instance
+var defaultIgnores = []string{"node_modules/"}
+
+// projectWalker lists the files of a directory to upload
+type projectWalker struct {
+	matcher  *gitignore.Matcher
+	symlinks entity.SymlinkPolicy
+	// prefix names the directory in the upload, "" for its root, or the name
+	// of a shared directory with a trailing slash
+	prefix string
+	// realBoundary is the directory followed links may not lead out of, with
+	// its symlinks resolved
+	realBoundary string
+	// ancestors holds the real paths of the directories being walked, which
+	// a followed link going back to would loop forever
+	ancestors map[string]bool
+
+	onIgnored func(entity.UploadPlanFile)
+	fn        func(path, name string, fileInfo fs.FileInfo) error
+}
+
+// uploadDir is a directory of an upload, whose files are named after prefix
+type uploadDir struct {
+	path   string
+	prefix string
+}
+
+// uploadDirs returns the root of an upload, then its shared directories
+func uploadDirs(req *entity.UploadRequest) []uploadDir {
+	dirs := []uploadDir{{path: req.RootDir}}
+
+	for _, shared := range req.Shared {
+		dirs = append(dirs, uploadDir{path: shared.Path, prefix: shared.Name + "/"})
+	}
+
+	return dirs
+}
+
+// walkUpload calls walkProject with every directory of the upload, the root
+// first. Shared directories can't replace anything in the root
+func walkUpload(req *entity.UploadRequest, onIgnored func(entity.UploadPlanFile), fn func(path, name string, fileInfo fs.FileInfo) error) error {
+	for _, shared := range req.Shared {
+		if _, err := os.Lstat(filepath.Join(req.RootDir, filepath.FromSlash(shared.Name))); err == nil {
+			return fmt.Errorf("%s is shared with the upload, but %s already holds it", shared.Name, req.RootDir)
+		}
+	}
+
+	for _, dir := range uploadDirs(req) {
+		if err := walkProject(req, dir, onIgnored, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// walkProject calls fn with every file of a directory of the upload that
+// isn't ignored, in lexical order, and onIgnored with every file and
+// directory that is. Nothing inside an ignored directory is looked at, like
+// with git.
+//
+// Symlinks are handled according to the policy of the upload. Followed links
+// are walked under their own name, and fn gets the path of their target.
+// Preserved ones are passed to fn with the information of the link itself
+func walkProject(req *entity.UploadRequest, dir uploadDir, onIgnored func(entity.UploadPlanFile), fn func(path, name string, fileInfo fs.FileInfo) error) error {
+	symlinks := req.Symlinks
+	if symlinks == "" {
+		symlinks = entity.SymlinksFollowWithinRoot
+	}
+
+	matcher, err := gitignore.NewInProject(req.ProjectDir, dir.path, ignoreFiles, defaultIgnores)
+	if err != nil {
+		return err
+	}
+
+	realRoot, err := realPath(dir.path)
+	if err != nil {
+		return err
+	}
+
+	realBoundary := realRoot
+	if req.ProjectDir != "" {
+		realProject, err := realPath(req.ProjectDir)
+		if err != nil {
+			return err
+		}
+
+		// Directories outside of the project are their own boundary
+		if isWithin(realProject, realRoot) {
+			realBoundary = realProject
+		}
+	}
+
+	w := &projectWalker{
+		matcher:      matcher,
+		symlinks:     symlinks,
+		prefix:       dir.prefix,
+		realBoundary: realBoundary,
+		ancestors:    map[string]bool{realRoot: true},
+		onIgnored:    onIgnored,
+		fn:           fn,
+	}
+
+	return w.walkDir(dir.path, realRoot, "")
+}
+
+// realPath returns the absolute path of dir with its symlinks resolved
+func realPath(dir string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.EvalSymlinks(absDir)
+}
+
+// walkDir walks the directory at path, whose real path is realDir. prefix is
+// its name relative to the walked directory, with a trailing slash
+func (w *projectWalker) walkDir(path, realDir, prefix string) error {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+
+	for _, de := range entries {
+		entryPath, name := filepath.Join(path, de.Name()), prefix+de.Name()
+
+		if de.Type()&fs.ModeSymlink != 0 {
+			if err := w.walkLink(entryPath, name); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		ignoredBy := gitignore.GitDir
+		if de.Name() != ".git" {
+			if ignoredBy, err = w.matcher.Match(name, de.IsDir()); err != nil {
+				return err
+			}
+		}
+
+		if ignoredBy != nil {
+			var size int64
+			if info, err := de.Info(); err == nil && !de.IsDir() {
+				size = info.Size()
+			}
+
+			w.ignore(name, de.IsDir(), size, ignoredBy)
+
+			continue
+		}
+
+		if de.IsDir() {
+			realPath := filepath.Join(realDir, de.Name())
+
+			w.ancestors[realPath] = true
+			err := w.walkDir(entryPath, realPath, name+"/")
+			delete(w.ancestors, realPath)
+
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		info, err := de.Info()
+		if err != nil {
+			return err
+		}
+
+		if err := w.walkFile(entryPath, name, info); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// walkLink applies the symlink policy to the link at path
+func (w *projectWalker) walkLink(path, name string) error {
+	// Until they are followed, links are files to ignore patterns, like
+	// they are to git
+	if w.symlinks == entity.SymlinksPreserve || w.symlinks == entity.SymlinksError {
+		ignoredBy, err := w.matcher.Match(name, false)
+		if err != nil {
+			return err
+		}
+
+		if ignoredBy != nil {
+			w.ignore(name, false, 0, ignoredBy)
+			return nil
+		}
+
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+
+		if w.symlinks == entity.SymlinksError {
+			return fmt.Errorf("%s is a symlink to %s, which the %s symlink policy refuses. Ignore it, or upload with another policy", w.prefix+name, target, w.symlinks)
+		}
+
+		// Preserved links are archived as they are, so the archive and the
+		// manifest only hold those that stay inside the project
+		realTarget, err := preservedTarget(path, target)
+		if err != nil {
+			return err
+		}
+
+		if !isWithin(w.realBoundary, realTarget) {
+			w.report(entity.UploadPlanFile{Path: name, Reason: "symlink to " + target + ", outside of the project"})
+			return nil
+		}
+
+		info, err := os.Lstat(path)
+		if err != nil {
+			return err
+		}
+
+		return w.fn(path, w.prefix+name, info)
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	target, err := filepath.EvalSymlinks(absPath)
+	if err != nil {
+		return w.leaveOutUnlessIgnored(entity.UploadPlanFile{Path: name, Reason: "broken symlink"})
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		return err
+	}
+
+	// Followed links are matched like what they point at
+	ignoredBy, err := w.matcher.Match(name, info.IsDir())
+	if err != nil {
+		return err
+	}
+
+	if ignoredBy != nil {
+		w.ignore(name, info.IsDir(), 0, ignoredBy)
+		return nil
+	}
+
+	if w.symlinks == entity.SymlinksFollowWithinRoot && !isWithin(w.realBoundary, target) {
+		w.report(entity.UploadPlanFile{Path: name, Dir: info.IsDir(), Reason: "symlink to " + target + ", outside of the project"})
+		return nil
+	}
+
+	if !info.IsDir() {
+		return w.walkFile(target, name, info)
+	}
+
+	if w.ancestors[target] {
+		w.report(entity.UploadPlanFile{Path: name, Dir: true, Reason: "symlink cycle back to " + target})
+		return nil
+	}
+
+	w.ancestors[target] = true
+	defer delete(w.ancestors, target)
+
+	return w.walkDir(target, target, name+"/")
+}
+
+// preservedTarget returns the real path the link at path leads to. Broken
+// links are resolved as far as their target exists, so that one pointing to
+// a missing file outside of the project is still caught
+func preservedTarget(path, target string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	if realTarget, err := filepath.EvalSymlinks(absPath); err == nil {
+		return realTarget, nil
+	}
+
+	if filepath.IsAbs(target) {
+		return filepath.Clean(target), nil
+	}
+
+	realDir, err := filepath.EvalSymlinks(filepath.Dir(absPath))
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(realDir, target), nil
+}
+
+// walkFile passes regular files to fn, and leaves out the others, like
+// sockets or named pipes, which can't be uploaded
+func (w *projectWalker) walkFile(path, name string, info fs.FileInfo) error {
+	if !info.Mode().IsRegular() {
+		w.report(entity.UploadPlanFile{Path: name, Reason: "not a regular file"})
+		return nil
+	}
+
+	return w.fn(path, w.prefix+name, info)
+}
+
+// leaveOutUnlessIgnored reports file with its reason, or with the pattern
+// ignoring it when there's one
+func (w *projectWalker) leaveOutUnlessIgnored(file entity.UploadPlanFile) error {
+	ignoredBy, err := w.matcher.Match(file.Path, file.Dir)
+	if err != nil {
+		return err
+	}
+
+	if ignoredBy != nil {
+		w.ignore(file.Path, file.Dir, 0, ignoredBy)
+		return nil
+	}
+
+	w.report(file)
+
+	return nil
+}
+
+func (w *projectWalker) ignore(name string, isDir bool, size int64, ignoredBy *gitignore.Pattern) {
+	ignored := entity.UploadPlanFile{Path: name, Dir: isDir, Size: size}
+
+	if ignoredBy != gitignore.GitDir {
+		ignored.Pattern = ignoredBy.Text
+
+		if ignoredBy.Source != gitignore.DefaultSource {
+			ignored.IgnoreFile = ignoredBy.Source
+			ignored.Line = ignoredBy.Line
+
+			// Ignore files of shared directories are named like their files
+			if w.prefix != "" && !filepath.IsAbs(ignoredBy.Source) {
+				ignored.IgnoreFile = path.Join(w.prefix, ignoredBy.Source)
+			}
+		}
+	}
+
+	w.report(ignored)
+}
+
+// report passes an ignored path, relative to the walked directory, to
+// onIgnored with its name in the upload
+func (w *projectWalker) report(file entity.UploadPlanFile) {
+	file.Path = w.prefix + file.Path
+	w.onIgnored(file)
+}
+
+// isWithin tells whether path is dir or inside it, both being absolute and
+// clean
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func isSymlink(fileInfo fs.FileInfo) bool {
+	return fileInfo.Mode()&fs.ModeSymlink != 0
+}
+
+// fileSize is the size of the content uploaded for a file, none for a
+// preserved symlink
+func fileSize(fileInfo fs.FileInfo) int64 {
+	if isSymlink(fileInfo) {
+		return 0
+	}
+
+	return fileInfo.Size()
+}
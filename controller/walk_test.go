@@ -0,0 +1,232 @@
+package controller
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/botwayorg/railway-api/entity"
+)
+
+// newLinkedProject returns a project holding links of every kind, next to a
+// directory outside of it
+func newLinkedProject(t *testing.T) string {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "project")
+
+	for path, content := range map[string]string{
+		"project/main.go":     "package main\n",
+		"project/lib/util.go": "package lib\n",
+		"outside/secret.txt":  "secret\n",
+	} {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for link, target := range map[string]string{
+		"inside":        "lib/util.go",
+		"libdir":        "lib",
+		"lib/cycle":     "..",
+		"broken":        "missing.txt",
+		"escape":        "../outside/secret.txt",
+		"escape-abs":    filepath.Join(dir, "outside", "secret.txt"),
+		"escape-broken": "../outside/missing.txt",
+	} {
+		if err := os.Symlink(target, filepath.Join(root, link)); err != nil {
+			t.Skip("symlinks aren't supported:", err)
+		}
+	}
+
+	return root
+}
+
+// walkedProject is what walking a project included, true for the files that
+// were kept as links, and what it left out, with the reason
+type walkedProject struct {
+	included map[string]bool
+	leftOut  map[string]string
+}
+
+func walk(t *testing.T, root string, symlinks entity.SymlinkPolicy) (*walkedProject, error) {
+	walked := &walkedProject{included: map[string]bool{}, leftOut: map[string]string{}}
+
+	err := walkUpload(&entity.UploadRequest{RootDir: root, Symlinks: symlinks}, func(file entity.UploadPlanFile) {
+		walked.leftOut[file.Path] = file.Reason
+	}, func(path, name string, fileInfo fs.FileInfo) error {
+		walked.included[name] = isSymlink(fileInfo)
+		return nil
+	})
+
+	return walked, err
+}
+
+func TestWalkSymlinks(t *testing.T) {
+	root := newLinkedProject(t)
+
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outside := filepath.Join(filepath.Dir(realRoot), "outside", "secret.txt")
+
+	tests := []struct {
+		policy   entity.SymlinkPolicy
+		included map[string]bool
+		leftOut  map[string]string
+	}{
+		{
+			policy: entity.SymlinksPreserve,
+			included: map[string]bool{
+				"main.go": false, "lib/util.go": false,
+				"inside": true, "libdir": true, "lib/cycle": true, "broken": true,
+			},
+			leftOut: map[string]string{
+				"escape":        "symlink to ../outside/secret.txt, outside of the project",
+				"escape-abs":    "symlink to " + filepath.Join(filepath.Dir(root), "outside", "secret.txt") + ", outside of the project",
+				"escape-broken": "symlink to ../outside/missing.txt, outside of the project",
+			},
+		},
+		{
+			policy: entity.SymlinksFollowWithinRoot,
+			included: map[string]bool{
+				"main.go": false, "lib/util.go": false,
+				"inside": false, "libdir/util.go": false,
+			},
+			leftOut: map[string]string{
+				"broken":        "broken symlink",
+				"escape-broken": "broken symlink",
+				"lib/cycle":     "symlink cycle back to " + realRoot,
+				"libdir/cycle":  "symlink cycle back to " + realRoot,
+				"escape":        "symlink to " + outside + ", outside of the project",
+				"escape-abs":    "symlink to " + outside + ", outside of the project",
+			},
+		},
+		{
+			policy: entity.SymlinksFollow,
+			included: map[string]bool{
+				"main.go": false, "lib/util.go": false,
+				"inside": false, "libdir/util.go": false,
+				"escape": false, "escape-abs": false,
+			},
+			leftOut: map[string]string{
+				"broken":        "broken symlink",
+				"escape-broken": "broken symlink",
+				"lib/cycle":     "symlink cycle back to " + realRoot,
+				"libdir/cycle":  "symlink cycle back to " + realRoot,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.policy), func(t *testing.T) {
+			walked, err := walk(t, root, test.policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(walked.included, test.included) {
+				t.Errorf("included %v, want %v", walked.included, test.included)
+			}
+
+			if !reflect.DeepEqual(walked.leftOut, test.leftOut) {
+				t.Errorf("left out %v, want %v", walked.leftOut, test.leftOut)
+			}
+		})
+	}
+}
+
+func TestWalkSymlinksError(t *testing.T) {
+	root := newLinkedProject(t)
+
+	_, err := walk(t, root, entity.SymlinksError)
+	if err == nil || !strings.Contains(err.Error(), "broken is a symlink to missing.txt") {
+		t.Errorf("err = %v, want the first link refused", err)
+	}
+
+	// Ignored links aren't refused
+	ignore := "broken\nescape*\ninside\nlibdir\nlib/cycle\n"
+	if err := os.WriteFile(filepath.Join(root, ".railwayignore"), []byte(ignore), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := walk(t, root, entity.SymlinksError); err != nil {
+		t.Errorf("ignored links were refused: %s", err)
+	}
+}
+
+// TestUploadPreservedSymlinks makes sure the links leaving the project reach
+// neither archives nor manifests
+func TestUploadPreservedSymlinks(t *testing.T) {
+	for _, incremental := range []bool{false, true} {
+		c, backend := newFakeController(t)
+
+		req := newUploadRequest(t, backend, nil)
+		req.RootDir = newLinkedProject(t)
+		req.Symlinks = entity.SymlinksPreserve
+		req.Incremental = incremental
+
+		if _, err := c.Upload(context.Background(), req); err != nil {
+			t.Fatal(err)
+		}
+
+		upload := backend.Uploads()[0]
+
+		links := map[string]string{}
+		for _, file := range upload.Manifest {
+			if file.Symlink != "" {
+				links[file.Path] = file.Symlink
+			}
+		}
+
+		if !incremental {
+			links = archivedLinks(t, upload.Body)
+		}
+
+		want := map[string]string{"inside": "lib/util.go", "libdir": "lib", "lib/cycle": "..", "broken": "missing.txt"}
+		if !reflect.DeepEqual(links, want) {
+			t.Errorf("incremental %t: uploaded the links %v, want %v", incremental, links, want)
+		}
+	}
+}
+
+// archivedLinks returns the links of a gzipped tarball, by name
+func archivedLinks(t *testing.T, archive []byte) map[string]string {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	links := map[string]string{}
+	tr := tar.NewReader(gz)
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return links
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if header.Typeflag == tar.TypeSymlink {
+			links[header.Name] = header.Linkname
+		}
+	}
+}
@@ -1,17 +1,57 @@
 package entity
 
 type RootConfig struct {
-	User     UserConfig               `json:"user"`
-	Projects map[string]ProjectConfig `json:"projects"`
+	User     UserConfig                 `json:"user"`
+	Projects map[string]ProjectConfig   `json:"projects"`
+	Profile  string                     `json:"profile,omitempty"`
+	Profiles map[string]EndpointProfile `json:"profiles,omitempty"`
+	// Transport holds the TLS and proxy settings for every connection to the API
+	Transport *TransportConfig `json:"transport,omitempty"`
 }
 
 type UserConfig struct {
 	Token string `json:"token"`
 }
 
+// EndpointProfile is a named set of endpoints the gateway can talk to, e.g. a
+// self-hosted backend or a local stand-in server
+type EndpointProfile struct {
+	APIURL string `json:"apiUrl,omitempty"`
+}
+
+// TransportConfig customizes how the CLI connects to the API, for networks
+// with TLS-inspecting proxies or mutual TLS. Empty fields keep the defaults
+type TransportConfig struct {
+	// CAFile is a PEM bundle trusted in addition to the system roots
+	CAFile string `json:"caFile,omitempty"`
+	// ClientCert and ClientKey are a PEM certificate and key presented to
+	// servers asking for one
+	ClientCert string `json:"clientCert,omitempty"`
+	ClientKey  string `json:"clientKey,omitempty"`
+	// ProxyURL replaces the HTTP(S)_PROXY environment variables
+	ProxyURL string `json:"proxyUrl,omitempty"`
+	// NoProxy lists the hosts, domains and CIDRs reached without the proxy,
+	// comma separated
+	NoProxy string `json:"noProxy,omitempty"`
+}
+
 type ProjectConfig struct {
 	ProjectPath     string          `json:"projectPath,omitempty"`
 	Project         string          `json:"project,omitempty"`
 	Environment     string          `json:"environment,omitempty"`
 	LockedEnvsNames map[string]bool `json:"lockedEnvsNames,omitempty"`
+	// Symlinks is the policy uploads of the project follow, empty for the
+	// default
+	Symlinks SymlinkPolicy `json:"symlinks,omitempty"`
+	// Services maps the names of the services of a monorepo to the
+	// directories uploaded for them
+	Services map[string]ServicePaths `json:"services,omitempty"`
+}
+
+// ServicePaths are the directories uploaded for a service, relative to the
+// project path with forward slashes. Path is the root of the upload, and the
+// Shared directories are added to it under the same relative paths
+type ServicePaths struct {
+	Path   string   `json:"path"`
+	Shared []string `json:"shared,omitempty"`
 }
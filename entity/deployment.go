@@ -31,15 +31,10 @@ type DeploymentLogsRequest struct {
 	NumLines     int32  `json:"numLines"`
 }
 
-type DeploymentGQL struct {
-	ID         bool `json:"id"`
-	BuildLogs  bool `json:"buildLogs"`
-	DeployLogs bool `json:"deployLogs"`
-	Status     bool `json:"status"`
-}
-
 type DeploymentByIDRequest struct {
 	ProjectID    string `json:"projectId"`
 	DeploymentID string `json:"deploymentId"`
-	GQL          DeploymentGQL
+	// BuildLogs and DeployLogs select which logs are fetched
+	BuildLogs  bool `json:"buildLogs"`
+	DeployLogs bool `json:"deployLogs"`
 }
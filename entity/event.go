@@ -0,0 +1,136 @@
+package entity
+
+import "time"
+
+// Event is emitted by controller operations for the user interface to render
+type Event interface {
+	event()
+}
+
+// ProgressEvent marks the start of a step that takes a while, or its end when
+// Done is set. Message describes the step, or how it ended
+type ProgressEvent struct {
+	Message string
+	Done    bool
+}
+
+// LogLinesEvent carries build or deploy log lines, in order
+type LogLinesEvent struct {
+	Lines []string
+}
+
+type NoticeLevel int
+
+const (
+	NoticeInfo NoticeLevel = iota
+	NoticeWarning
+	NoticeError
+)
+
+// NoticeEvent is a message for the user that doesn't stop the operation
+type NoticeEvent struct {
+	Level   NoticeLevel
+	Message string
+}
+
+// UploadFindingsEvent lists the files of an upload that look like secrets or
+// are too large, and that nothing allows. In strict mode, the upload stops
+// there
+type UploadFindingsEvent struct {
+	Findings []UploadFinding
+	Strict   bool
+}
+
+// LoginCodeEvent asks the user to enter the pairing code at URL to finish
+// logging in
+type LoginCodeEvent struct {
+	Code string
+	URL  string
+}
+
+type UploadPhase int
+
+const (
+	// UploadScanning is when the project is walked to find what to upload
+	UploadScanning UploadPhase = iota
+	// UploadSending is when the project is archived and sent at once, or
+	// when the files the server is missing are sent
+	UploadSending
+	UploadFinished
+	UploadFailed
+)
+
+// UploadProgressEvent reports how far archiving and uploading the project got.
+// It is sent repeatedly while the upload runs, and a last time with the
+// UploadFinished or UploadFailed phase
+type UploadProgressEvent struct {
+	Phase         UploadPhase
+	FilesIncluded int
+	FilesIgnored  int
+	// Incremental is set when only the files missing on the server are
+	// sent, FilesReused being those it already had
+	Incremental bool
+	FilesReused int
+	// TotalBytes is the size of the files to send, once they are all found
+	TotalBytes int64
+	// ArchivedBytes is how much of TotalBytes was read so far
+	ArchivedBytes int64
+	// UploadedBytes is how much of the compressed archive, or of the missing
+	// files, was sent so far
+	UploadedBytes int64
+	// Elapsed is the time since sending started
+	Elapsed time.Duration
+}
+
+// FilesScanned is the number of files looked at so far
+func (e UploadProgressEvent) FilesScanned() int {
+	return e.FilesIncluded + e.FilesIgnored
+}
+
+// Fraction is how much of the project was sent so far, between 0 and 1
+func (e UploadProgressEvent) Fraction() float64 {
+	if e.Phase == UploadFinished {
+		return 1
+	}
+
+	if e.TotalBytes == 0 {
+		return 0
+	}
+
+	return float64(e.ArchivedBytes) / float64(e.TotalBytes)
+}
+
+// Throughput is the number of compressed bytes sent per second
+func (e UploadProgressEvent) Throughput() float64 {
+	if e.Elapsed <= 0 {
+		return 0
+	}
+
+	return float64(e.UploadedBytes) / e.Elapsed.Seconds()
+}
+
+// ETA estimates how long sending the rest of the project takes, zero when it
+// can't be told yet
+func (e UploadProgressEvent) ETA() time.Duration {
+	fraction := e.Fraction()
+	if fraction <= 0 || fraction >= 1 {
+		return 0
+	}
+
+	return time.Duration(float64(e.Elapsed) * (1 - fraction) / fraction)
+}
+
+func (ProgressEvent) event()       {}
+func (LogLinesEvent) event()       {}
+func (NoticeEvent) event()         {}
+func (LoginCodeEvent) event()      {}
+func (UploadProgressEvent) event() {}
+func (UploadFindingsEvent) event() {}
+
+// ConfirmationRequest is a yes/no question an operation needs answered before
+// going on
+type ConfirmationRequest struct {
+	// Reason tells why the confirmation is needed, e.g. a protected environment
+	Reason   string
+	Question string
+}
@@ -1,16 +1,91 @@
 package entity
 
-import "bytes"
+import (
+	"io"
+	"time"
+)
 
 type UploadRequest struct {
 	ProjectID     string
 	EnvironmentID string
 	ServiceID     string
-	RootDir       string
+	// RootDir is the directory uploaded, and Shared are directories of the
+	// project uploaded along with it
+	RootDir string
+	Shared  []SharedDir
+	// ProjectDir is the directory symlinks may not lead out of with the
+	// follow-within-root policy, RootDir when empty
+	ProjectDir string
+	Symlinks   SymlinkPolicy
+	Scan       UploadScan
+	// Incremental only sends the files the server doesn't have yet, on
+	// servers that take manifests
+	Incremental bool
 }
 
+// SharedDir is a directory uploaded along with the root of an upload, as if
+// it was in the root under Name, which has forward slashes
+type SharedDir struct {
+	Path string
+	Name string
+}
+
+// UploadScan configures the scan of uploads for files that look like secrets
+// or are too large to deploy
+type UploadScan struct {
+	// Strict refuses uploads holding flagged files that aren't allowed
+	Strict bool
+	// MaxFileSize flags larger files, 0 meaning the default
+	MaxFileSize int64
+	// Allow are ignore-style patterns of flagged files to upload anyway
+	Allow []string
+}
+
+type UploadFindingKind string
+
+const (
+	UploadFindingSecret UploadFindingKind = "secret"
+	UploadFindingLarge  UploadFindingKind = "large"
+)
+
+// UploadFinding is a file of an upload that likely shouldn't be deployed
+type UploadFinding struct {
+	// Path is relative to the root of the upload, with forward slashes
+	Path string
+	Size int64
+	Kind UploadFindingKind
+	// Reason tells what was found, like "private key on line 1"
+	Reason string
+	// AllowedBy is the "!" pattern of a .railwayignore, or the --allow
+	// pattern, letting the file be uploaded anyway. It is empty when nothing
+	// allows it
+	AllowedBy string
+}
+
+// SymlinkPolicy decides what uploads do with the symlinks of a project
+type SymlinkPolicy string
+
+const (
+	// SymlinksPreserve uploads links that stay inside the project as links,
+	// without following them, and leaves out the others
+	SymlinksPreserve SymlinkPolicy = "preserve"
+	// SymlinksFollowWithinRoot uploads the targets of links that stay inside
+	// the project, and leaves out the others. It is the default
+	SymlinksFollowWithinRoot SymlinkPolicy = "follow-within-root"
+	// SymlinksFollow uploads the targets of all links, wherever they are
+	SymlinksFollow SymlinkPolicy = "follow"
+	// SymlinksError fails uploads of projects holding links that aren't
+	// ignored
+	SymlinksError SymlinkPolicy = "error"
+)
+
+// SymlinkPolicies are the valid policies, the default first
+var SymlinkPolicies = []SymlinkPolicy{SymlinksFollowWithinRoot, SymlinksPreserve, SymlinksFollow, SymlinksError}
+
 type UpRequest struct {
-	Data          bytes.Buffer
+	// Data is the gzipped tarball of the project. It is read once, as it is
+	// uploaded
+	Data          io.Reader
 	ProjectID     string
 	EnvironmentID string
 	ServiceID     string
@@ -19,9 +94,92 @@ type UpRequest struct {
 type UpResponse struct {
 	URL              string
 	DeploymentDomain string
+	// Digest identifies the deployed sources, see UploadRecord
+	Digest string
+}
+
+// UploadRecord is what is remembered locally about an upload. Digest is the
+// SHA-256 of the path, mode and content of every uploaded file, the same for
+// identical sources whichever machine uploads them
+type UploadRecord struct {
+	Time          time.Time `json:"time"`
+	ProjectID     string    `json:"projectId"`
+	EnvironmentID string    `json:"environmentId"`
+	ServiceID     string    `json:"serviceId,omitempty"`
+	RootDir       string    `json:"rootDir"`
+	Shared        []string  `json:"shared,omitempty"`
+	Files         int       `json:"files"`
+	Digest        string    `json:"digest"`
+	URL           string    `json:"url"`
 }
 
 type UpErrorResponse struct {
 	Message   string `json:"message"`
 	RequestID string `json:"reqId"`
 }
+
+// UploadPlan lists what uploading a directory would archive, and what it
+// would leave out
+type UploadPlan struct {
+	RootDir  string
+	Shared   []SharedDir
+	Included []UploadPlanFile
+	Ignored  []UploadPlanFile
+	// Findings are the included files that look like secrets or are too
+	// large, allowed or not
+	Findings []UploadFinding
+}
+
+type UploadPlanFile struct {
+	// Path is relative to the root of the upload, with forward slashes
+	Path string
+	Size int64
+	// Dir is set for directories that are skipped as a whole
+	Dir bool
+	// IgnoreFile is the ignore file that excluded the path, relative to the
+	// root of the upload unless it is a global one, with the Line and Pattern
+	// that matched. It is empty for the patterns built into the CLI, and
+	// Pattern too for what is never uploaded, like .git
+	IgnoreFile string
+	Line       int
+	Pattern    string
+	// Reason explains why a path that no pattern ignores is left out, like a
+	// symlink leaving the project
+	Reason string
+}
+
+// ManifestFile is a file of an incremental upload. Its content is sent as a
+// blob named by its SHA-256, which the backend keeps between uploads
+type ManifestFile struct {
+	// Path is relative to the root of the upload, with forward slashes
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+	// Mode is 0755 for executables and 0644 for other files
+	Mode int64 `json:"mode"`
+	// Symlink is the target of a link uploaded as a link, which has no
+	// content, hash or size
+	Symlink string `json:"symlink,omitempty"`
+}
+
+type MissingBlobsRequest struct {
+	ProjectID     string
+	EnvironmentID string
+	Hashes        []string
+}
+
+type UploadBlobRequest struct {
+	ProjectID     string
+	EnvironmentID string
+	SHA256        string
+	Size          int64
+	// Data is the content of the file, read once as it is uploaded
+	Data io.Reader
+}
+
+type UpManifestRequest struct {
+	ProjectID     string
+	EnvironmentID string
+	ServiceID     string
+	Files         []ManifestFile
+}
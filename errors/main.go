@@ -25,6 +25,8 @@ var (
 	CommandNotSpecified                 RailwayError = fmt.Errorf("%s\nRun %s", ui.RedText("Specify a command to run inside the railway environment. Not providing a command will build and run the Dockerfile in the current directory."), ui.Bold("railway run [cmd]"))
 	LoginFailed                         RailwayError = fmt.Errorf("%s", ui.RedText("Login failed"))
 	LoginTimeout                        RailwayError = fmt.Errorf("%s", ui.RedText("Login timeout"))
+	CommandInterrupted                  RailwayError = fmt.Errorf("%s", "Interrupted")
+	CommandTimedOut                     RailwayError = fmt.Errorf("%s\nRaise the limit with %s", ui.RedText("The command timed out."), ui.Bold("--timeout"))
 	PluginAlreadyExists                 RailwayError = fmt.Errorf("%s", ui.RedText("Plugin already exists"))
 	PluginNotSpecified                  RailwayError = fmt.Errorf("%s\nRun %s", ui.RedText("Specify a plugin to create."), ui.Bold("railway add <plugin>"))
 	PluginCreateFailed                  RailwayError = fmt.Errorf("%s\nUhh Ohh! One of our trains derailed.", ui.RedText("There was a problem creating the plugin."))
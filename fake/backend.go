@@ -0,0 +1,335 @@
+// Package fake is an in-memory stand-in for the Railway backboard, for running
+// the CLI and programs embedding the gateway without network access or an
+// account. It answers the GraphQL operations of the gateway from the
+// checked-in schema, accepts uploads, and plays deployments through their
+// statuses while writing logs
+//
+//	backend := fake.New()
+//	client := gateway.NewClient(
+//		gateway.WithHost(fake.Host),
+//		gateway.WithHTTPClient(backend.Client()),
+//		gateway.WithToken(fake.UserToken),
+//	)
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/botwayorg/railway-api/entity"
+	"github.com/botwayorg/railway-api/gateway"
+	"github.com/botwayorg/railway-api/lib/gql"
+)
+
+const (
+	// Host is where the client of Client sends requests. Any host works, the
+	// requests never leave the process
+	Host = "http://backboard.fake"
+	// UserToken logs in as the user of the backend
+	UserToken = "fake-user-token"
+	// ProjectToken gives access to the production environment of the demo
+	// project
+	ProjectToken = "fake-project-token"
+)
+
+// Backend is a fake backboard holding its state in memory. It is safe for
+// concurrent use
+type Backend struct {
+	mu     sync.Mutex
+	schema *gql.Schema
+	now    func() time.Time
+	nextID int
+
+	user          *user
+	teams         []*team
+	projects      []*project
+	userTokens    map[string]bool
+	projectTokens map[string]*entity.ProjectToken
+	loginSessions map[string]*loginSession
+	workflows     map[string]*workflow
+	deployments   []*deployment
+	// variables are keyed by environment, then by the service or plugin
+	// they belong to
+	variables map[string]map[string]entity.Envs
+	uploads   []*Upload
+	// blobs holds the files of incremental uploads by project, then by
+	// SHA-256
+	blobs               map[string]map[string][]byte
+	incrementalDisabled bool
+	failNext            bool
+}
+
+type user struct {
+	id    string
+	email string
+	name  string
+}
+
+type team struct {
+	id   string
+	name string
+}
+
+type project struct {
+	id           string
+	name         string
+	description  string
+	updatedAt    time.Time
+	team         *team
+	environments []*entity.Environment
+	services     []*entity.Service
+	plugins      []*entity.Plugin
+}
+
+type loginSession struct {
+	// polls counts the attempts to consume the session, which is approved
+	// from the second one on
+	polls int
+	token string
+}
+
+type workflow struct {
+	polls int
+}
+
+// Upload is a deployment upload the backend received
+type Upload struct {
+	ProjectID     string
+	EnvironmentID string
+	ServiceID     string
+	DeploymentID  string
+	// Body is the archive as uploaded
+	Body []byte
+	// Manifest lists the files of an incremental upload, which has no Body
+	Manifest []entity.ManifestFile
+}
+
+// New returns a backend seeded with a user, a team, and a demo project with a
+// production environment, a web service, the env plugin and a successful
+// deployment. UserToken and ProjectToken authenticate against it
+func New() *Backend {
+	schema, err := gateway.Schema()
+	if err != nil {
+		panic(err)
+	}
+
+	b := &Backend{
+		schema:        schema,
+		now:           time.Now,
+		userTokens:    map[string]bool{UserToken: true},
+		projectTokens: make(map[string]*entity.ProjectToken),
+		loginSessions: make(map[string]*loginSession),
+		workflows:     make(map[string]*workflow),
+		variables:     make(map[string]map[string]entity.Envs),
+		blobs:         make(map[string]map[string][]byte),
+	}
+
+	b.user = &user{id: b.newID("user"), email: "dev@example.com", name: "Fake User"}
+	b.teams = []*team{{id: b.newID("team"), name: "Fake Team"}}
+
+	demo := b.createProject("demo", "A project to try the CLI on", nil)
+	web := b.createService(demo, "web")
+	production := demo.environments[0]
+
+	b.setVariables(production.Id, b.plugin(demo, "env").ID, entity.Envs{"GREETING": "hello"}, false)
+	b.setVariables(production.Id, web.ID, entity.Envs{"PORT": "3000"}, false)
+
+	deploy := b.createDeployment(demo, production.Id, web.ID)
+	for deploy.status != entity.STATUS_SUCCESS {
+		b.advance(deploy)
+	}
+
+	b.projectTokens[ProjectToken] = &entity.ProjectToken{
+		ProjectId:     demo.id,
+		EnvironmentId: production.Id,
+	}
+
+	return b
+}
+
+func (b *Backend) newID(kind string) string {
+	b.nextID++
+
+	return fmt.Sprintf("%s-%04d", kind, b.nextID)
+}
+
+// Client returns an HTTP client serving requests from the backend without
+// opening connections, for gateway.WithHTTPClient
+func (b *Backend) Client() *http.Client {
+	return &http.Client{Transport: roundTripper{b}}
+}
+
+type roundTripper struct {
+	backend *Backend
+}
+
+func (t roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+
+	recorder := httptest.NewRecorder()
+	t.backend.ServeHTTP(recorder, req)
+
+	res := recorder.Result()
+	res.Request = req
+
+	return res, nil
+}
+
+// Token returns a new user token, as if the user had logged in
+func (b *Backend) Token() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	token := b.newID("token")
+	b.userTokens[token] = true
+
+	return token
+}
+
+// Projects returns the projects of the backend with their environments,
+// services and plugins
+func (b *Backend) Projects() []*entity.Project {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	projects := make([]*entity.Project, len(b.projects))
+
+	for i, p := range b.projects {
+		projects[i] = p.entity()
+	}
+
+	return projects
+}
+
+// Variables returns the variables a service sees in an environment, or the
+// shared ones of the env plugin when serviceID is empty
+func (b *Backend) Variables(projectID, environmentID, serviceID string) entity.Envs {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	p := b.project(projectID)
+	if p == nil {
+		return nil
+	}
+
+	return b.serviceVariables(p, environmentID, serviceID)
+}
+
+// Deployments returns the deployments of an environment, newest first
+func (b *Backend) Deployments(projectID, environmentID string) []*entity.Deployment {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var deployments []*entity.Deployment
+
+	for _, d := range b.environmentDeployments(projectID, environmentID) {
+		deployments = append(deployments, d.entity())
+	}
+
+	return deployments
+}
+
+// Uploads returns the uploads received so far, oldest first
+func (b *Backend) Uploads() []*Upload {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]*Upload{}, b.uploads...)
+}
+
+// FailNextDeployment makes the build of the next deployment fail
+func (b *Backend) FailNextDeployment() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failNext = true
+}
+
+// caller is who sent a request: the user, a project token, or nobody
+type caller struct {
+	user         bool
+	userToken    string
+	projectToken *entity.ProjectToken
+}
+
+type callerKey struct{}
+
+func callerFrom(ctx context.Context) *caller {
+	c, _ := ctx.Value(callerKey{}).(*caller)
+	return c
+}
+
+// authenticate resolves the credentials of a request. Unknown tokens are
+// rejected outright, missing ones only by the fields needing them
+func (b *Backend) authenticate(req *http.Request) (*caller, bool) {
+	if token := req.Header.Get("project-access-token"); token != "" {
+		projectToken, ok := b.projectTokens[token]
+		return &caller{projectToken: projectToken}, ok
+	}
+
+	if auth := req.Header.Get("authorization"); auth != "" {
+		token := strings.TrimPrefix(auth, "Bearer ")
+		return &caller{user: true, userToken: token}, b.userTokens[token]
+	}
+
+	return &caller{}, true
+}
+
+// ServeHTTP serves the GraphQL endpoint on /graphql and deployment uploads
+// below /project/{id}/environment/{id}/up
+func (b *Backend) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.authenticate(req)
+	if !ok {
+		writeJSON(w, http.StatusOK, &gql.Response{Errors: []*gql.Error{{
+			Message:    "Not Authorized",
+			Extensions: map[string]interface{}{"code": "UNAUTHENTICATED"},
+		}}})
+
+		return
+	}
+
+	ctx := context.WithValue(req.Context(), callerKey{}, c)
+
+	switch {
+	case req.URL.Path == "/graphql" && req.Method == http.MethodPost:
+		b.serveGraphQL(ctx, w, req)
+
+	case strings.HasPrefix(req.URL.Path, "/project/"):
+		b.serveUpload(ctx, w, req)
+
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func (b *Backend) serveGraphQL(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+	var gqlReq gql.Request
+
+	if err := json.NewDecoder(req.Body).Decode(&gqlReq); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, b.schema.Execute(ctx, &gqlReq, b.root()))
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		io.WriteString(w, err.Error())
+	}
+}
@@ -0,0 +1,149 @@
+package fake
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/botwayorg/railway-api/entity"
+)
+
+const timeFormat = time.RFC3339
+
+type deployment struct {
+	id            string
+	projectID     string
+	environmentID string
+	serviceID     string
+	status        string
+	staticURL     string
+	createdAt     time.Time
+	buildLogs     []string
+	deployLogs    []string
+	// script holds the steps the deployment has yet to go through
+	script []deploymentStep
+}
+
+// deploymentStep is a status a deployment reaches, with the log lines written
+// on the way
+type deploymentStep struct {
+	status     string
+	buildLogs  []string
+	deployLogs []string
+}
+
+var successfulDeployment = []deploymentStep{
+	{status: entity.STATUS_BUILDING, buildLogs: []string{"==> Building with the fake builder", "Installing dependencies"}},
+	{status: entity.STATUS_BUILDING, buildLogs: []string{"Build completed"}},
+	{status: entity.STATUS_DEPLOYING, deployLogs: []string{"Starting container"}},
+	{status: entity.STATUS_SUCCESS, deployLogs: []string{"Listening on port 3000"}},
+}
+
+var failedDeployment = []deploymentStep{
+	{status: entity.STATUS_BUILDING, buildLogs: []string{"==> Building with the fake builder", "Installing dependencies"}},
+	{status: entity.STATUS_FAILED, buildLogs: []string{"error: the build script exited with code 1"}},
+}
+
+// createDeployment starts a deployment in its first step. Every time it is
+// fetched by ID afterwards, it moves on to the next one
+func (b *Backend) createDeployment(p *project, environmentID, serviceID string) *deployment {
+	script := successfulDeployment
+	if b.failNext {
+		script = failedDeployment
+		b.failNext = false
+	}
+
+	name := p.name
+	if service := b.service(p, serviceID); service != nil {
+		name = service.Name
+	}
+
+	env := b.environment(p, environmentID)
+
+	d := &deployment{
+		id:            b.newID("deployment"),
+		projectID:     p.id,
+		environmentID: environmentID,
+		serviceID:     serviceID,
+		staticURL:     fmt.Sprintf("%s-%s.up.railway.app", slug(name), slug(env.Name)),
+		createdAt:     b.now(),
+		script:        script,
+	}
+
+	b.advance(d)
+
+	// Newest first
+	b.deployments = append([]*deployment{d}, b.deployments...)
+	p.updatedAt = b.now()
+
+	return d
+}
+
+// advance moves a deployment to its next step. A successful deployment
+// replaces the previous one of its service
+func (b *Backend) advance(d *deployment) {
+	if len(d.script) == 0 {
+		return
+	}
+
+	step := d.script[0]
+	d.script = d.script[1:]
+
+	d.status = step.status
+	d.buildLogs = append(d.buildLogs, step.buildLogs...)
+	d.deployLogs = append(d.deployLogs, step.deployLogs...)
+
+	if d.status != entity.STATUS_SUCCESS {
+		return
+	}
+
+	for _, other := range b.deployments {
+		if other != d && other.environmentID == d.environmentID && other.serviceID == d.serviceID &&
+			other.status == entity.STATUS_SUCCESS {
+			other.status = entity.STATUS_REMOVED
+		}
+	}
+}
+
+func (b *Backend) deployment(id string) *deployment {
+	for _, d := range b.deployments {
+		if d.id == id {
+			return d
+		}
+	}
+
+	return nil
+}
+
+func (b *Backend) environmentDeployments(projectID, environmentID string) []*deployment {
+	var deployments []*deployment
+
+	for _, d := range b.deployments {
+		if d.projectID == projectID && d.environmentID == environmentID {
+			deployments = append(deployments, d)
+		}
+	}
+
+	return deployments
+}
+
+func (d *deployment) entity() *entity.Deployment {
+	return &entity.Deployment{
+		ID:         d.id,
+		ProjectID:  d.projectID,
+		Status:     d.status,
+		StaticUrl:  d.staticURL,
+		BuildLogs:  joinLogs(d.buildLogs),
+		DeployLogs: joinLogs(d.deployLogs),
+	}
+}
+
+// joinLogs formats log lines the way the backboard does, each line ending
+// with a newline
+func joinLogs(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,645 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/botwayorg/railway-api/entity"
+	"github.com/botwayorg/railway-api/lib/gql"
+)
+
+// root holds the fields of the Query and Mutation types alike, as their names
+// don't overlap
+func (b *Backend) root() gql.Object {
+	return gql.Object{
+		// Queries
+		"me":                           gql.Resolver(b.me),
+		"projectById":                  gql.Resolver(b.projectByID),
+		"projectToken":                 gql.Resolver(b.projectToken),
+		"availablePluginsForProject":   gql.Resolver(b.availablePlugins),
+		"allDeploymentsForEnvironment": gql.Resolver(b.allDeploymentsForEnvironment),
+		"deploymentById":               gql.Resolver(b.deploymentByID),
+		"decryptedVariablesForService": gql.Resolver(b.decryptedVariables),
+		"getWritableGithubScopes":      gql.Resolver(b.githubScopes),
+		"getAllStarters":               gql.Resolver(b.starters),
+		"getWorkflowStatus":            gql.Resolver(b.workflowStatus),
+
+		// Mutations
+		"createLoginSession":         gql.Resolver(b.createLoginSession),
+		"consumeLoginSession":        gql.Resolver(b.consumeLoginSession),
+		"logout":                     gql.Resolver(b.logout),
+		"createProject":              gql.Resolver(b.createProjectField),
+		"createProjectFromTemplate":  gql.Resolver(b.createProjectFromTemplate),
+		"updateProject":              gql.Resolver(b.updateProject),
+		"deleteProject":              gql.Resolver(b.deleteProjectField),
+		"createEnvironment":          gql.Resolver(b.createEnvironmentField),
+		"createEphemeralEnvironment": gql.Resolver(b.createEphemeralEnvironment),
+		"deleteEnvironment":          gql.Resolver(b.deleteEnvironmentField),
+		"createPlugin":               gql.Resolver(b.createPluginField),
+		"upsertVariablesFromObject":  gql.Resolver(b.updateVariables(false)),
+		"variablesSetFromObject":     gql.Resolver(b.updateVariables(true)),
+		"deleteVariable":             gql.Resolver(b.deleteVariable),
+		"deployEnvironmentTriggers":  gql.Resolver(b.deployEnvironmentTriggers),
+		"removeDeployment":           gql.Resolver(b.removeDeployment),
+		"sendTelemetry":              gql.Resolver(b.sendTelemetry),
+	}
+}
+
+func stringArg(args map[string]interface{}, name string) string {
+	s, _ := args[name].(string)
+	return s
+}
+
+func stringsArg(args map[string]interface{}, name string) []string {
+	var values []string
+
+	items, _ := args[name].([]interface{})
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			values = append(values, s)
+		}
+	}
+
+	return values
+}
+
+// envsArg reads a Json argument holding variables, stringifying the values
+func envsArg(args map[string]interface{}, name string) (entity.Envs, error) {
+	object, ok := args[name].(map[string]interface{})
+	if !ok && args[name] != nil {
+		return nil, badInput("%s must be an object", name)
+	}
+
+	envs := make(entity.Envs)
+
+	for key, value := range object {
+		if s, ok := value.(string); ok {
+			envs.Set(key, s)
+		} else {
+			envs.Set(key, fmt.Sprint(value))
+		}
+	}
+
+	return envs, nil
+}
+
+func badInput(format string, args ...interface{}) error {
+	return &gql.FieldError{Code: "BAD_USER_INPUT", Message: fmt.Sprintf(format, args...)}
+}
+
+func notFound(what string) error {
+	return &gql.FieldError{Code: "NOT_FOUND", Message: what + " not found"}
+}
+
+func (b *Backend) userObject() gql.Object {
+	teams := make([]gql.Object, len(b.teams))
+
+	for i, t := range b.teams {
+		teams[i] = b.teamObject(t)
+	}
+
+	return gql.Object{
+		"id":     b.user.id,
+		"email":  b.user.email,
+		"name":   b.user.name,
+		"has2FA": false,
+		"teams":  teams,
+		"projects": gql.Resolver(func(_ context.Context, args map[string]interface{}) (interface{}, error) {
+			where, _ := args["where"].(map[string]interface{})
+			filter, _ := where["name"].(map[string]interface{})
+
+			projects := []gql.Object{}
+
+			for _, p := range b.projects {
+				if p.team != nil || !matchesFilter(p.name, filter) {
+					continue
+				}
+
+				projects = append(projects, b.projectObject(p))
+			}
+
+			return projects, nil
+		}),
+	}
+}
+
+func matchesFilter(value string, filter map[string]interface{}) bool {
+	if equals, ok := filter["equals"].(string); ok && value != equals {
+		return false
+	}
+
+	if contains, ok := filter["contains"].(string); ok && !strings.Contains(value, contains) {
+		return false
+	}
+
+	return true
+}
+
+func (b *Backend) teamObject(t *team) gql.Object {
+	return gql.Object{
+		"id":   t.id,
+		"name": t.name,
+		// Resolved lazily, only when selected
+		"projects": gql.Resolver(func(context.Context, map[string]interface{}) (interface{}, error) {
+			projects := []gql.Object{}
+
+			for _, p := range b.projects {
+				if p.team == t {
+					projects = append(projects, b.projectObject(p))
+				}
+			}
+
+			return projects, nil
+		}),
+	}
+}
+
+func (b *Backend) projectObject(p *project) gql.Object {
+	environments := make([]gql.Object, len(p.environments))
+	for i, env := range p.environments {
+		environments[i] = gql.Object{"id": env.Id, "name": env.Name}
+	}
+
+	services := make([]gql.Object, len(p.services))
+	for i, service := range p.services {
+		services[i] = gql.Object{"id": service.ID, "name": service.Name}
+	}
+
+	plugins := make([]gql.Object, len(p.plugins))
+	for i, plugin := range p.plugins {
+		plugins[i] = gql.Object{"id": plugin.ID, "name": plugin.Name}
+	}
+
+	return gql.Object{
+		"id":           p.id,
+		"name":         p.name,
+		"description":  p.description,
+		"updatedAt":    p.updatedAt.UTC().Format(timeFormat),
+		"environments": environments,
+		"services":     services,
+		"plugins":      plugins,
+	}
+}
+
+func deploymentObject(d *deployment) gql.Object {
+	return gql.Object{
+		"id":         d.id,
+		"projectId":  d.projectID,
+		"status":     d.status,
+		"meta":       nil,
+		"staticUrl":  d.staticURL,
+		"buildLogs":  joinLogs(d.buildLogs),
+		"deployLogs": joinLogs(d.deployLogs),
+	}
+}
+
+func (b *Backend) me(ctx context.Context, _ map[string]interface{}) (interface{}, error) {
+	if err := b.authorizeUser(callerFrom(ctx)); err != nil {
+		return nil, err
+	}
+
+	return b.userObject(), nil
+}
+
+func (b *Backend) projectByID(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	p, err := b.authorizeProject(callerFrom(ctx), stringArg(args, "projectId"))
+	if err != nil {
+		return nil, err
+	}
+
+	return b.projectObject(p), nil
+}
+
+func (b *Backend) projectToken(ctx context.Context, _ map[string]interface{}) (interface{}, error) {
+	c := callerFrom(ctx)
+	if c == nil || c.projectToken == nil {
+		return nil, errNotAuthorized
+	}
+
+	return gql.Object{
+		"projectId":     c.projectToken.ProjectId,
+		"environmentId": c.projectToken.EnvironmentId,
+	}, nil
+}
+
+func (b *Backend) availablePlugins(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	p, err := b.authorizeProject(callerFrom(ctx), stringArg(args, "projectId"))
+	if err != nil {
+		return nil, err
+	}
+
+	available := []string{}
+
+	for name := range knownPlugins {
+		if b.plugin(p, name) == nil {
+			available = append(available, name)
+		}
+	}
+
+	sort.Strings(available)
+
+	return available, nil
+}
+
+func (b *Backend) allDeploymentsForEnvironment(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	projectID, environmentID := stringArg(args, "projectId"), stringArg(args, "environmentId")
+
+	if _, err := b.authorizeEnvironment(callerFrom(ctx), projectID, environmentID); err != nil {
+		return nil, err
+	}
+
+	nodes := []gql.Object{}
+
+	for _, d := range b.environmentDeployments(projectID, environmentID) {
+		nodes = append(nodes, deploymentObject(d))
+	}
+
+	return nodes, nil
+}
+
+// deploymentByID returns the deployment as it is, then moves it to its next
+// step for the following fetch
+func (b *Backend) deploymentByID(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	d, err := b.authorizeDeployment(callerFrom(ctx), stringArg(args, "projectId"), stringArg(args, "deploymentId"))
+	if err != nil {
+		return nil, err
+	}
+
+	object := deploymentObject(d)
+	b.advance(d)
+
+	return object, nil
+}
+
+func (b *Backend) authorizeDeployment(c *caller, projectID, deploymentID string) (*deployment, error) {
+	if _, err := b.authorizeProject(c, projectID); err != nil {
+		return nil, err
+	}
+
+	d := b.deployment(deploymentID)
+	if d == nil || d.projectID != projectID {
+		return nil, notFound("Deployment")
+	}
+
+	if c.projectToken != nil && c.projectToken.EnvironmentId != d.environmentID {
+		return nil, errNotAuthorized
+	}
+
+	return d, nil
+}
+
+func (b *Backend) decryptedVariables(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	projectID, environmentID := stringArg(args, "projectId"), stringArg(args, "environmentId")
+
+	p, err := b.authorizeEnvironment(callerFrom(ctx), projectID, environmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceID := stringArg(args, "serviceId")
+	if serviceID != "" && b.service(p, serviceID) == nil {
+		return nil, notFound("Service")
+	}
+
+	return b.serviceVariables(p, environmentID, serviceID), nil
+}
+
+func (b *Backend) githubScopes(ctx context.Context, _ map[string]interface{}) (interface{}, error) {
+	if err := b.authorizeUser(callerFrom(ctx)); err != nil {
+		return nil, err
+	}
+
+	scopes := []string{slug(b.user.name)}
+
+	for _, t := range b.teams {
+		scopes = append(scopes, slug(t.name))
+	}
+
+	return scopes, nil
+}
+
+func (b *Backend) starters(context.Context, map[string]interface{}) (interface{}, error) {
+	starters := []gql.Object{}
+
+	for _, name := range []string{"nodejs", "python", "go"} {
+		url := "https://github.com/railwayapp/starters/tree/master/examples/" + name
+
+		starters = append(starters, gql.Object{
+			"title":  name,
+			"url":    "https://railway.app/new/template?template=" + url,
+			"source": url,
+		})
+	}
+
+	return starters, nil
+}
+
+// workflowStatus reports workflows as running the first time they are
+// checked, and complete afterwards
+func (b *Backend) workflowStatus(_ context.Context, args map[string]interface{}) (interface{}, error) {
+	w := b.workflows[stringArg(args, "workflowId")]
+	if w == nil {
+		return nil, notFound("Workflow")
+	}
+
+	w.polls++
+
+	status := entity.WorkflowComplete
+	if w.polls == 1 {
+		status = entity.WorkflowRunning
+	}
+
+	return gql.Object{"status": string(status)}, nil
+}
+
+func (b *Backend) createLoginSession(context.Context, map[string]interface{}) (interface{}, error) {
+	code := b.newID("login")
+	b.loginSessions[code] = &loginSession{}
+
+	return code, nil
+}
+
+// consumeLoginSession returns no token the first time, as if the user hadn't
+// entered the code yet, and a new token from then on
+func (b *Backend) consumeLoginSession(_ context.Context, args map[string]interface{}) (interface{}, error) {
+	code := stringArg(args, "code")
+
+	session := b.loginSessions[code]
+	if session == nil {
+		return nil, notFound("Login session")
+	}
+
+	session.polls++
+
+	if session.polls == 1 {
+		return nil, nil
+	}
+
+	if session.token == "" {
+		session.token = b.newID("token")
+		b.userTokens[session.token] = true
+	}
+
+	return session.token, nil
+}
+
+func (b *Backend) logout(ctx context.Context, _ map[string]interface{}) (interface{}, error) {
+	c := callerFrom(ctx)
+	if err := b.authorizeUser(c); err != nil {
+		return nil, err
+	}
+
+	delete(b.userTokens, c.userToken)
+
+	return true, nil
+}
+
+func (b *Backend) createProjectField(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	if err := b.authorizeUser(callerFrom(ctx)); err != nil {
+		return nil, err
+	}
+
+	plugins := stringsArg(args, "plugins")
+	for _, plugin := range plugins {
+		if _, ok := knownPlugins[plugin]; !ok {
+			return nil, badInput("plugin %s does not exist", plugin)
+		}
+	}
+
+	name := stringArg(args, "name")
+	if name == "" {
+		name = fmt.Sprintf("project-%d", len(b.projects)+1)
+	}
+
+	return b.projectObject(b.createProject(name, stringArg(args, "description"), plugins)), nil
+}
+
+// createProjectFromTemplate creates a project with a single service named
+// after the template, and a workflow standing for the deployment of the
+// template
+func (b *Backend) createProjectFromTemplate(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	if err := b.authorizeUser(callerFrom(ctx)); err != nil {
+		return nil, err
+	}
+
+	variables, err := envsArg(args, "variables")
+	if err != nil {
+		return nil, err
+	}
+
+	p := b.createProject(stringArg(args, "name"), "", stringsArg(args, "plugins"))
+
+	for _, t := range b.teams {
+		if slug(t.name) == stringArg(args, "owner") {
+			p.team = t
+		}
+	}
+
+	service := b.createService(p, path.Base(stringArg(args, "template")))
+	production := p.environments[0]
+
+	b.setVariables(production.Id, service.ID, variables, false)
+	b.createDeployment(p, production.Id, service.ID)
+
+	workflowID := b.newID("workflow")
+	b.workflows[workflowID] = &workflow{}
+
+	return gql.Object{"projectId": p.id, "workflowId": workflowID}, nil
+}
+
+func (b *Backend) updateProject(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	p, err := b.authorizeProject(callerFrom(ctx), stringArg(args, "projectId"))
+	if err != nil {
+		return nil, err
+	}
+
+	p.updatedAt = b.now()
+
+	return b.projectObject(p), nil
+}
+
+func (b *Backend) deleteProjectField(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	c := callerFrom(ctx)
+	if err := b.authorizeUser(c); err != nil {
+		return nil, err
+	}
+
+	p, err := b.authorizeProject(c, stringArg(args, "projectId"))
+	if err != nil {
+		return nil, err
+	}
+
+	b.deleteProject(p)
+
+	return true, nil
+}
+
+func (b *Backend) createEnvironmentField(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	p, err := b.authorizeProject(callerFrom(ctx), stringArg(args, "projectId"))
+	if err != nil {
+		return nil, err
+	}
+
+	name := stringArg(args, "name")
+
+	for _, env := range p.environments {
+		if env.Name == name {
+			return nil, badInput("environment %s already exists", name)
+		}
+	}
+
+	env := b.createEnvironment(p, name)
+
+	return gql.Object{"id": env.Id, "name": env.Name}, nil
+}
+
+// createEphemeralEnvironment creates an environment with the variables of its
+// base environment
+func (b *Backend) createEphemeralEnvironment(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	projectID, baseID := stringArg(args, "projectId"), stringArg(args, "baseEnvironmentId")
+
+	p, err := b.authorizeEnvironment(callerFrom(ctx), projectID, baseID)
+	if err != nil {
+		return nil, err
+	}
+
+	env := b.createEnvironment(p, stringArg(args, "name"))
+
+	for ownerID, envs := range b.variables[baseID] {
+		b.setVariables(env.Id, ownerID, envs, true)
+	}
+
+	return gql.Object{"id": env.Id, "name": env.Name}, nil
+}
+
+func (b *Backend) deleteEnvironmentField(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	projectID, environmentID := stringArg(args, "projectId"), stringArg(args, "environmentId")
+
+	p, err := b.authorizeEnvironment(callerFrom(ctx), projectID, environmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	b.deleteEnvironment(p, environmentID)
+
+	return true, nil
+}
+
+func (b *Backend) createPluginField(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	p, err := b.authorizeProject(callerFrom(ctx), stringArg(args, "projectId"))
+	if err != nil {
+		return nil, err
+	}
+
+	name := stringArg(args, "name")
+
+	if _, ok := knownPlugins[name]; !ok {
+		return nil, badInput("plugin %s does not exist", name)
+	}
+
+	if b.plugin(p, name) != nil {
+		return nil, badInput("plugin %s already exists in the project", name)
+	}
+
+	plugin := b.createPlugin(p, name)
+
+	return gql.Object{"id": plugin.ID, "name": plugin.Name}, nil
+}
+
+// variablesOwner returns the service or plugin variables are set on
+func (b *Backend) variablesOwner(p *project, args map[string]interface{}) (string, error) {
+	if serviceID := stringArg(args, "serviceId"); serviceID != "" {
+		if b.service(p, serviceID) == nil {
+			return "", notFound("Service")
+		}
+
+		return serviceID, nil
+	}
+
+	pluginID := stringArg(args, "pluginId")
+	if pluginID == "" || b.plugin(p, pluginID) == nil {
+		return "", notFound("Plugin")
+	}
+
+	return pluginID, nil
+}
+
+func (b *Backend) updateVariables(replace bool) gql.Resolver {
+	return func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		environmentID := stringArg(args, "environmentId")
+
+		p, err := b.authorizeEnvironment(callerFrom(ctx), stringArg(args, "projectId"), environmentID)
+		if err != nil {
+			return nil, err
+		}
+
+		ownerID, err := b.variablesOwner(p, args)
+		if err != nil {
+			return nil, err
+		}
+
+		envs, err := envsArg(args, "variables")
+		if err != nil {
+			return nil, err
+		}
+
+		b.setVariables(environmentID, ownerID, envs, replace)
+
+		return true, nil
+	}
+}
+
+func (b *Backend) deleteVariable(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	environmentID := stringArg(args, "environmentId")
+
+	p, err := b.authorizeEnvironment(callerFrom(ctx), stringArg(args, "projectId"), environmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	ownerID, err := b.variablesOwner(p, args)
+	if err != nil {
+		return nil, err
+	}
+
+	b.variables[environmentID][ownerID].Delete(stringArg(args, "name"))
+
+	return true, nil
+}
+
+func (b *Backend) deployEnvironmentTriggers(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	environmentID := stringArg(args, "environmentId")
+
+	p, err := b.authorizeEnvironment(callerFrom(ctx), stringArg(args, "projectId"), environmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceID := stringArg(args, "serviceId")
+	if b.service(p, serviceID) == nil {
+		return nil, notFound("Service")
+	}
+
+	b.createDeployment(p, environmentID, serviceID)
+
+	return true, nil
+}
+
+func (b *Backend) removeDeployment(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	d, err := b.authorizeDeployment(callerFrom(ctx), stringArg(args, "projectId"), stringArg(args, "deploymentId"))
+	if err != nil {
+		return nil, err
+	}
+
+	d.status = entity.STATUS_REMOVED
+	d.script = nil
+
+	return true, nil
+}
+
+func (b *Backend) sendTelemetry(context.Context, map[string]interface{}) (interface{}, error) {
+	return true, nil
+}
@@ -0,0 +1,210 @@
+package fake
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/botwayorg/railway-api/entity"
+)
+
+// DisableIncrementalUploads makes the backend refuse the routes of
+// incremental uploads, so that clients fall back to whole archives
+func (b *Backend) DisableIncrementalUploads() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.incrementalDisabled = true
+}
+
+// Blob returns the content of a file sent to the project by an incremental
+// upload, by its SHA-256
+func (b *Backend) Blob(projectID, hash string) ([]byte, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	blob, ok := b.blobs[projectID][hash]
+
+	return blob, ok
+}
+
+// serveUpload serves, below /project/{projectId}/environment/{environmentId},
+// the archive route of the backboard and the routes incremental uploads are
+// proposed with, which no Railway server is known to serve yet:
+//
+//	POST up                archives to deploy
+//	POST up/blobs          which of {"hashes": [...]} the project is missing
+//	PUT  up/blobs/{sha256} the content of a file
+//	POST up/manifest       {"files": [...]} to deploy from blobs
+func (b *Backend) serveUpload(ctx context.Context, w http.ResponseWriter, req *http.Request) {
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if len(parts) < 5 || parts[2] != "environment" || parts[4] != "up" {
+		http.NotFound(w, req)
+		return
+	}
+
+	route := strings.Join(parts[4:], "/")
+	incremental := route != "up"
+
+	if incremental && b.incrementalDisabled {
+		http.NotFound(w, req)
+		return
+	}
+
+	projectID, environmentID := parts[1], parts[3]
+	serviceID := req.URL.Query().Get("serviceId")
+
+	p, err := b.authorizeEnvironment(callerFrom(ctx), projectID, environmentID)
+	if err != nil {
+		status := http.StatusNotFound
+		if err == errNotAuthorized {
+			status = http.StatusUnauthorized
+		}
+
+		writeJSON(w, status, &entity.UpErrorResponse{Message: err.Error()})
+
+		return
+	}
+
+	if serviceID != "" && b.service(p, serviceID) == nil {
+		writeJSON(w, http.StatusNotFound, &entity.UpErrorResponse{Message: "Service not found"})
+		return
+	}
+
+	switch {
+	case route == "up" && req.Method == http.MethodPost:
+		b.serveArchive(w, req, p, environmentID, serviceID)
+
+	case route == "up/blobs" && req.Method == http.MethodPost:
+		b.serveMissingBlobs(w, req, p)
+
+	case len(parts) == 7 && parts[5] == "blobs" && req.Method == http.MethodPut:
+		b.serveBlob(w, req, p, parts[6])
+
+	case route == "up/manifest" && req.Method == http.MethodPost:
+		b.serveManifest(w, req, p, environmentID, serviceID)
+
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func (b *Backend) serveArchive(w http.ResponseWriter, req *http.Request, p *project, environmentID, serviceID string) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, &entity.UpErrorResponse{Message: err.Error()})
+		return
+	}
+
+	b.deployUpload(w, p, &Upload{
+		ProjectID:     p.id,
+		EnvironmentID: environmentID,
+		ServiceID:     serviceID,
+		Body:          body,
+	})
+}
+
+func (b *Backend) serveMissingBlobs(w http.ResponseWriter, req *http.Request, p *project) {
+	var body struct {
+		Hashes []string `json:"hashes"`
+	}
+
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, &entity.UpErrorResponse{Message: fmt.Sprintf("invalid request body: %s", err)})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]string{"missing": b.missingBlobs(p, body.Hashes)})
+}
+
+func (b *Backend) serveBlob(w http.ResponseWriter, req *http.Request, p *project, hash string) {
+	blob, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, &entity.UpErrorResponse{Message: err.Error()})
+		return
+	}
+
+	sum := sha256.Sum256(blob)
+	if hex.EncodeToString(sum[:]) != hash {
+		writeJSON(w, http.StatusBadRequest, &entity.UpErrorResponse{Message: "Blob doesn't match its SHA-256"})
+		return
+	}
+
+	if b.blobs[p.id] == nil {
+		b.blobs[p.id] = make(map[string][]byte)
+	}
+
+	b.blobs[p.id][hash] = blob
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (b *Backend) serveManifest(w http.ResponseWriter, req *http.Request, p *project, environmentID, serviceID string) {
+	var body struct {
+		Files []entity.ManifestFile `json:"files"`
+	}
+
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, &entity.UpErrorResponse{Message: fmt.Sprintf("invalid request body: %s", err)})
+		return
+	}
+
+	hashes := []string{}
+
+	for _, file := range body.Files {
+		// Symlinks are uploaded as links, without content
+		if file.Symlink == "" {
+			hashes = append(hashes, file.SHA256)
+		}
+	}
+
+	if missing := b.missingBlobs(p, hashes); len(missing) > 0 {
+		writeJSON(w, http.StatusConflict, map[string]interface{}{
+			"message": fmt.Sprintf("%d blobs are missing", len(missing)),
+			"missing": missing,
+		})
+
+		return
+	}
+
+	b.deployUpload(w, p, &Upload{
+		ProjectID:     p.id,
+		EnvironmentID: environmentID,
+		ServiceID:     serviceID,
+		Manifest:      body.Files,
+	})
+}
+
+// missingBlobs returns the hashes the project has no blob for, once each
+func (b *Backend) missingBlobs(p *project, hashes []string) []string {
+	missing := []string{}
+	seen := make(map[string]bool)
+
+	for _, hash := range hashes {
+		if _, ok := b.blobs[p.id][hash]; !ok && !seen[hash] {
+			missing = append(missing, hash)
+		}
+
+		seen[hash] = true
+	}
+
+	return missing
+}
+
+// deployUpload starts a deployment of the upload
+func (b *Backend) deployUpload(w http.ResponseWriter, p *project, upload *Upload) {
+	deploy := b.createDeployment(p, upload.EnvironmentID, upload.ServiceID)
+
+	upload.DeploymentID = deploy.id
+	b.uploads = append(b.uploads, upload)
+
+	writeJSON(w, http.StatusOK, &entity.UpResponse{
+		URL:              fmt.Sprintf("https://railway.app/project/%s/deployments?id=%s", upload.ProjectID, deploy.id),
+		DeploymentDomain: deploy.staticURL,
+	})
+}
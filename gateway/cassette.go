@@ -0,0 +1,447 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// CassetteMode tells whether a cassette records the traffic of the gateway or
+// replays it
+type CassetteMode string
+
+const (
+	// CassetteRecord sends requests to the backboard and saves every exchange
+	CassetteRecord CassetteMode = "record"
+	// CassetteReplay answers requests from the saved exchanges, without
+	// touching the network
+	CassetteReplay CassetteMode = "replay"
+)
+
+// cassetteVersion is bumped when the file format changes incompatibly
+const cassetteVersion = 1
+
+// Response headers worth keeping, the rest is noise in a fixture
+var cassetteResponseHeaders = []string{"Content-Type", "Retry-After", "X-Request-Id", "X-Railway-Request-Id"}
+
+// Cassette records the GraphQL and upload exchanges of the gateway to a file,
+// or replays them from it. Secrets are redacted on the way in, like in HAR
+// files, so cassettes can be attached to bug reports. Upload bodies aren't
+// kept, only the fact that an upload was sent
+type Cassette struct {
+	mu   sync.Mutex
+	mode CassetteMode
+	path string
+	// interactions are those left to replay
+	interactions []*cassetteInteraction
+	// recorded counts the interactions written so far, and end is the offset
+	// of the end of the file they are followed by
+	recorded int
+	end      int64
+}
+
+type cassetteFile struct {
+	Version      int                    `json:"version"`
+	Interactions []*cassetteInteraction `json:"interactions"`
+}
+
+type cassetteInteraction struct {
+	Request  cassetteRequest   `json:"request"`
+	Response *cassetteResponse `json:"response,omitempty"`
+	// Error is set when the request failed without a response, and ErrorKind
+	// tells what failed so that retries happen the same way on replay
+	Error     string `json:"error,omitempty"`
+	ErrorKind string `json:"errorKind,omitempty"`
+
+	// used marks the interactions already replayed
+	used bool
+}
+
+type cassetteRequest struct {
+	Method string `json:"method"`
+	// URI is the path and query of the request, the host doesn't matter
+	URI string `json:"uri"`
+	// Operation names the GraphQL operation, for people reading the file
+	Operation string      `json:"operation,omitempty"`
+	Query     string      `json:"query,omitempty"`
+	Variables interface{} `json:"variables,omitempty"`
+}
+
+type cassetteResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body"`
+}
+
+// OpenCassette starts recording to path, replacing any previous recording, or
+// loads the exchanges saved in it to replay them
+func OpenCassette(path string, mode CassetteMode) (*Cassette, error) {
+	c := &Cassette{mode: mode, path: path}
+
+	switch mode {
+	case CassetteRecord:
+		// Fail now rather than after the first request if path isn't writable
+		if err := ioutil.WriteFile(path, []byte(cassetteHead+cassetteTail), 0600); err != nil {
+			return nil, err
+		}
+
+		c.end = int64(len(cassetteHead))
+
+	case CassetteReplay:
+		encoded, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var file cassetteFile
+
+		if err := json.Unmarshal(encoded, &file); err != nil {
+			return nil, fmt.Errorf("reading cassette %s: %w", path, err)
+		}
+
+		if file.Version != cassetteVersion {
+			return nil, fmt.Errorf("cassette %s has version %d, expected %d", path, file.Version, cassetteVersion)
+		}
+
+		c.interactions = file.Interactions
+
+	default:
+		return nil, fmt.Errorf("unknown cassette mode %q", mode)
+	}
+
+	return c, nil
+}
+
+// NewCassetteFromEnv returns the cassette configured with RAILWAY_RECORD or
+// RAILWAY_REPLAY, or nil if neither is set
+func NewCassetteFromEnv() (*Cassette, error) {
+	record, replay := os.Getenv("RAILWAY_RECORD"), os.Getenv("RAILWAY_REPLAY")
+
+	switch {
+	case record != "" && replay != "":
+		return nil, errors.New("RAILWAY_RECORD and RAILWAY_REPLAY can't be used together")
+	case record != "":
+		return OpenCassette(record, CassetteRecord)
+	case replay != "":
+		return OpenCassette(replay, CassetteReplay)
+	}
+
+	return nil, nil
+}
+
+// SetCassette records or replays the traffic of the gateway with the given
+// cassette, or goes back to plain requests when nil. Websocket upgrades fail
+// while a cassette is in use, as their frames can't be recorded
+func (g *Gateway) SetCassette(cassette *Cassette) {
+	g.transport.Cassette = cassette
+}
+
+// cassetteTransport sends requests through a cassette, which forwards them to
+// base when recording
+type cassetteTransport struct {
+	cassette *Cassette
+	base     http.RoundTripper
+}
+
+func (t cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.cassette.roundTrip(t.base, req)
+}
+
+// roundTrip records the exchange with base, or replays it
+func (c *Cassette) roundTrip(base http.RoundTripper, req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+
+	// Only buffer JSON bodies, uploads are streamed and can be huge
+	if req.Body != nil && isJSON(req.Header.Get("Content-Type")) {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		reqBody = body
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	key := newCassetteRequest(req, reqBody)
+
+	if c.mode == CassetteReplay {
+		return c.replay(req, key)
+	}
+
+	return c.record(base, req, key)
+}
+
+func newCassetteRequest(req *http.Request, body []byte) cassetteRequest {
+	key := cassetteRequest{
+		Method: req.Method,
+		URI:    req.URL.RequestURI(),
+	}
+
+	if body == nil {
+		return key
+	}
+
+	var gqlBody struct {
+		Query     string      `json:"query"`
+		Variables interface{} `json:"variables"`
+	}
+
+	if err := json.Unmarshal([]byte(redactRequestBody(body)), &gqlBody); err == nil {
+		key.Operation = operationName(gqlBody.Query)
+		key.Query = strings.Join(strings.Fields(gqlBody.Query), " ")
+		key.Variables = gqlBody.Variables
+	}
+
+	return key
+}
+
+func (c *Cassette) record(base http.RoundTripper, req *http.Request, key cassetteRequest) (*http.Response, error) {
+	interaction := &cassetteInteraction{Request: key}
+
+	res, err := base.RoundTrip(req)
+	if err != nil {
+		interaction.Error = err.Error()
+		interaction.ErrorKind = cassetteErrorKind(err)
+	} else {
+		body, readErr := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+
+		if readErr != nil {
+			return nil, readErr
+		}
+
+		res.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		interaction.Response = &cassetteResponse{
+			Status:  res.StatusCode,
+			Headers: make(map[string]string),
+			Body:    redactJSON(body),
+		}
+
+		for _, name := range cassetteResponseHeaders {
+			if value := res.Header.Get(name); value != "" {
+				interaction.Response.Headers[name] = value
+			}
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if writeErr := c.append(interaction); writeErr != nil {
+		return nil, writeErr
+	}
+
+	return res, err
+}
+
+// replay answers with the first exchange not replayed yet for the same
+// request. When no recorded request has the same variables, which happens
+// with generated names, the first one for the same operation is used
+func (c *Cassette) replay(req *http.Request, key cassetteRequest) (*http.Response, error) {
+	// The body is never sent anywhere, but streaming writers wait for it to
+	// be consumed
+	if req.Body != nil {
+		io.Copy(ioutil.Discard, req.Body)
+		req.Body.Close()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var match *cassetteInteraction
+
+	for _, interaction := range c.interactions {
+		recorded := interaction.Request
+
+		if interaction.used || recorded.Method != key.Method || recorded.URI != key.URI || recorded.Query != key.Query {
+			continue
+		}
+
+		if reflect.DeepEqual(recorded.Variables, key.Variables) {
+			match = interaction
+			break
+		}
+
+		if match == nil {
+			match = interaction
+		}
+	}
+
+	if match == nil {
+		name := key.Operation
+		if name == "" {
+			name = fmt.Sprintf("%s %s", key.Method, key.URI)
+		}
+
+		return nil, fmt.Errorf("cassette %s has no recorded response left for %s", c.path, name)
+	}
+
+	match.used = true
+
+	if match.Response == nil {
+		return nil, replayedError(match.ErrorKind, match.Error)
+	}
+
+	header := http.Header{}
+	for name, value := range match.Response.Headers {
+		header.Set(name, value)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", match.Response.Status, http.StatusText(match.Response.Status)),
+		StatusCode:    match.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(strings.NewReader(match.Response.Body)),
+		ContentLength: int64(len(match.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+// The cassette file is written as it is recorded, each interaction going
+// between cassetteHead and cassetteTail so that the file is complete even if
+// the process exits without warning
+var (
+	cassetteHead = fmt.Sprintf("{\n  \"version\": %d,\n  \"interactions\": [", cassetteVersion)
+	cassetteTail = "\n  ]\n}\n"
+)
+
+// append writes interaction over the tail of the file, followed by the tail
+func (c *Cassette) append(interaction *cassetteInteraction) error {
+	encoded, err := json.MarshalIndent(interaction, "    ", "  ")
+	if err != nil {
+		return err
+	}
+
+	separator := ",\n    "
+	if c.recorded == 0 {
+		separator = "\n    "
+	}
+
+	f, err := os.OpenFile(c.path, os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	chunk := separator + string(encoded)
+
+	if _, err := f.WriteAt([]byte(chunk+cassetteTail), c.end); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	c.recorded++
+	c.end += int64(len(chunk))
+
+	return nil
+}
+
+// cassetteErrorCauses are the errors round trips fail with that callers tell
+// apart, see isTransientNetError, by the kind recorded for them
+var cassetteErrorCauses = []struct {
+	kind  string
+	cause error
+}{
+	{"deadline-exceeded", context.DeadlineExceeded},
+	{"canceled", context.Canceled},
+	{"connection-refused", syscall.ECONNREFUSED},
+	{"connection-reset", syscall.ECONNRESET},
+	{"connection-aborted", syscall.ECONNABORTED},
+	{"broken-pipe", syscall.EPIPE},
+	{"unexpected-eof", io.ErrUnexpectedEOF},
+	{"eof", io.EOF},
+}
+
+// cassetteErrorKind returns the kind of a failed round trip, or "" when it
+// is none of the known ones
+func cassetteErrorKind(err error) string {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		if dnsErr.IsTemporary {
+			return "dns-temporary"
+		}
+
+		return "dns"
+	}
+
+	for _, known := range cassetteErrorCauses {
+		if errors.Is(err, known.cause) {
+			return known.kind
+		}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return "timeout"
+	}
+
+	return ""
+}
+
+// replayedError returns an error with the message of a recorded failure,
+// which is of the same kind
+func replayedError(kind, message string) error {
+	switch kind {
+	case "dns", "dns-temporary":
+		return &replayError{message: message, cause: &net.DNSError{Err: message, IsTemporary: kind == "dns-temporary"}}
+	case "timeout":
+		return &replayError{message: message, cause: timeoutError{}}
+	}
+
+	for _, known := range cassetteErrorCauses {
+		if known.kind == kind {
+			return &replayError{message: message, cause: known.cause}
+		}
+	}
+
+	return errors.New(message)
+}
+
+// replayError is a recorded failure, which wraps an error of its kind
+type replayError struct {
+	message string
+	cause   error
+}
+
+func (e *replayError) Error() string {
+	return e.message
+}
+
+func (e *replayError) Unwrap() error {
+	return e.cause
+}
+
+// Timeout tells *url.Error about timeouts, as it doesn't unwrap the error
+func (e *replayError) Timeout() bool {
+	var netErr net.Error
+
+	return errors.As(e.cause, &netErr) && netErr.Timeout()
+}
+
+// timeoutError stands for the timeouts of the network, which have no error
+// value of their own
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
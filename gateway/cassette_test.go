@@ -0,0 +1,156 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// cassetteFailures are the errors TestCassetteErrors records, by path
+var cassetteFailures = map[string]error{
+	"/timeout": &net.OpError{Op: "read", Net: "tcp", Err: timeoutError{}},
+	"/reset":   &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET},
+	"/dns":     &net.DNSError{Err: "server misbehaving", Name: "backboard.railway.app", IsTemporary: true},
+	"/other":   errors.New("something else"),
+}
+
+// cassetteServer answers /ok/{n} with n and fails the other paths with
+// cassetteFailures
+func cassetteServer(req *http.Request) (*http.Response, error) {
+	if err, ok := cassetteFailures[req.URL.Path]; ok {
+		return nil, err
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"text/plain"}},
+		Body:       ioutil.NopCloser(strings.NewReader(strings.TrimPrefix(req.URL.Path, "/ok/"))),
+		Request:    req,
+	}, nil
+}
+
+func cassetteGateway(t *testing.T, path string, mode CassetteMode, transport roundTripFunc) *Gateway {
+	cassette, err := OpenCassette(path, mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewClient(WithHost("http://backboard.test"), WithHTTPClient(&http.Client{Transport: transport}))
+	g.SetCassette(cassette)
+
+	return g
+}
+
+func TestCassetteRecordReplay(t *testing.T) {
+	path := t.TempDir() + "/cassette.json"
+	g := cassetteGateway(t, path, CassetteRecord, cassetteServer)
+
+	for i := 0; i < 3; i++ {
+		res, err := g.httpClient.Get(fmt.Sprintf("http://backboard.test/ok/%d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+
+		// The file is complete after every request
+		encoded, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var file cassetteFile
+		if err := json.Unmarshal(encoded, &file); err != nil {
+			t.Fatalf("after %d requests: %s\n%s", i+1, err, encoded)
+		}
+
+		if file.Version != cassetteVersion || len(file.Interactions) != i+1 {
+			t.Fatalf("after %d requests, the cassette has version %d and %d interactions", i+1, file.Version, len(file.Interactions))
+		}
+	}
+
+	g = cassetteGateway(t, path, CassetteReplay, func(req *http.Request) (*http.Response, error) {
+		t.Fatalf("replaying sent %s", req.URL)
+		return nil, nil
+	})
+
+	for i := 0; i < 3; i++ {
+		res, err := g.httpClient.Get(fmt.Sprintf("http://backboard.test/ok/%d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+
+		if string(body) != fmt.Sprint(i) {
+			t.Errorf("body = %q, want %q", body, fmt.Sprint(i))
+		}
+	}
+
+	if _, err := g.httpClient.Get("http://backboard.test/ok/0"); err == nil {
+		t.Error("an interaction was replayed twice")
+	}
+}
+
+func TestCassetteErrors(t *testing.T) {
+	path := t.TempDir() + "/cassette.json"
+	recording := cassetteGateway(t, path, CassetteRecord, cassetteServer)
+
+	recorded := make(map[string]error)
+
+	for failure := range cassetteFailures {
+		_, err := recording.httpClient.Get("http://backboard.test" + failure)
+		if err == nil {
+			t.Fatalf("%s didn't fail", failure)
+		}
+
+		recorded[failure] = err
+	}
+
+	replaying := cassetteGateway(t, path, CassetteReplay, func(req *http.Request) (*http.Response, error) {
+		t.Fatalf("replaying sent %s", req.URL)
+		return nil, nil
+	})
+
+	for failure, want := range recorded {
+		_, err := replaying.httpClient.Get("http://backboard.test" + failure)
+		if err == nil {
+			t.Fatalf("%s didn't fail on replay", failure)
+		}
+
+		if !errors.As(err, new(*url.Error)) {
+			t.Errorf("%s: %T isn't a *url.Error", failure, err)
+		}
+
+		if err.Error() != want.Error() {
+			t.Errorf("%s: replayed %q, want %q", failure, err, want)
+		}
+
+		if got, want := isTransientNetError(err), isTransientNetError(want); got != want {
+			t.Errorf("%s: transient = %t on replay, %t when recorded", failure, got, want)
+		}
+	}
+
+	_, err := replaying.httpClient.Get("http://backboard.test/reset")
+	if err == nil {
+		t.Fatal("an interaction was replayed twice")
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) {
+		t.Error("a missing interaction replayed as a reset connection")
+	}
+}
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,94 @@
+package gateway
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Option configures a gateway built with NewClient
+type Option func(*clientOptions)
+
+type clientOptions struct {
+	host         string
+	token        string
+	projectToken string
+	userAgent    string
+	httpClient   *http.Client
+}
+
+// WithHost sends the requests to another backboard than DefaultHost
+func WithHost(host string) Option {
+	return func(o *clientOptions) {
+		o.host = strings.TrimSuffix(host, "/")
+	}
+}
+
+// WithToken authenticates as the user the API token belongs to
+func WithToken(token string) Option {
+	return func(o *clientOptions) {
+		o.token = token
+	}
+}
+
+// WithProjectToken authenticates with a project token, which takes precedence
+// over WithToken. Only the project and environment of the token are reachable
+func WithProjectToken(token string) Option {
+	return func(o *clientOptions) {
+		o.projectToken = token
+	}
+}
+
+// WithHTTPClient sends the requests through the given client. Its timeout,
+// cookie jar and redirect policy are kept, and its transport is wrapped to
+// add the headers the backboard expects
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *clientOptions) {
+		o.httpClient = client
+	}
+}
+
+// WithUserAgent identifies the program making the requests
+func WithUserAgent(userAgent string) Option {
+	return func(o *clientOptions) {
+		o.userAgent = userAgent
+	}
+}
+
+// NewClient returns a gateway for programs embedding this module. Unlike New
+// it neither reads the environment nor touches the filesystem: everything it
+// needs comes from the options
+//
+//	client := gateway.NewClient(gateway.WithToken(token))
+//	user, err := client.GetUser(ctx)
+func NewClient(opts ...Option) *Gateway {
+	options := &clientOptions{host: DefaultHost}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	httpClient := &http.Client{}
+	if options.httpClient != nil {
+		copied := *options.httpClient
+		httpClient = &copied
+	}
+
+	transport := &AttachCommonHeadersTransport{
+		Base:      httpClient.Transport,
+		UserAgent: options.userAgent,
+	}
+
+	httpClient.Transport = transport
+
+	return &Gateway{
+		httpClient:     httpClient,
+		transport:      transport,
+		retryPolicy:    DefaultRetryPolicy,
+		requestTimeout: DefaultRequestTimeout,
+		host:           options.host,
+		userToken: func() (string, error) {
+			return options.token, nil
+		},
+		projectToken: options.projectToken,
+	}
+}
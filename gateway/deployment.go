@@ -2,93 +2,124 @@ package gateway
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/botwayorg/railway-api/entity"
 	"github.com/botwayorg/railway-api/errors"
 	gqlgen "github.com/botwayorg/railway-api/lib/gql"
 )
 
-func (g *Gateway) GetDeploymentsForEnvironment(ctx context.Context, projectId, environmentId string) ([]*entity.Deployment, error) {
-	gqlReq, err := g.NewRequestWithAuth(`
-		query ($projectId: ID!, $environmentId: ID!) {
-			allDeploymentsForEnvironment(projectId: $projectId, environmentId: $environmentId) {
-				id
-				status
-				projectId
-				meta
-				staticUrl
-			}
-		}
-	`)
-
-	if err != nil {
-		return nil, err
-	}
+type deploymentsForEnvironmentVars struct {
+	ProjectID     string `json:"projectId"`
+	EnvironmentID string `json:"environmentId"`
+}
 
-	gqlReq.Var("projectId", projectId)
-	gqlReq.Var("environmentId", environmentId)
+type deploymentsForEnvironmentResult struct {
+	Deployments []*entity.Deployment `json:"allDeploymentsForEnvironment"`
+}
 
-	var resp struct {
-		Deployments []*entity.Deployment `json:"allDeploymentsForEnvironment"`
+var deploymentsForEnvironmentQuery = operation[deploymentsForEnvironmentVars, deploymentsForEnvironmentResult]("allDeploymentsForEnvironment", `
+	query ($projectId: ID!, $environmentId: ID!) {
+		allDeploymentsForEnvironment(projectId: $projectId, environmentId: $environmentId) {
+			id
+			status
+			projectId
+			meta
+			staticUrl
+		}
 	}
+`)
 
-	if err := gqlReq.Run(ctx, &resp); err != nil {
-		return nil, errors.DeploymentFetchingFailed
-	}
+type deploymentByIDVars struct {
+	ProjectID    string `json:"projectId" gql:"ID!"`
+	DeploymentID string `json:"deploymentId" gql:"ID!"`
+	BuildLogs    bool   `json:"buildLogs"`
+	DeployLogs   bool   `json:"deployLogs"`
+}
 
-	return resp.Deployments, nil
+type deploymentByIDResult struct {
+	Deployment *struct {
+		ID         string `json:"id"`
+		ProjectID  string `json:"projectId"`
+		Status     string `json:"status"`
+		BuildLogs  string `json:"buildLogs" gql:"buildLogs @include(if: $buildLogs)"`
+		DeployLogs string `json:"deployLogs" gql:"deployLogs @include(if: $deployLogs)"`
+	} `json:"deploymentById" gql:"deploymentById(projectId: $projectId, deploymentId: $deploymentId)"`
 }
 
-func (g *Gateway) GetLatestDeploymentForEnvironment(ctx context.Context, projectID, environmentID string) (*entity.Deployment, error) {
-	deployments, err := g.GetDeploymentsForEnvironment(ctx, projectID, environmentID)
+var deploymentByIDQuery = operation[deploymentByIDVars, deploymentByIDResult]("deploymentById",
+	gqlgen.MustBuild[deploymentByIDVars, deploymentByIDResult]("query", "deploymentById"),
+)
 
-	if err != nil {
-		return nil, err
-	}
+// IterateDeploymentsForEnvironment walks the deployments of an environment,
+// newest first. The backend returns them all at once
+func (g *Gateway) IterateDeploymentsForEnvironment(projectId, environmentId string) *Iterator[*entity.Deployment] {
+	return newIterator(func(ctx context.Context) ([]*entity.Deployment, error) {
+		gqlReq, err := g.NewRequestWithAuth(deploymentsForEnvironmentQuery.Document)
 
-	if len(deployments) == 0 {
-		return nil, errors.NoDeploymentsFound
-	}
+		if err != nil {
+			return nil, err
+		}
 
-	for _, deploy := range deployments {
-		if deploy.Status != entity.STATUS_REMOVED {
+		resp, err := runOperation(ctx, gqlReq, deploymentsForEnvironmentQuery, deploymentsForEnvironmentVars{
+			ProjectID:     projectId,
+			EnvironmentID: environmentId,
+		})
+
+		if err != nil {
+			return nil, withFallback(err, errors.DeploymentFetchingFailed)
+		}
+
+		return resp.Deployments, nil
+	})
+}
+
+func (g *Gateway) GetDeploymentsForEnvironment(ctx context.Context, projectId, environmentId string) ([]*entity.Deployment, error) {
+	return g.IterateDeploymentsForEnvironment(projectId, environmentId).All(ctx)
+}
+
+func (g *Gateway) GetLatestDeploymentForEnvironment(ctx context.Context, projectID, environmentID string) (*entity.Deployment, error) {
+	deployments := g.IterateDeploymentsForEnvironment(projectID, environmentID)
+
+	for deployments.Next(ctx) {
+		if deploy := deployments.Value(); deploy.Status != entity.STATUS_REMOVED {
 			return deploy, nil
 		}
 	}
 
+	if err := deployments.Err(); err != nil {
+		return nil, err
+	}
+
 	return nil, errors.NoDeploymentsFound
 }
 
 func (g *Gateway) GetDeploymentByID(ctx context.Context, req *entity.DeploymentByIDRequest) (*entity.Deployment, error) {
-	gen, err := gqlgen.AsGQL(ctx, req.GQL)
+	gqlReq, err := g.NewRequestWithAuth(deploymentByIDQuery.Document)
 
 	if err != nil {
 		return nil, err
 	}
 
-	gqlReq, err := g.NewRequestWithAuth(fmt.Sprintf(`
-		query ($projectId: ID!, $deploymentId: ID!) {
-			deploymentById(projectId: $projectId, deploymentId: $deploymentId) {
-				%s
-			}
-		}
-	`, *gen))
+	resp, err := runOperation(ctx, gqlReq, deploymentByIDQuery, deploymentByIDVars{
+		ProjectID:    req.ProjectID,
+		DeploymentID: req.DeploymentID,
+		BuildLogs:    req.BuildLogs,
+		DeployLogs:   req.DeployLogs,
+	})
 
 	if err != nil {
-		return nil, err
-	}
-
-	gqlReq.Var("projectId", req.ProjectID)
-	gqlReq.Var("deploymentId", req.DeploymentID)
-
-	var resp struct {
-		Deployment *entity.Deployment `json:"deploymentById"`
+		return nil, withFallback(err, errors.DeploymentFetchingFailed)
 	}
 
-	if err := gqlReq.Run(ctx, &resp); err != nil {
-		return nil, errors.DeploymentFetchingFailed
+	if resp.Deployment == nil {
+		return nil, nil
 	}
 
-	return resp.Deployment, nil
+	return &entity.Deployment{
+		ID:         resp.Deployment.ID,
+		ProjectID:  resp.Deployment.ProjectID,
+		Status:     resp.Deployment.Status,
+		BuildLogs:  resp.Deployment.BuildLogs,
+		DeployLogs: resp.Deployment.DeployLogs,
+	}, nil
 }
@@ -6,28 +6,30 @@ import (
 	"github.com/botwayorg/railway-api/entity"
 )
 
-func (g *Gateway) DeployEnvironmentTriggers(ctx context.Context, req *entity.DeployEnvironmentTriggersRequest) error {
-	gqlReq, err := g.NewRequestWithAuth(`
-	  	mutation($projectId: ID!, $environmentId: ID!, $serviceId: ID!) {
-			deployEnvironmentTriggers(projectId: $projectId, environmentId: $environmentId, serviceId: $serviceId)
-	  	}
-	`)
+type deployEnvironmentTriggersVars struct {
+	ProjectID     string `json:"projectId"`
+	EnvironmentID string `json:"environmentId"`
+	ServiceID     string `json:"serviceId"`
+}
 
-	if err != nil {
-		return err
+var deployEnvironmentTriggersMutation = operation[deployEnvironmentTriggersVars, struct{}]("deployEnvironmentTriggers", `
+	mutation($projectId: ID!, $environmentId: ID!, $serviceId: ID!) {
+		deployEnvironmentTriggers(projectId: $projectId, environmentId: $environmentId, serviceId: $serviceId)
 	}
+`)
 
-	gqlReq.Var("projectId", req.ProjectID)
-	gqlReq.Var("environmentId", req.EnvironmentID)
-	gqlReq.Var("serviceId", req.ServiceID)
-
-	var resp struct {
-		// Nothing useful here
-	}
+func (g *Gateway) DeployEnvironmentTriggers(ctx context.Context, req *entity.DeployEnvironmentTriggersRequest) error {
+	gqlReq, err := g.NewRequestWithAuth(deployEnvironmentTriggersMutation.Document)
 
-	if err := gqlReq.Run(ctx, &resp); err != nil {
+	if err != nil {
 		return err
 	}
 
-	return nil
+	_, err = runOperation(ctx, gqlReq, deployEnvironmentTriggersMutation, deployEnvironmentTriggersVars{
+		ProjectID:     req.ProjectID,
+		EnvironmentID: req.EnvironmentID,
+		ServiceID:     req.ServiceID,
+	})
+
+	return err
 }
@@ -6,6 +6,17 @@ import (
 	"github.com/botwayorg/railway-api/entity"
 )
 
+type removeDeploymentVars struct {
+	ProjectID    string `json:"projectId"`
+	DeploymentID string `json:"deploymentId"`
+}
+
+var removeDeploymentMutation = operation[removeDeploymentVars, struct{}]("removeDeployment", `
+	mutation removeDeployment($projectId: ID!, $deploymentId: ID!) {
+		removeDeployment(projectId: $projectId, deploymentId: $deploymentId)
+	}
+`)
+
 func (g *Gateway) Down(ctx context.Context, req *entity.DownRequest) error {
 	deployment, err := g.GetLatestDeploymentForEnvironment(ctx, req.ProjectID, req.EnvironmentID)
 
@@ -13,22 +24,16 @@ func (g *Gateway) Down(ctx context.Context, req *entity.DownRequest) error {
 		return err
 	}
 
-	gqlReq, err := g.NewRequestWithAuth(`
-		mutation removeDeployment($projectId: ID!, $deploymentId: ID!) {
-			removeDeployment(projectId: $projectId, deploymentId: $deploymentId)
-		}
-	`)
+	gqlReq, err := g.NewRequestWithAuth(removeDeploymentMutation.Document)
 
 	if err != nil {
 		return err
 	}
 
-	gqlReq.Var("projectId", req.ProjectID)
-	gqlReq.Var("deploymentId", deployment.ID)
-
-	if err = gqlReq.Run(ctx, nil); err != nil {
-		return err
-	}
+	_, err = runOperation(ctx, gqlReq, removeDeploymentMutation, removeDeploymentVars{
+		ProjectID:    req.ProjectID,
+		DeploymentID: deployment.ID,
+	})
 
-	return nil
+	return err
 }
@@ -7,83 +7,83 @@ import (
 	"github.com/botwayorg/railway-api/errors"
 )
 
-func (g *Gateway) CreateEnvironment(ctx context.Context, req *entity.CreateEnvironmentRequest) (*entity.Environment, error) {
-	gqlReq, err := g.NewRequestWithAuth(`
-		mutation($name: String!, $projectId: String!) {
-			createEnvironment(name: $name, projectId: $projectId) {
-				id
-				name
-			}
+type createEnvironmentResult struct {
+	Environment *entity.Environment `json:"createEnvironment,omitempty"`
+}
+
+var createEnvironmentMutation = operation[entity.CreateEnvironmentRequest, createEnvironmentResult]("createEnvironment", `
+	mutation($name: String!, $projectId: String!) {
+		createEnvironment(name: $name, projectId: $projectId) {
+			id
+			name
 		}
-	`)
+	}
+`)
 
-	if err != nil {
-		return nil, err
+type createEphemeralEnvironmentResult struct {
+	Environment *entity.Environment `json:"createEphemeralEnvironment,omitempty"`
+}
+
+var createEphemeralEnvironmentMutation = operation[entity.CreateEphemeralEnvironmentRequest, createEphemeralEnvironmentResult]("createEphemeralEnvironment", `
+	mutation($name: String!, $projectId: String!, $baseEnvironmentId: String!) {
+		createEphemeralEnvironment(name: $name, projectId: $projectId, baseEnvironmentId: $baseEnvironmentId) {
+			id
+			name
+		}
+	}
+`)
+
+type deleteEnvironmentResult struct {
+	Deleted bool `json:"deleteEnvironment"`
+}
+
+var deleteEnvironmentMutation = operation[entity.DeleteEnvironmentRequest, deleteEnvironmentResult]("deleteEnvironment", `
+	mutation($environmentId: String!, $projectId: String!) {
+		deleteEnvironment(environmentId: $environmentId, projectId: $projectId)
 	}
+`)
 
-	gqlReq.Var("projectId", req.ProjectID)
-	gqlReq.Var("name", req.Name)
+func (g *Gateway) CreateEnvironment(ctx context.Context, req *entity.CreateEnvironmentRequest) (*entity.Environment, error) {
+	gqlReq, err := g.NewRequestWithAuth(createEnvironmentMutation.Document)
 
-	var resp struct {
-		Environment *entity.Environment `json:"createEnvironment,omitempty"`
+	if err != nil {
+		return nil, err
 	}
 
-	if err := gqlReq.Run(ctx, &resp); err != nil {
-		return nil, errors.CreateEnvironmentFailed
+	resp, err := runOperation(ctx, gqlReq, createEnvironmentMutation, *req)
+
+	if err != nil {
+		return nil, withFallback(err, errors.CreateEnvironmentFailed)
 	}
 
 	return resp.Environment, nil
 }
 
 func (g *Gateway) CreateEphemeralEnvironment(ctx context.Context, req *entity.CreateEphemeralEnvironmentRequest) (*entity.Environment, error) {
-	gqlReq, err := g.NewRequestWithAuth(`
-		mutation($name: String!, $projectId: String!, $baseEnvironmentId: String!) {
-			createEphemeralEnvironment(name: $name, projectId: $projectId, baseEnvironmentId: $baseEnvironmentId) {
-				id
-				name
-			}
-		}
-	`)
+	gqlReq, err := g.NewRequestWithAuth(createEphemeralEnvironmentMutation.Document)
 
 	if err != nil {
 		return nil, err
 	}
 
-	gqlReq.Var("projectId", req.ProjectID)
-	gqlReq.Var("name", req.Name)
-	gqlReq.Var("baseEnvironmentId", req.BaseEnvironmentID)
+	resp, err := runOperation(ctx, gqlReq, createEphemeralEnvironmentMutation, *req)
 
-	var resp struct {
-		Environment *entity.Environment `json:"createEphemeralEnvironment,omitempty"`
-	}
-
-	if err := gqlReq.Run(ctx, &resp); err != nil {
-		return nil, errors.CreateEnvironmentFailed
+	if err != nil {
+		return nil, withFallback(err, errors.CreateEnvironmentFailed)
 	}
 
 	return resp.Environment, nil
 }
 
 func (g *Gateway) DeleteEnvironment(ctx context.Context, req *entity.DeleteEnvironmentRequest) error {
-	gqlReq, err := g.NewRequestWithAuth(`
-		mutation($environmentId: String!, $projectId: String!) {
-			deleteEnvironment(environmentId: $environmentId, projectId: $projectId)
-		}
-	`)
+	gqlReq, err := g.NewRequestWithAuth(deleteEnvironmentMutation.Document)
 
 	if err != nil {
 		return err
 	}
 
-	gqlReq.Var("environmentId", req.EnvironmentId)
-	gqlReq.Var("projectId", req.ProjectID)
-
-	var resp struct {
-		Created bool `json:"createEnvironment,omitempty"`
-	}
-
-	if err := gqlReq.Run(ctx, &resp); err != nil {
-		return errors.CreateEnvironmentFailed
+	if _, err := runOperation(ctx, gqlReq, deleteEnvironmentMutation, *req); err != nil {
+		return withFallback(err, errors.CreateEnvironmentFailed)
 	}
 
 	return nil
@@ -2,34 +2,76 @@ package gateway
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/botwayorg/railway-api/entity"
 )
 
-func (g *Gateway) GetEnvs(ctx context.Context, req *entity.GetEnvsRequest) (*entity.Envs, error) {
-	gqlReq, err := g.NewRequestWithAuth(`
-		query ($projectId: String!, $environmentId: String!, $serviceId: String!) {
-			decryptedVariablesForService(projectId: $projectId, environmentId: $environmentId, serviceId: $serviceId)
-		}
-	`)
+type getEnvsVars struct {
+	ProjectID     string `json:"projectId"`
+	EnvironmentID string `json:"environmentId"`
+	ServiceID     string `json:"serviceId,omitempty"`
+}
 
-	if err != nil {
-		return nil, err
+type getEnvsResult struct {
+	Envs *entity.Envs `json:"decryptedVariablesForService"`
+}
+
+var getEnvsQuery = operation[getEnvsVars, getEnvsResult]("decryptedVariablesForService", `
+	query ($projectId: String!, $environmentId: String!, $serviceId: String!) {
+		decryptedVariablesForService(projectId: $projectId, environmentId: $environmentId, serviceId: $serviceId)
+	}
+`)
+
+type updateEnvsVars struct {
+	ProjectID     string       `json:"projectId"`
+	EnvironmentID string       `json:"environmentId"`
+	PluginID      string       `json:"pluginId,omitempty"`
+	ServiceID     string       `json:"serviceId,omitempty"`
+	Variables     *entity.Envs `json:"variables"`
+}
+
+var upsertVariablesMutation = operation[updateEnvsVars, struct{}]("upsertVariablesFromObject", `
+	mutation($projectId: String!, $environmentId: String!, $pluginId: String, $serviceId: String, $variables: Json!) {
+		upsertVariablesFromObject(projectId: $projectId, environmentId: $environmentId, pluginId: $pluginId, serviceId: $serviceId, variables: $variables)
 	}
+`)
 
-	gqlReq.Var("projectId", req.ProjectID)
-	gqlReq.Var("environmentId", req.EnvironmentID)
+// When replacing, use the set mutation which will blow away all old variables
+// and only set the ones in this request
+var setVariablesMutation = operation[updateEnvsVars, struct{}]("variablesSetFromObject", `
+	mutation($projectId: String!, $environmentId: String!, $pluginId: String, $serviceId: String, $variables: Json!) {
+		variablesSetFromObject(projectId: $projectId, environmentId: $environmentId, pluginId: $pluginId, serviceId: $serviceId, variables: $variables)
+	}
+`)
+
+type deleteVariableVars struct {
+	ProjectID     string `json:"projectId"`
+	EnvironmentID string `json:"environmentId"`
+	PluginID      string `json:"pluginId,omitempty"`
+	ServiceID     string `json:"serviceId,omitempty"`
+	Name          string `json:"name"`
+}
 
-	if req.ServiceID != "" {
-		gqlReq.Var("serviceId", req.ServiceID)
+var deleteVariableMutation = operation[deleteVariableVars, struct{}]("deleteVariable", `
+	mutation($projectId: String!, $environmentId: String!, $pluginId: String, $serviceId: String, $name: String!) {
+		deleteVariable(projectId: $projectId, environmentId: $environmentId, pluginId: $pluginId, serviceId: $serviceId, name: $name)
 	}
+`)
+
+func (g *Gateway) GetEnvs(ctx context.Context, req *entity.GetEnvsRequest) (*entity.Envs, error) {
+	gqlReq, err := g.NewRequestWithAuth(getEnvsQuery.Document)
 
-	var resp struct {
-		Envs *entity.Envs `json:"decryptedVariablesForService"`
+	if err != nil {
+		return nil, err
 	}
 
-	if err := gqlReq.Run(ctx, &resp); err != nil {
+	resp, err := runOperation(ctx, gqlReq, getEnvsQuery, getEnvsVars{
+		ProjectID:     req.ProjectID,
+		EnvironmentID: req.EnvironmentID,
+		ServiceID:     req.ServiceID,
+	})
+
+	if err != nil {
 		return nil, err
 	}
 
@@ -37,69 +79,43 @@ func (g *Gateway) GetEnvs(ctx context.Context, req *entity.GetEnvsRequest) (*ent
 }
 
 func (g *Gateway) UpdateVariablesFromObject(ctx context.Context, req *entity.UpdateEnvsRequest) error {
-	queryName := "upsertVariablesFromObject"
+	op := upsertVariablesMutation
 
 	if req.Replace {
-		// When replacing, use the set query which will blow away all old variables and only set the ones in this query
-		queryName = "variablesSetFromObject"
+		op = setVariablesMutation
 	}
 
-	gqlReq, err := g.NewRequestWithAuth(fmt.Sprintf(`
-	  	mutation($projectId: String!, $environmentId: String!, $pluginId: String, $serviceId: String, $variables: Json!) {
-				%s(projectId: $projectId, environmentId: $environmentId, pluginId: $pluginId, serviceId: $serviceId, variables: $variables)
-	  	}
-	`, queryName))
+	gqlReq, err := g.NewRequestWithAuth(op.Document)
 
 	if err != nil {
 		return err
 	}
 
-	gqlReq.Var("projectId", req.ProjectID)
-	gqlReq.Var("environmentId", req.EnvironmentID)
-
-	if req.PluginID != "" {
-		gqlReq.Var("pluginId", req.PluginID)
-	}
-
-	if req.ServiceID != "" {
-		gqlReq.Var("serviceId", req.ServiceID)
-	}
-
-	gqlReq.Var("variables", req.Envs)
-
-	if err := gqlReq.Run(ctx, nil); err != nil {
-		return err
-	}
+	_, err = runOperation(ctx, gqlReq, op, updateEnvsVars{
+		ProjectID:     req.ProjectID,
+		EnvironmentID: req.EnvironmentID,
+		PluginID:      req.PluginID,
+		ServiceID:     req.ServiceID,
+		Variables:     req.Envs,
+	})
 
-	return nil
+	return err
 }
 
 func (g *Gateway) DeleteVariable(ctx context.Context, req *entity.DeleteVariableRequest) error {
-	gqlReq, err := g.NewRequestWithAuth(`
-	  	mutation($projectId: String!, $environmentId: String!, $pluginId: String, $serviceId: String, $name: String!) {
-				deleteVariable(projectId: $projectId, environmentId: $environmentId, pluginId: $pluginId, serviceId: $serviceId, name: $name)
-	  	}
-	`)
+	gqlReq, err := g.NewRequestWithAuth(deleteVariableMutation.Document)
 
 	if err != nil {
 		return err
 	}
 
-	gqlReq.Var("projectId", req.ProjectID)
-	gqlReq.Var("environmentId", req.EnvironmentID)
-	gqlReq.Var("name", req.Name)
-
-	if req.PluginID != "" {
-		gqlReq.Var("pluginId", req.PluginID)
-	}
-
-	if req.ServiceID != "" {
-		gqlReq.Var("serviceId", req.ServiceID)
-	}
-
-	if err := gqlReq.Run(ctx, nil); err != nil {
-		return err
-	}
+	_, err = runOperation(ctx, gqlReq, deleteVariableMutation, deleteVariableVars{
+		ProjectID:     req.ProjectID,
+		EnvironmentID: req.EnvironmentID,
+		PluginID:      req.PluginID,
+		ServiceID:     req.ServiceID,
+		Name:          req.Name,
+	})
 
-	return nil
+	return err
 }
@@ -0,0 +1,259 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GQLErrorLocation points at the part of the query an error refers to
+type GQLErrorLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// GQLError is a single entry of the `errors` list in a GraphQL response
+type GQLError struct {
+	Message    string                 `json:"message"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Locations  []GQLErrorLocation     `json:"locations,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+func (e GQLError) Error() string {
+	return e.Message
+}
+
+// Code returns the `extensions.code` of the error, if the server sent one
+func (e GQLError) Code() string {
+	code, _ := e.Extensions["code"].(string)
+
+	return code
+}
+
+// RequestError describes a failed request to the backboard. The more specific
+// error types below embed it, so callers can branch on the kind of failure
+// with errors.As and still get to the status and GraphQL errors
+type RequestError struct {
+	// StatusCode is the HTTP status of the response
+	StatusCode int
+	// RequestID is the ID the server assigned to the request, if any
+	RequestID string
+	// Errors are the errors from the GraphQL response, if any
+	Errors []GQLError
+	// Message is used when the server did not send any GraphQL errors
+	Message string
+	// RetryAfter is how long the server asked us to wait before retrying, zero
+	// if it did not say
+	RetryAfter time.Duration
+}
+
+func (e *RequestError) Error() string {
+	if len(e.Errors) == 0 {
+		if e.Message != "" {
+			return e.Message
+		}
+
+		return fmt.Sprintf("response not successful status=%d", e.StatusCode)
+	}
+
+	if len(e.Errors) == 1 {
+		return e.Errors[0].Message
+	}
+
+	messages := make([]string, len(e.Errors))
+
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+
+	return fmt.Sprintf("%d Errors: %s", len(e.Errors), strings.Join(messages, ", "))
+}
+
+// Codes returns the `extensions.code` of every GraphQL error that has one
+func (e *RequestError) Codes() []string {
+	codes := []string{}
+
+	for _, err := range e.Errors {
+		if code := err.Code(); code != "" {
+			codes = append(codes, code)
+		}
+	}
+
+	return codes
+}
+
+func (e *RequestError) hasCode(codes ...string) bool {
+	for _, code := range e.Codes() {
+		for _, c := range codes {
+			if code == c {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (e *RequestError) hasMessage(message string) bool {
+	for _, err := range e.Errors {
+		if strings.Contains(err.Message, message) {
+			return true
+		}
+	}
+
+	return strings.Contains(e.Message, message)
+}
+
+// AuthError is returned when the request is not authenticated, or the
+// credentials used are not allowed to perform it
+type AuthError struct{ RequestError }
+
+// NotFoundError is returned when the requested resource does not exist
+type NotFoundError struct{ RequestError }
+
+// ValidationError is returned when the server rejected the query or its
+// variables
+type ValidationError struct{ RequestError }
+
+// RateLimitError is returned when too many requests were sent
+type RateLimitError struct{ RequestError }
+
+// ServerError is returned when the server failed to handle the request
+type ServerError struct{ RequestError }
+
+// AsRequestError returns the RequestError embedded in any of the error types
+// above, if err is or wraps one of them
+func AsRequestError(err error) (*RequestError, bool) {
+	var (
+		authErr       *AuthError
+		notFoundErr   *NotFoundError
+		validationErr *ValidationError
+		rateLimitErr  *RateLimitError
+		serverErr     *ServerError
+		reqErr        *RequestError
+	)
+
+	switch {
+	case errors.As(err, &authErr):
+		return &authErr.RequestError, true
+	case errors.As(err, &notFoundErr):
+		return &notFoundErr.RequestError, true
+	case errors.As(err, &validationErr):
+		return &validationErr.RequestError, true
+	case errors.As(err, &rateLimitErr):
+		return &rateLimitErr.RequestError, true
+	case errors.As(err, &serverErr):
+		return &serverErr.RequestError, true
+	case errors.As(err, &reqErr):
+		return reqErr, true
+	}
+
+	return nil, false
+}
+
+// newRequestError classifies a failed response into one of the typed errors
+// above, falling back to a plain *RequestError. The HTTP status and the
+// `extensions.code` of the GraphQL errors are looked at first, as messages are
+// meant for people and change without notice. Only errors without either are
+// recognized by their message, as the backboard doesn't always send a code
+// when the user isn't logged in
+func newRequestError(res *http.Response, gqlErrors []GQLError, message string) error {
+	return classifyRequestError(RequestError{
+		StatusCode: res.StatusCode,
+		RequestID:  requestID(res.Header),
+		Errors:     gqlErrors,
+		Message:    message,
+		RetryAfter: retryAfter(res.Header),
+	})
+}
+
+func classifyRequestError(reqErr RequestError) error {
+	status := reqErr.StatusCode
+
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden ||
+		reqErr.hasCode("UNAUTHENTICATED", "FORBIDDEN", "UNAUTHORIZED"):
+		return &AuthError{reqErr}
+
+	case status == http.StatusTooManyRequests || reqErr.hasCode("RATE_LIMITED", "TOO_MANY_REQUESTS"):
+		return &RateLimitError{reqErr}
+
+	case status == http.StatusNotFound || reqErr.hasCode("NOT_FOUND"):
+		return &NotFoundError{reqErr}
+
+	case status == http.StatusBadRequest || status == http.StatusUnprocessableEntity ||
+		reqErr.hasCode("GRAPHQL_VALIDATION_FAILED", "GRAPHQL_PARSE_FAILED", "BAD_USER_INPUT", "VALIDATION_ERROR"):
+		return &ValidationError{reqErr}
+
+	case status >= 500 || reqErr.hasCode("INTERNAL_SERVER_ERROR"):
+		return &ServerError{reqErr}
+
+	case reqErr.hasMessage("Not Authorized"):
+		return &AuthError{reqErr}
+	}
+
+	return &reqErr
+}
+
+func requestID(header http.Header) string {
+	for _, key := range []string{"X-Request-Id", "X-Railway-Request-Id"} {
+		if id := header.Get(key); id != "" {
+			return id
+		}
+	}
+
+	return ""
+}
+
+// retryAfter parses the Retry-After header, which is either a number of
+// seconds or an HTTP date
+func retryAfter(header http.Header) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+
+	return 0
+}
+
+// fallbackError shows a friendlier message for a failed request while keeping
+// the original error reachable through errors.As, and the message through
+// errors.Is
+type fallbackError struct {
+	message error
+	cause   error
+}
+
+func (e *fallbackError) Error() string {
+	return e.message.Error()
+}
+
+func (e *fallbackError) Unwrap() error {
+	return e.cause
+}
+
+func (e *fallbackError) Is(target error) bool {
+	return target == e.message
+}
+
+// withFallback replaces the message of err with the one of fallback
+func withFallback(err error, fallback error) error {
+	return &fallbackError{
+		message: fallback,
+		cause:   err,
+	}
+}
@@ -0,0 +1,88 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestClassifyRequestError(t *testing.T) {
+	withCode := func(code string) []GQLError {
+		return []GQLError{{Message: "something failed", Extensions: map[string]interface{}{"code": code}}}
+	}
+
+	tests := []struct {
+		name   string
+		status int
+		errors []GQLError
+		msg    string
+		want   string
+	}{
+		{name: "401", status: http.StatusUnauthorized, want: "*gateway.AuthError"},
+		{name: "403", status: http.StatusForbidden, want: "*gateway.AuthError"},
+		{name: "UNAUTHENTICATED", status: http.StatusOK, errors: withCode("UNAUTHENTICATED"), want: "*gateway.AuthError"},
+		{name: "FORBIDDEN", status: http.StatusOK, errors: withCode("FORBIDDEN"), want: "*gateway.AuthError"},
+		{name: "UNAUTHORIZED", status: http.StatusOK, errors: withCode("UNAUTHORIZED"), want: "*gateway.AuthError"},
+		{name: "Not Authorized without code", status: http.StatusOK, errors: []GQLError{{Message: "Not Authorized"}}, want: "*gateway.AuthError"},
+		{name: "Not Authorized as message", status: http.StatusOK, msg: "Not Authorized", want: "*gateway.AuthError"},
+		{name: "code wins over message", status: http.StatusOK, errors: []GQLError{{Message: "Not Authorized", Extensions: map[string]interface{}{"code": "NOT_FOUND"}}}, want: "*gateway.NotFoundError"},
+		{name: "429", status: http.StatusTooManyRequests, want: "*gateway.RateLimitError"},
+		{name: "RATE_LIMITED", status: http.StatusOK, errors: withCode("RATE_LIMITED"), want: "*gateway.RateLimitError"},
+		{name: "TOO_MANY_REQUESTS", status: http.StatusOK, errors: withCode("TOO_MANY_REQUESTS"), want: "*gateway.RateLimitError"},
+		{name: "404", status: http.StatusNotFound, want: "*gateway.NotFoundError"},
+		{name: "NOT_FOUND", status: http.StatusOK, errors: withCode("NOT_FOUND"), want: "*gateway.NotFoundError"},
+		{name: "400", status: http.StatusBadRequest, want: "*gateway.ValidationError"},
+		{name: "422", status: http.StatusUnprocessableEntity, want: "*gateway.ValidationError"},
+		{name: "GRAPHQL_VALIDATION_FAILED", status: http.StatusOK, errors: withCode("GRAPHQL_VALIDATION_FAILED"), want: "*gateway.ValidationError"},
+		{name: "GRAPHQL_PARSE_FAILED", status: http.StatusOK, errors: withCode("GRAPHQL_PARSE_FAILED"), want: "*gateway.ValidationError"},
+		{name: "BAD_USER_INPUT", status: http.StatusOK, errors: withCode("BAD_USER_INPUT"), want: "*gateway.ValidationError"},
+		{name: "VALIDATION_ERROR", status: http.StatusOK, errors: withCode("VALIDATION_ERROR"), want: "*gateway.ValidationError"},
+		{name: "500", status: http.StatusInternalServerError, want: "*gateway.ServerError"},
+		{name: "503", status: http.StatusServiceUnavailable, want: "*gateway.ServerError"},
+		{name: "INTERNAL_SERVER_ERROR", status: http.StatusOK, errors: withCode("INTERNAL_SERVER_ERROR"), want: "*gateway.ServerError"},
+		{name: "unknown code", status: http.StatusOK, errors: withCode("SOMETHING_ELSE"), want: "*gateway.RequestError"},
+		{name: "plain message", status: http.StatusOK, errors: []GQLError{{Message: "Project is archived"}}, want: "*gateway.RequestError"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := classifyRequestError(RequestError{StatusCode: test.status, Errors: test.errors, Message: test.msg})
+
+			if got := fmt.Sprintf("%T", err); got != test.want {
+				t.Fatalf("got %s, want %s", got, test.want)
+			}
+
+			reqErr, ok := AsRequestError(err)
+			if !ok {
+				t.Fatal("AsRequestError didn't find the request error")
+			}
+
+			if reqErr.StatusCode != test.status {
+				t.Errorf("status = %d, want %d", reqErr.StatusCode, test.status)
+			}
+		})
+	}
+}
+
+func TestNewRequestError(t *testing.T) {
+	res := &http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"X-Request-Id": {"req-1"}, "Retry-After": {"3"}},
+	}
+
+	err := newRequestError(res, nil, "")
+
+	var rateLimitErr *RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("got %T, want *RateLimitError", err)
+	}
+
+	if rateLimitErr.RequestID != "req-1" || rateLimitErr.RetryAfter.Seconds() != 3 {
+		t.Errorf("request id = %q, retry after = %s", rateLimitErr.RequestID, rateLimitErr.RetryAfter)
+	}
+
+	if got, want := err.Error(), "response not successful status=429"; got != want {
+		t.Errorf("message = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,85 @@
+package gateway
+
+import "context"
+
+// Iterator walks a list fetched from the backend:
+//
+//	it := gtwy.IterateProjects()
+//	for it.Next(ctx) {
+//		project := it.Value()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+//
+// The backboard has no paginated connections, so the whole list is fetched in
+// a single request on the first call to Next. Limit bounds what is walked,
+// not what is fetched
+type Iterator[T any] struct {
+	fetch func(ctx context.Context) ([]T, error)
+	limit int
+
+	items   []T
+	fetched bool
+	count   int
+	current T
+	err     error
+}
+
+func newIterator[T any](fetch func(ctx context.Context) ([]T, error)) *Iterator[T] {
+	return &Iterator[T]{fetch: fetch}
+}
+
+// Limit stops the iteration after n items, zero meaning no limit
+func (it *Iterator[T]) Limit(n int) *Iterator[T] {
+	it.limit = n
+
+	return it
+}
+
+// Next advances to the next item, fetching the list on the first call. It
+// returns false when there are no more items or the request failed
+func (it *Iterator[T]) Next(ctx context.Context) bool {
+	if it.err != nil || (it.limit > 0 && it.count >= it.limit) {
+		return false
+	}
+
+	if !it.fetched {
+		it.fetched = true
+
+		if it.items, it.err = it.fetch(ctx); it.err != nil {
+			return false
+		}
+	}
+
+	if len(it.items) == 0 {
+		return false
+	}
+
+	it.current = it.items[0]
+	it.items = it.items[1:]
+	it.count++
+
+	return true
+}
+
+// Value returns the current item
+func (it *Iterator[T]) Value() T {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// All consumes the rest of the iterator into a slice
+func (it *Iterator[T]) All(ctx context.Context) ([]T, error) {
+	items := []T{}
+
+	for it.Next(ctx) {
+		items = append(items, it.Value())
+	}
+
+	return items, it.Err()
+}
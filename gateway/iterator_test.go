@@ -0,0 +1,74 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// countingFetch returns items, counting how many times it was called
+func countingFetch(items []int, err error, calls *int) func(ctx context.Context) ([]int, error) {
+	return func(ctx context.Context) ([]int, error) {
+		*calls++
+		return items, err
+	}
+}
+
+func TestIterator(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []int
+		limit int
+		want  []int
+	}{
+		{name: "all", items: []int{1, 2, 3}, want: []int{1, 2, 3}},
+		{name: "limit", items: []int{1, 2, 3}, limit: 2, want: []int{1, 2}},
+		{name: "limit above length", items: []int{1, 2}, limit: 5, want: []int{1, 2}},
+		{name: "empty", items: nil, want: []int{}},
+		{name: "empty with limit", items: []int{}, limit: 1, want: []int{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			calls := 0
+			it := newIterator(countingFetch(test.items, nil, &calls)).Limit(test.limit)
+
+			got, err := it.All(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+
+			if it.Next(context.Background()) {
+				t.Error("Next returned true after the end")
+			}
+
+			if calls != 1 {
+				t.Errorf("fetched %d times, want 1", calls)
+			}
+		})
+	}
+}
+
+func TestIteratorFetchError(t *testing.T) {
+	failed := errors.New("no projects today")
+
+	calls := 0
+	it := newIterator(countingFetch([]int{1}, failed, &calls))
+
+	if it.Next(context.Background()) {
+		t.Fatal("Next returned true when the fetch failed")
+	}
+
+	if !errors.Is(it.Err(), failed) {
+		t.Errorf("err = %v, want %v", it.Err(), failed)
+	}
+
+	if it.Next(context.Background()) || calls != 1 {
+		t.Errorf("the iterator went on after the error, fetching %d times", calls)
+	}
+}
@@ -7,29 +7,45 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
+	"net/url"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 
-    errors2 "github.com/botwayorg/railway-api/errors"
 	configs "github.com/botwayorg/railway-api/configs"
 	"github.com/botwayorg/railway-api/constants"
-    "github.com/botwayorg/railway-api/ui"
 )
 
 const (
 	CLI_SOURCE_HEADER = "cli"
 )
 
+// DefaultRequestTimeout bounds a single GraphQL request. Uploads aren't bound
+// by it
+const DefaultRequestTimeout = 30 * time.Second
+
+// DefaultHost is the production backboard
+const DefaultHost = "https://backboard.railway.app"
+
 type Gateway struct {
-	cfg        *configs.Configs
-	httpClient *http.Client
+	httpClient     *http.Client
+	transport      *AttachCommonHeadersTransport
+	retryPolicy    RetryPolicy
+	requestTimeout time.Duration
+
+	// host is resolved once, when the gateway is built. hostErr is returned
+	// in its place when that failed
+	host    string
+	hostErr error
+	// userToken is resolved on every request, as logging in changes it in
+	// the middle of a command
+	userToken    func() (string, error)
+	projectToken string
 }
 
 func GetHost() string {
-	baseURL := "https://backboard.railway.app"
+	baseURL := DefaultHost
 
 	if configs.IsDevMode() {
 		baseURL = "https://backboard.railway-develop.app"
@@ -42,11 +58,29 @@ func GetHost() string {
 	return baseURL
 }
 
-type AttachCommonHeadersTransport struct{}
+// Host returns the backboard URL requests are sent to
+func (g *Gateway) Host() (string, error) {
+	return g.host, g.hostErr
+}
+
+type AttachCommonHeadersTransport struct {
+	// Tracer records every request when set
+	Tracer *Tracer
+	// Base sends the requests, http.DefaultTransport when nil
+	Base http.RoundTripper
+	// UserAgent replaces the User-Agent of Go when set
+	UserAgent string
+	// Cassette records or replays every request when set
+	Cassette *Cassette
+}
 
 func (t *AttachCommonHeadersTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	req.Header.Add("x-source", CLI_SOURCE_HEADER)
 
+	if t.UserAgent != "" {
+		req.Header.Set("User-Agent", t.UserAgent)
+	}
+
 	version := constants.Version
 
 	if constants.IsDevVersion() {
@@ -55,34 +89,95 @@ func (t *AttachCommonHeadersTransport) RoundTrip(req *http.Request) (*http.Respo
 
 	req.Header.Set("X-Railway-Version", version)
 
-	return http.DefaultTransport.RoundTrip(req)
+	base := t.baseTransport()
+
+	if t.Cassette != nil {
+		base = cassetteTransport{cassette: t.Cassette, base: base}
+	}
+
+	if t.Tracer != nil {
+		return t.Tracer.roundTrip(base, req)
+	}
+
+	return base.RoundTrip(req)
 }
 
+// New returns the gateway of the CLI. It authenticates with RAILWAY_TOKEN or
+// the token of the logged in user, and sends requests to RAILWAY_API_URL, the
+// active endpoint profile or the RAILWAY_ENV based default, in that order
 func New() *Gateway {
-	httpClient := &http.Client{
-		Timeout:   time.Second * 30,
-		Transport: &AttachCommonHeadersTransport{},
+	cfg := configs.New()
+
+	g := NewClient(WithProjectToken(cfg.RailwayProductionToken))
+
+	g.host, g.hostErr = resolveHost(cfg)
+
+	g.userToken = func() (string, error) {
+		user, err := cfg.GetUserConfigs()
+		if err != nil {
+			return "", err
+		}
+
+		return user.Token, nil
 	}
 
-	return &Gateway{
-		cfg:        configs.New(),
-		httpClient: httpClient,
+	g.SetTracer(NewTracerFromEnv())
+
+	return g
+}
+
+// resolveHost returns the backboard URL of New, from RAILWAY_API_URL, the
+// active endpoint profile or RAILWAY_ENV
+func resolveHost(cfg *configs.Configs) (string, error) {
+	if apiURL, ok := configs.GetRailwayAPIURL(); ok {
+		return checkHost(apiURL, "RAILWAY_API_URL")
+	}
+
+	profile, err := cfg.GetEndpointProfile()
+	if err != nil {
+		return "", err
+	}
+
+	if profile != nil && profile.APIURL != "" {
+		return checkHost(profile.APIURL, "the endpoint profile")
 	}
+
+	return GetHost(), nil
 }
 
-type GQLRequest struct {
-	q          string
-	vars       map[string]interface{}
-	header     http.Header
-	httpClient *http.Client
+// checkHost returns a configured backboard URL without its trailing slash,
+// or an error naming where it came from if requests can't be sent to it
+func checkHost(host, source string) (string, error) {
+	u, err := url.Parse(host)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid API URL %q in %s, it should look like %s", host, source, DefaultHost)
+	}
+
+	return strings.TrimSuffix(host, "/"), nil
+}
+
+// SetTracer starts recording requests with the given tracer, or stops when nil
+func (g *Gateway) SetTracer(tracer *Tracer) {
+	g.transport.Tracer = tracer
 }
 
-type GQLError struct {
-	Message string `json:"message"`
+// SetRequestTimeout changes how long a single GraphQL request may take, zero
+// meaning no limit besides the context of the request
+func (g *Gateway) SetRequestTimeout(timeout time.Duration) {
+	g.requestTimeout = timeout
 }
 
-func (e GQLError) Error() string {
-	return e.Message
+// SetRetryPolicy replaces the policy used to retry failed queries
+func (g *Gateway) SetRetryPolicy(policy RetryPolicy) {
+	g.retryPolicy = policy
+}
+
+type GQLRequest struct {
+	q          string
+	vars       map[string]interface{}
+	header     http.Header
+	gtwy       *Gateway
+	idempotent bool
 }
 
 type GQLResponse struct {
@@ -91,16 +186,18 @@ type GQLResponse struct {
 }
 
 func (g *Gateway) authorize(header http.Header) error {
-	if g.cfg.RailwayProductionToken != "" {
-		header.Add("project-access-token", g.cfg.RailwayProductionToken)
+	if g.projectToken != "" {
+		header.Add("project-access-token", g.projectToken)
 	} else {
-		user, err := g.cfg.GetUserConfigs()
+		token, err := g.userToken()
 
 		if err != nil {
 			return err
 		}
 
-		header.Add("authorization", fmt.Sprintf("Bearer %s", user.Token))
+		if token != "" {
+			header.Add("authorization", fmt.Sprintf("Bearer %s", token))
+		}
 	}
 
 	return nil
@@ -110,8 +207,9 @@ func (g *Gateway) NewRequestWithoutAuth(query string) *GQLRequest {
 	gqlReq := &GQLRequest{
 		q:          query,
 		header:     http.Header{},
-		httpClient: g.httpClient,
+		gtwy:       g,
 		vars:       make(map[string]interface{}),
+		idempotent: isQuery(query),
 	}
 
 	return gqlReq
@@ -129,7 +227,46 @@ func (g *Gateway) NewRequestWithAuth(query string) (*GQLRequest, error) {
 	return gqlReq, nil
 }
 
+// MarkIdempotent allows a mutation to be retried like a query. Only use it for
+// mutations that are safe to apply more than once
+func (r *GQLRequest) MarkIdempotent() {
+	r.idempotent = true
+}
+
+// Run sends the request and decodes the response data into resp. Queries are
+// retried according to the gateway's retry policy
 func (r *GQLRequest) Run(ctx context.Context, resp interface{}) error {
+	policy := r.gtwy.retryPolicy
+
+	if !r.idempotent {
+		policy.MaxAttempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := r.run(ctx, resp)
+		if err == nil {
+			return nil
+		}
+
+		delay, retry := policy.wait(ctx, attempt, err)
+		if !retry {
+			return err
+		}
+
+		if err := Sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *GQLRequest) run(ctx context.Context, resp interface{}) error {
+	if timeout := r.gtwy.requestTimeout; timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
 	var requestBody bytes.Buffer
 
 	requestBodyObj := struct {
@@ -144,16 +281,21 @@ func (r *GQLRequest) Run(ctx context.Context, resp interface{}) error {
 		return errors.Wrap(err, "encode body")
 	}
 
-	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/graphql", GetHost()), &requestBody)
+	host, err := r.gtwy.Host()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/graphql", host), &requestBody)
 	if err != nil {
 		return err
 	}
 
 	req = req.WithContext(ctx)
-	req.Header = r.header
+	req.Header = r.header.Clone()
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json; charset=utf-8")
-	res, err := r.httpClient.Do(req)
+	res, err := r.gtwy.httpClient.Do(req)
 
 	if err != nil {
 		return err
@@ -166,39 +308,24 @@ func (r *GQLRequest) Run(ctx context.Context, resp interface{}) error {
 		return err
 	}
 
-	// TODO: Handle auth errors and other things in a special way
-	if res.StatusCode < 200 || res.StatusCode >= 300 {
-		return fmt.Errorf("response not successful status=%d", res.StatusCode)
-	}
-
 	gr := &GQLResponse{
 		Data: resp,
 	}
 
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		// Error responses may still carry GraphQL errors, but don't have to
+		var errRes GQLResponse
+		_ = json.Unmarshal(buf.Bytes(), &errRes)
+
+		return newRequestError(res, errRes.Errors, "")
+	}
+
 	if err := json.NewDecoder(&buf).Decode(&gr); err != nil {
 		return errors.Wrap(err, "decoding response")
 	}
 
 	if len(gr.Errors) > 0 {
-		messages := make([]string, len(gr.Errors))
-
-		for i, err := range gr.Errors {
-			messages[i] = err.Error()
-		}
-
-		errText := gr.Errors[0].Message
-		if len(gr.Errors) > 1 {
-			errText = fmt.Sprintf("%d Errors: %s", len(gr.Errors), strings.Join(messages, ", "))
-		}
-
-		// If any GQL responses return fail because unauthenticated, print an error telling the
-		// user to log in and exit immediately
-		if strings.Contains(errText, "Not Authorized") {
-			println(ui.AlertDanger(errors2.UserNotAuthorized.Error()))
-			os.Exit(1)
-		}
-
-		return errors.New(errText)
+		return newRequestError(res, gr.Errors, "")
 	}
 
 	return nil
@@ -0,0 +1,91 @@
+package gateway
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	configs "github.com/botwayorg/railway-api/configs"
+	"github.com/botwayorg/railway-api/entity"
+)
+
+func TestResolveHost(t *testing.T) {
+	tests := []struct {
+		name    string
+		apiURL  string
+		profile string
+		active  string
+		env     string
+		want    string
+		wantErr string
+	}{
+		{name: "default", want: DefaultHost},
+		{name: "RAILWAY_ENV", env: "staging", want: "https://backboard.railway-staging.app"},
+		{name: "active profile", active: "local", want: "http://localhost:8910"},
+		{name: "RAILWAY_PROFILE", profile: "self-hosted", active: "local", want: "https://backboard.example.com"},
+		{name: "RAILWAY_API_URL", apiURL: "http://127.0.0.1:4000/", profile: "self-hosted", want: "http://127.0.0.1:4000"},
+		{name: "profile over RAILWAY_ENV", active: "local", env: "develop", want: "http://localhost:8910"},
+		{name: "unknown profile", profile: "missing", wantErr: `endpoint profile "missing" not found`},
+		{name: "invalid RAILWAY_API_URL", apiURL: "backboard.example.com", wantErr: `invalid API URL "backboard.example.com" in RAILWAY_API_URL`},
+		{name: "invalid profile URL", profile: "broken", wantErr: `invalid API URL "ftp://backboard.example.com" in the endpoint profile`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv("RAILWAY_API_URL", test.apiURL)
+			t.Setenv("RAILWAY_PROFILE", test.profile)
+			t.Setenv("RAILWAY_ENV", test.env)
+
+			cfg := configs.New()
+
+			for name, apiURL := range map[string]string{
+				"local":       "http://localhost:8910/",
+				"self-hosted": "https://backboard.example.com",
+				"broken":      "ftp://backboard.example.com",
+			} {
+				if err := cfg.SetEndpointProfile(name, &entity.EndpointProfile{APIURL: apiURL}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if test.active != "" {
+				if err := cfg.UseEndpointProfile(test.active); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := resolveHost(cfg)
+
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("err = %v, want %q", err, test.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+// TestNewInvalidHost makes sure requests of a gateway whose host can't be
+// resolved fail with the reason, before anything is sent
+func TestNewInvalidHost(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("RAILWAY_API_URL", "backboard.example.com")
+
+	g := New()
+
+	err := g.NewRequestWithoutAuth("query { me { id } }").Run(context.Background(), &struct{}{})
+	if err == nil || !strings.Contains(err.Error(), "invalid API URL") {
+		t.Errorf("err = %v, want the invalid API URL", err)
+	}
+}
@@ -0,0 +1,137 @@
+package gateway
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/botwayorg/railway-api/lib/gql"
+)
+
+//go:generate go run ../tools/gqlschema -o schema.graphql
+
+//go:embed schema.graphql
+var schemaSource string
+
+type registeredOperation struct {
+	document string
+	check    func(schema *gql.Schema) error
+}
+
+// operations holds every operation declared with operation, by name
+var operations = make(map[string]registeredOperation)
+
+// operation declares a typed operation and registers it so that
+// ValidateOperations checks it against schema.graphql
+func operation[V, R any](name, document string) *gql.Operation[V, R] {
+	if _, exists := operations[name]; exists {
+		panic(fmt.Sprintf("operation %q is declared more than once", name))
+	}
+
+	op := gql.NewOperation[V, R](document)
+	operations[name] = registeredOperation{document: document, check: op.Check}
+
+	return op
+}
+
+// Schema returns the checked-in subset of the backboard schema the operations
+// of the gateway are written against. `go generate ./gateway` regenerates it
+// from the introspection of the backboard
+func Schema() (*gql.Schema, error) {
+	schema, err := gql.ParseSchema(schemaSource)
+	if err != nil {
+		return nil, fmt.Errorf("schema.graphql: %w", err)
+	}
+
+	return schema, nil
+}
+
+// schemaHeader starts schema.graphql
+const schemaHeader = `# The part of the backboard schema the operations of the gateway use. Don't
+# edit it by hand: ` + "`go generate ./gateway`" + ` introspects the backboard and
+# rewrites it from the operations.
+
+`
+
+// GenerateSchema returns the content of schema.graphql for the given schema
+// of the backboard: the part of it the operations of the gateway use
+func GenerateSchema(backboard *gql.Schema) (string, error) {
+	var docs []*gql.Document
+
+	for _, name := range operationNames() {
+		doc, err := gql.ParseDocument(operations[name].document)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", name, err)
+		}
+
+		docs = append(docs, doc)
+	}
+
+	schema, err := backboard.Subset(docs...)
+	if err != nil {
+		return "", err
+	}
+
+	return schemaHeader + gql.PrintSchema(schema), nil
+}
+
+// ValidateOperations checks every operation the gateway sends against the
+// checked-in schema, including the Go types their variables and responses are
+// encoded from and decoded into
+func ValidateOperations() error {
+	schema, err := Schema()
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+
+	for _, name := range operationNames() {
+		if err := operations[name].check(schema); err != nil {
+			for _, line := range strings.Split(err.Error(), "\n") {
+				problems = append(problems, fmt.Sprintf("%s: %s", name, line))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid operations:\n%s", strings.Join(problems, "\n"))
+	}
+
+	return nil
+}
+
+func operationNames() []string {
+	names := make([]string, 0, len(operations))
+
+	for name := range operations {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// runOperation sends a typed operation with the given variables and returns
+// the decoded response data
+func runOperation[V, R any](ctx context.Context, req *GQLRequest, op *gql.Operation[V, R], vars V) (*R, error) {
+	variables, err := op.Variables(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, value := range variables {
+		req.Var(name, value)
+	}
+
+	var resp R
+
+	if err := req.Run(ctx, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
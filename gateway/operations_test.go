@@ -0,0 +1,27 @@
+package gateway
+
+import "testing"
+
+func TestOperationsMatchSchema(t *testing.T) {
+	if err := ValidateOperations(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestSchemaIsGenerated makes sure schema.graphql is laid out like
+// `go generate ./gateway` writes it, and holds nothing the operations don't use
+func TestSchemaIsGenerated(t *testing.T) {
+	schema, err := Schema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	generated, err := GenerateSchema(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if generated != schemaSource {
+		t.Errorf("schema.graphql is out of date, run `go generate ./gateway`. It should be:\n%s", generated)
+	}
+}
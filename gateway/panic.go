@@ -7,29 +7,41 @@ import (
 	"github.com/botwayorg/railway-api/errors"
 )
 
+type sendTelemetryVars struct {
+	Command       string `json:"command"`
+	Error         string `json:"error"`
+	Stacktrace    string `json:"stacktrace"`
+	ProjectID     string `json:"projectId"`
+	EnvironmentID string `json:"environmentId"`
+}
+
+type sendTelemetryResult struct {
+	Status bool `json:"sendTelemetry"`
+}
+
+var sendTelemetryMutation = operation[sendTelemetryVars, sendTelemetryResult]("sendTelemetry", `
+	mutation($command: String!, $error: String!, $stacktrace: String!, $projectId: String, $environmentId: String) {
+		sendTelemetry(command: $command, error: $error, stacktrace: $stacktrace, projectId: $projectId, environmentId: $environmentId)
+	}
+`)
+
 func (g *Gateway) SendPanic(ctx context.Context, req *entity.PanicRequest) (bool, error) {
-	gqlReq, err := g.NewRequestWithAuth(`
-		mutation($command: String!, $error: String!, $stacktrace: String!, $projectId: String, $environmentId: String) {
-			sendTelemetry(command: $command, error: $error, stacktrace: $stacktrace, projectId: $projectId, environmentId: $environmentId)
-		}
-	`)
+	gqlReq, err := g.NewRequestWithAuth(sendTelemetryMutation.Document)
 
 	if err != nil {
 		return false, err
 	}
 
-	gqlReq.Var("command", req.Command)
-	gqlReq.Var("error", req.PanicError)
-	gqlReq.Var("stacktrace", req.Stacktrace)
-	gqlReq.Var("projectId", req.ProjectID)
-	gqlReq.Var("environmentId", req.EnvironmentID)
-
-	var resp struct {
-		Status bool `json:"sendTelemetry"`
-	}
+	resp, err := runOperation(ctx, gqlReq, sendTelemetryMutation, sendTelemetryVars{
+		Command:       req.Command,
+		Error:         req.PanicError,
+		Stacktrace:    req.Stacktrace,
+		ProjectID:     req.ProjectID,
+		EnvironmentID: req.EnvironmentID,
+	})
 
-	if err := gqlReq.Run(ctx, &resp); err != nil {
-		return false, errors.TelemetryFailed
+	if err != nil {
+		return false, withFallback(err, errors.TelemetryFailed)
 	}
 
 	return resp.Status, nil
@@ -7,53 +7,68 @@ import (
 	"github.com/botwayorg/railway-api/errors"
 )
 
-func (g *Gateway) GetAvailablePlugins(ctx context.Context, projectId string) ([]string, error) {
-	gqlReq, err := g.NewRequestWithAuth(`
-		query ($projectId: ID!) {
-			availablePluginsForProject(projectId: $projectId)
+type projectVars struct {
+	ProjectID string `json:"projectId"`
+}
+
+type availablePluginsResult struct {
+	Plugins []string `json:"availablePluginsForProject"`
+}
+
+var availablePluginsQuery = operation[projectVars, availablePluginsResult]("availablePluginsForProject", `
+	query ($projectId: ID!) {
+		availablePluginsForProject(projectId: $projectId)
+	}
+`)
+
+type createPluginVars struct {
+	ProjectID string `json:"projectId"`
+	Name      string `json:"name"`
+}
+
+type createPluginResult struct {
+	Plugin *entity.Plugin `json:"createPlugin"`
+}
+
+var createPluginMutation = operation[createPluginVars, createPluginResult]("createPlugin", `
+	mutation($projectId: String!, $name: String!) {
+		createPlugin(projectId: $projectId, name: $name) {
+			id,
+			name
 		}
-	`)
+	}
+`)
+
+func (g *Gateway) GetAvailablePlugins(ctx context.Context, projectId string) ([]string, error) {
+	gqlReq, err := g.NewRequestWithAuth(availablePluginsQuery.Document)
 
 	if err != nil {
 		return nil, err
 	}
 
-	gqlReq.Var("projectId", projectId)
-
-	var resp struct {
-		Plugins []string `json:"availablePluginsForProject"`
-	}
+	resp, err := runOperation(ctx, gqlReq, availablePluginsQuery, projectVars{ProjectID: projectId})
 
-	if err := gqlReq.Run(ctx, &resp); err != nil {
-		return nil, errors.PluginGetFailed
+	if err != nil {
+		return nil, withFallback(err, errors.PluginGetFailed)
 	}
 
 	return resp.Plugins, nil
 }
 
 func (g *Gateway) CreatePlugin(ctx context.Context, req *entity.CreatePluginRequest) (*entity.Plugin, error) {
-	gqlReq, err := g.NewRequestWithAuth(`
-		mutation($projectId: String!, $name: String!) {
-			createPlugin(projectId: $projectId, name: $name) {
-				id,
-				name
-			}
-		}
-	`)
+	gqlReq, err := g.NewRequestWithAuth(createPluginMutation.Document)
 
 	if err != nil {
 		return nil, err
 	}
 
-	gqlReq.Var("projectId", req.ProjectID)
-	gqlReq.Var("name", req.Plugin)
+	resp, err := runOperation(ctx, gqlReq, createPluginMutation, createPluginVars{
+		ProjectID: req.ProjectID,
+		Name:      req.Plugin,
+	})
 
-	var resp struct {
-		Plugin *entity.Plugin `json:"createPlugin"`
-	}
-
-	if err := gqlReq.Run(ctx, &resp); err != nil {
-		return nil, errors.PluginCreateFailed
+	if err != nil {
+		return nil, withFallback(err, errors.PluginCreateFailed)
 	}
 
 	return resp.Plugin, nil
@@ -10,42 +10,58 @@ import (
 	"github.com/pkg/browser"
 )
 
-// GetProjectToken looks up a project and environment by the RAILWAY_TOKEN
-func (g *Gateway) GetProjectToken(ctx context.Context) (*entity.ProjectToken, error) {
-	if g.cfg.RailwayProductionToken == "" {
-		return nil, errors.ProjectTokenNotFound
-	}
+type projectTokenResult struct {
+	ProjectToken *entity.ProjectToken `json:"projectToken"`
+}
 
-	gqlReq, err := g.NewRequestWithAuth(`
-		query {
-			projectToken {
-				projectId
-				environmentId
-			}
+var projectTokenQuery = operation[struct{}, projectTokenResult]("projectToken", `
+	query {
+		projectToken {
+			projectId
+			environmentId
 		}
-	`)
-
-	if err != nil {
-		return nil, err
 	}
+`)
 
-	var resp struct {
-		ProjectToken *entity.ProjectToken `json:"projectToken"`
-	}
+type projectByIDResult struct {
+	Project *entity.Project `json:"projectById"`
+}
 
-	if err := gqlReq.Run(ctx, &resp); err != nil {
-		return nil, errors.ProjectTokenNotFound
+var projectByIDQuery = operation[projectVars, projectByIDResult]("projectById", `
+	query ($projectId: ID!) {
+		projectById(projectId: $projectId) {
+			id,
+			name,
+			plugins {
+				id,
+				name,
+			},
+			environments {
+				id,
+				name
+			},
+			services {
+				id,
+				name
+			},
+		}
 	}
+`)
 
-	return resp.ProjectToken, nil
+type projectByNameVars struct {
+	ProjectName string `json:"projectName"`
 }
 
-// GetProject returns the project associated with the projectId, as well as
-// it's environments, plugins, etc
-func (g *Gateway) GetProject(ctx context.Context, projectId string) (*entity.Project, error) {
-	gqlReq, err := g.NewRequestWithAuth(`
-		query ($projectId: ID!) {
-			projectById(projectId: $projectId) {
+type projectByNameResult struct {
+	Me struct {
+		Projects []*entity.Project `json:"projects"`
+	} `json:"me"`
+}
+
+var projectByNameQuery = operation[projectByNameVars, projectByNameResult]("projectByName", `
+	query ($projectName: String!) {
+		me {
+			projects(where: { name: { equals: $projectName } }) {
 				id,
 				name,
 				plugins {
@@ -56,37 +72,46 @@ func (g *Gateway) GetProject(ctx context.Context, projectId string) (*entity.Pro
 					id,
 					name
 				},
-				services {
-					id,
-					name
-				},
 			}
 		}
-	`)
-
-	if err != nil {
-		return nil, err
-	}
-
-	gqlReq.Var("projectId", projectId)
-
-	var resp struct {
-		Project *entity.Project `json:"projectById"`
-	}
-
-	if err := gqlReq.Run(ctx, &resp); err != nil {
-		return nil, errors.ProjectConfigNotFound
 	}
-
-	return resp.Project, nil
+`)
+
+type projectsResult struct {
+	Me struct {
+		Name     *string           `json:"name"`
+		Projects []*entity.Project `json:"projects"`
+		Teams    []*struct {
+			Name     string            `json:"name"`
+			Projects []*entity.Project `json:"projects"`
+		} `json:"teams"`
+	} `json:"me"`
 }
 
-func (g *Gateway) GetProjectByName(ctx context.Context, projectName string) (*entity.Project, error) {
-	gqlReq, err := g.NewRequestWithAuth(`
-		query ($projectName: String!) {
-			me {
-				projects(where: { name: { equals: $projectName } }) {
+// projectsQuery fetches the projects of the user along with their
+// environments and plugins, performing a dual join
+var projectsQuery = operation[struct{}, projectsResult]("projects", `
+	query {
+		me {
+			name
+			projects {
+				id,
+				updatedAt,
+				name,
+				plugins {
+					id,
+					name,
+				},
+				environments {
+					id,
+					name
+				},
+			}
+			teams {
+				name
+				projects {
 					id,
+					updatedAt,
 					name,
 					plugins {
 						id,
@@ -99,22 +124,125 @@ func (g *Gateway) GetProjectByName(ctx context.Context, projectName string) (*en
 				}
 			}
 		}
-	`)
+	}
+`)
+
+type createProjectVars struct {
+	Name *string `json:"name"`
+}
+
+type createProjectResult struct {
+	Project *entity.Project `json:"createProject"`
+}
+
+var createProjectMutation = operation[createProjectVars, createProjectResult]("createProject", `
+	mutation($name: String) {
+		createProject(name: $name) {
+			id,
+			name
+			environments {
+				id
+				name
+			}
+		}
+	}
+`)
+
+type createProjectFromTemplateVars struct {
+	Name      string            `json:"name"`
+	Owner     string            `json:"owner"`
+	Template  string            `json:"template"`
+	IsPrivate bool              `json:"isPrivate"`
+	Plugins   []string          `json:"plugins"`
+	Variables map[string]string `json:"variables"`
+}
+
+type createProjectFromTemplateResult struct {
+	Result *entity.CreateProjectFromTemplateResult `json:"createProjectFromTemplate"`
+}
+
+var createProjectFromTemplateMutation = operation[createProjectFromTemplateVars, createProjectFromTemplateResult]("createProjectFromTemplate", `
+	mutation($name: String!, $owner: String!, $template: String!, $isPrivate: Boolean, $plugins: [String!], $variables: Json) {
+		createProjectFromTemplate(name: $name, owner: $owner, template: $template, isPrivate: $isPrivate, plugins: $plugins, variables: $variables) {
+			projectId
+			workflowId
+		}
+	}
+`)
+
+type updateProjectResult struct {
+	Project *entity.Project `json:"updateProject"`
+}
+
+var updateProjectMutation = operation[projectVars, updateProjectResult]("updateProject", `
+	mutation($projectId: ID!) {
+		updateProject(projectId: $projectId) {
+			id,
+			name
+		}
+	}
+`)
+
+type deleteProjectResult struct {
+	Deleted bool `json:"deleteProject"`
+}
+
+var deleteProjectMutation = operation[projectVars, deleteProjectResult]("deleteProject", `
+	mutation($projectId: String!) {
+		deleteProject(projectId: $projectId)
+	}
+`)
+
+// GetProjectToken looks up a project and environment by the RAILWAY_TOKEN
+func (g *Gateway) GetProjectToken(ctx context.Context) (*entity.ProjectToken, error) {
+	if g.projectToken == "" {
+		return nil, errors.ProjectTokenNotFound
+	}
+
+	gqlReq, err := g.NewRequestWithAuth(projectTokenQuery.Document)
 
 	if err != nil {
 		return nil, err
 	}
 
-	gqlReq.Var("projectName", projectName)
+	resp, err := runOperation(ctx, gqlReq, projectTokenQuery, struct{}{})
 
-	var resp struct {
-		Me struct {
-			Projects []*entity.Project `json:"projects"`
-		} `json:"me"`
+	if err != nil {
+		return nil, withFallback(err, errors.ProjectTokenNotFound)
 	}
 
-	if err := gqlReq.Run(ctx, &resp); err != nil {
-		return nil, errors.ProjectConfigNotFound
+	return resp.ProjectToken, nil
+}
+
+// GetProject returns the project associated with the projectId, as well as
+// it's environments, plugins, etc
+func (g *Gateway) GetProject(ctx context.Context, projectId string) (*entity.Project, error) {
+	gqlReq, err := g.NewRequestWithAuth(projectByIDQuery.Document)
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := runOperation(ctx, gqlReq, projectByIDQuery, projectVars{ProjectID: projectId})
+
+	if err != nil {
+		return nil, withFallback(err, errors.ProjectConfigNotFound)
+	}
+
+	return resp.Project, nil
+}
+
+func (g *Gateway) GetProjectByName(ctx context.Context, projectName string) (*entity.Project, error) {
+	gqlReq, err := g.NewRequestWithAuth(projectByNameQuery.Document)
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := runOperation(ctx, gqlReq, projectByNameQuery, projectByNameVars{ProjectName: projectName})
+
+	if err != nil {
+		return nil, withFallback(err, errors.ProjectConfigNotFound)
 	}
 
 	projects := resp.Me.Projects
@@ -127,89 +255,54 @@ func (g *Gateway) GetProjectByName(ctx context.Context, projectName string) (*en
 }
 
 func (g *Gateway) CreateProject(ctx context.Context, req *entity.CreateProjectRequest) (*entity.Project, error) {
-	gqlReq, err := g.NewRequestWithAuth(`
-		mutation($name: String) {
-			createProject(name: $name) {
-				id,
-				name
-				environments {
-					id
-					name
-				}
-			}
-		}
-	`)
+	gqlReq, err := g.NewRequestWithAuth(createProjectMutation.Document)
 
 	if err != nil {
 		return nil, err
 	}
 
-	gqlReq.Var("name", req.Name)
+	resp, err := runOperation(ctx, gqlReq, createProjectMutation, createProjectVars{Name: req.Name})
 
-	var resp struct {
-		Project *entity.Project `json:"createProject"`
-	}
-
-	if err := gqlReq.Run(ctx, &resp); err != nil {
-		return nil, errors.ProjectCreateFailed
+	if err != nil {
+		return nil, withFallback(err, errors.ProjectCreateFailed)
 	}
 
 	return resp.Project, nil
 }
 
 func (g *Gateway) CreateProjectFromTemplate(ctx context.Context, req *entity.CreateProjectFromTemplateRequest) (*entity.CreateProjectFromTemplateResult, error) {
-	gqlReq, err := g.NewRequestWithAuth(`
-		mutation($name: String!, $owner: String!, $template: String!, $isPrivate: Boolean, $plugins: [String!], $variables: Json) {
-			createProjectFromTemplate(name: $name, owner: $owner, template: $template, isPrivate: $isPrivate, plugins: $plugins, variables: $variables) {
-				projectId
-				workflowId
-			}
-		}
-	`)
+	gqlReq, err := g.NewRequestWithAuth(createProjectFromTemplateMutation.Document)
 
 	if err != nil {
 		return nil, err
 	}
 
-	gqlReq.Var("name", req.Name)
-	gqlReq.Var("owner", req.Owner)
-	gqlReq.Var("template", req.Template)
-	gqlReq.Var("isPrivate", req.IsPrivate)
-	gqlReq.Var("plugins", req.Plugins)
-	gqlReq.Var("variables", req.Variables)
+	resp, err := runOperation(ctx, gqlReq, createProjectFromTemplateMutation, createProjectFromTemplateVars{
+		Name:      req.Name,
+		Owner:     req.Owner,
+		Template:  req.Template,
+		IsPrivate: req.IsPrivate,
+		Plugins:   req.Plugins,
+		Variables: req.Variables,
+	})
 
-	var resp struct {
-		Result *entity.CreateProjectFromTemplateResult `json:"createProjectFromTemplate"`
-	}
-
-	if err := gqlReq.Run(ctx, &resp); err != nil {
-		return nil, errors.ProjectCreateFromTemplateFailed
+	if err != nil {
+		return nil, withFallback(err, errors.ProjectCreateFromTemplateFailed)
 	}
 
 	return resp.Result, nil
 }
 
 func (g *Gateway) UpdateProject(ctx context.Context, req *entity.UpdateProjectRequest) (*entity.Project, error) {
-	gqlReq, err := g.NewRequestWithAuth(`
-		mutation($projectId: ID!) {
-			updateProject(projectId: $projectId) {
-				id,
-				name
-			}
-		}
-	`)
+	gqlReq, err := g.NewRequestWithAuth(updateProjectMutation.Document)
 
 	if err != nil {
 		return nil, err
 	}
 
-	gqlReq.Var("projectId", req.Id)
+	resp, err := runOperation(ctx, gqlReq, updateProjectMutation, projectVars{ProjectID: req.Id})
 
-	var resp struct {
-		Project *entity.Project `json:"createProject"`
-	}
-
-	if err := gqlReq.Run(ctx, &resp); err != nil {
+	if err != nil {
 		return nil, err
 	}
 
@@ -217,100 +310,64 @@ func (g *Gateway) UpdateProject(ctx context.Context, req *entity.UpdateProjectRe
 }
 
 func (g *Gateway) DeleteProject(ctx context.Context, projectId string) error {
-	gqlReq, err := g.NewRequestWithAuth(`
-		mutation($projectId: String!) {
-			deleteProject(projectId: $projectId)
-		}
-	`)
+	gqlReq, err := g.NewRequestWithAuth(deleteProjectMutation.Document)
 
 	if err != nil {
 		return err
 	}
 
-	gqlReq.Var("projectId", projectId)
-
-	var resp struct {
-		Deleted bool `json:"deleteProject"`
-	}
+	_, err = runOperation(ctx, gqlReq, deleteProjectMutation, projectVars{ProjectID: projectId})
 
-	return gqlReq.Run(ctx, &resp)
+	return err
 }
 
-// GetProjects returns all projects associated with the user, as well as
-// their environments associated with those projects, error otherwise
-// Performs a dual join
-func (g *Gateway) GetProjects(ctx context.Context) ([]*entity.Project, error) {
-	projectFrag := `
-		id,
-		updatedAt,
-		name,
-		plugins {
-			id,
-			name,
-		},
-		environments {
-			id,
-			name
-		},
-	`
+// IterateProjects walks all projects the user has access to, personal and
+// through teams, along with their environments and plugins. The backend
+// returns the projects of the user and of each team whole, in a single
+// response
+func (g *Gateway) IterateProjects() *Iterator[*entity.Project] {
+	return newIterator(func(ctx context.Context) ([]*entity.Project, error) {
+		gqlReq, err := g.NewRequestWithAuth(projectsQuery.Document)
 
-	gqlReq, err := g.NewRequestWithAuth(fmt.Sprintf(`
-		query {
-			me {
-				name
-				projects {
-					%s
-			  }
-				teams {
-					name
-					projects {
-						%s
-					}
-				}
-			}
+		if err != nil {
+			return nil, err
 		}
-	`, projectFrag, projectFrag))
 
-	if err != nil {
-		return nil, err
-	}
+		resp, err := runOperation(ctx, gqlReq, projectsQuery, struct{}{})
 
-	var resp struct {
-		Me struct {
-			Name     *string           `json:"name"`
-			Projects []*entity.Project `json:"projects"`
-			Teams    []*struct {
-				Name     string            `json:"name"`
-				Projects []*entity.Project `json:"projects"`
-			} `json:"teams"`
-		} `json:"me"`
-	}
-
-	if err := gqlReq.Run(ctx, &resp); err != nil {
-		return nil, errors.ProblemFetchingProjects
-	}
-
-	projects := resp.Me.Projects
+		if err != nil {
+			return nil, withFallback(err, errors.ProblemFetchingProjects)
+		}
 
-	for _, project := range resp.Me.Projects {
+		// Personal projects are listed under the name of the user
 		name := "Me"
 
 		if resp.Me.Name != nil {
 			name = *resp.Me.Name
 		}
 
-		project.Team = &name
-	}
+		for _, project := range resp.Me.Projects {
+			project.Team = &name
+		}
+
+		projects := resp.Me.Projects
+
+		for _, team := range resp.Me.Teams {
+			for _, project := range team.Projects {
+				project.Team = &team.Name
+			}
 
-	for _, team := range resp.Me.Teams {
-		for _, project := range team.Projects {
-			project.Team = &team.Name
+			projects = append(projects, team.Projects...)
 		}
 
-		projects = append(projects, team.Projects...)
-	}
+		return projects, nil
+	})
+}
 
-	return projects, nil
+// GetProjects returns all projects associated with the user, as well as
+// their environments associated with those projects, error otherwise
+func (g *Gateway) GetProjects(ctx context.Context) ([]*entity.Project, error) {
+	return g.IterateProjects().All(ctx)
 }
 
 func (g *Gateway) OpenProjectInBrowser(projectID string, environmentID string) error {
@@ -0,0 +1,152 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how failed GraphQL requests are retried. Only queries,
+// and mutations explicitly marked as idempotent, are ever retried
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, doubled on every attempt
+	BaseDelay time.Duration
+	// MaxDelay caps the exponential backoff
+	MaxDelay time.Duration
+	// MaxRetryAfter is the longest Retry-After we are willing to wait for.
+	// Requests asking for longer fail right away
+	MaxRetryAfter time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:   4,
+	BaseDelay:     250 * time.Millisecond,
+	MaxDelay:      8 * time.Second,
+	MaxRetryAfter: 30 * time.Second,
+}
+
+var (
+	jitterMu  sync.Mutex
+	jitterRnd = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// backoff returns the delay before retrying after the given (1-based) attempt.
+// Half of the delay is random, so that clients failing together don't retry
+// together
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	if delay <= 0 {
+		return 0
+	}
+
+	jitterMu.Lock()
+	jitter := time.Duration(jitterRnd.Int63n(int64(delay)/2 + 1))
+	jitterMu.Unlock()
+
+	return delay/2 + jitter
+}
+
+// wait returns how long to wait before the next attempt, and false if the
+// error should not be retried at all
+func (p RetryPolicy) wait(ctx context.Context, attempt int, err error) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts || ctx.Err() != nil {
+		return 0, false
+	}
+
+	delay := p.backoff(attempt)
+
+	if errors.As(err, new(*url.Error)) {
+		// Certificate errors, refused connections and the like won't go away
+		// by trying again
+		return delay, isTransientNetError(err)
+	}
+
+	reqErr, ok := AsRequestError(err)
+	if !ok {
+		return 0, false
+	}
+
+	if !errors.As(err, new(*RateLimitError)) && !isTransientStatus(reqErr.StatusCode) {
+		return 0, false
+	}
+
+	if reqErr.RetryAfter > p.MaxRetryAfter {
+		return 0, false
+	}
+
+	if reqErr.RetryAfter > delay {
+		delay = reqErr.RetryAfter
+	}
+
+	return delay, true
+}
+
+func isTransientStatus(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// isTransientNetError tells whether a failed round trip is worth retrying:
+// timeouts, connections dropped by the server or something in between, and
+// DNS failures the resolver itself calls temporary
+func isTransientNetError(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTemporary
+	}
+
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+var operationTypeRegex = regexp.MustCompile(`^\s*(query|mutation|subscription)\b`)
+
+// isQuery reports whether a GraphQL document is a query, including the
+// shorthand `{ ... }` form
+func isQuery(document string) bool {
+	match := operationTypeRegex.FindStringSubmatch(document)
+
+	return match == nil || match[1] == "query"
+}
+
+// Sleep waits for d, returning early with the context's error if it is done
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,224 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fastRetries retries like the default policy, without the waiting
+var fastRetries = RetryPolicy{
+	MaxAttempts:   4,
+	BaseDelay:     time.Millisecond,
+	MaxDelay:      4 * time.Millisecond,
+	MaxRetryAfter: time.Second,
+}
+
+// failingServer fails the first failures requests with fail, and answers the
+// others with an empty result. It returns the number of requests received
+func failingServer(t *testing.T, failures int32, fail func(w http.ResponseWriter)) (*Gateway, *int32) {
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&requests, 1) <= failures {
+			fail(w)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"data": {}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	g := NewClient(WithHost(server.URL))
+	g.SetRetryPolicy(fastRetries)
+
+	return g, &requests
+}
+
+func failWithStatus(status int) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.WriteHeader(status)
+	}
+}
+
+// dropConnection closes the connection without answering
+func dropConnection(w http.ResponseWriter) {
+	conn, _, err := w.(http.Hijacker).Hijack()
+	if err == nil {
+		conn.Close()
+	}
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name       string
+		document   string
+		idempotent bool
+		failures   int32
+		fail       func(w http.ResponseWriter)
+		wantErr    bool
+		want       int32
+	}{
+		{name: "query recovering", document: "query { me { id } }", failures: 2, fail: failWithStatus(http.StatusServiceUnavailable), want: 3},
+		{name: "shorthand query", document: "{ me { id } }", failures: 1, fail: failWithStatus(http.StatusBadGateway), want: 2},
+		{name: "query failing every attempt", document: "query { me { id } }", failures: 10, fail: failWithStatus(http.StatusServiceUnavailable), wantErr: true, want: 4},
+		{name: "query dropped", document: "query { me { id } }", failures: 2, fail: dropConnection, want: 3},
+		{name: "query rejected", document: "query { me { id } }", failures: 1, fail: failWithStatus(http.StatusBadRequest), wantErr: true, want: 1},
+		{name: "server error", document: "query { me { id } }", failures: 1, fail: failWithStatus(http.StatusInternalServerError), wantErr: true, want: 1},
+		{name: "mutation", document: "mutation { logout }", failures: 1, fail: failWithStatus(http.StatusServiceUnavailable), wantErr: true, want: 1},
+		{name: "mutation dropped", document: "mutation { logout }", failures: 1, fail: dropConnection, wantErr: true, want: 1},
+		{name: "idempotent mutation", document: "mutation { logout }", idempotent: true, failures: 1, fail: failWithStatus(http.StatusServiceUnavailable), want: 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g, requests := failingServer(t, test.failures, test.fail)
+
+			req := g.NewRequestWithoutAuth(test.document)
+			if test.idempotent {
+				req.MarkIdempotent()
+			}
+
+			err := req.Run(context.Background(), &struct{}{})
+			if (err != nil) != test.wantErr {
+				t.Errorf("err = %v, want an error: %t", err, test.wantErr)
+			}
+
+			if got := atomic.LoadInt32(requests); got != test.want {
+				t.Errorf("sent %d requests, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	want := []time.Duration{100, 200, 400, 800, 1000, 1000}
+
+	for i, max := range want {
+		attempt, max := i+1, max*time.Millisecond
+		seen := map[time.Duration]bool{}
+
+		for j := 0; j < 200; j++ {
+			delay := policy.backoff(attempt)
+			if delay < max/2 || delay > max {
+				t.Fatalf("attempt %d waits %s, want between %s and %s", attempt, delay, max/2, max)
+			}
+
+			seen[delay] = true
+		}
+
+		if len(seen) < 2 {
+			t.Errorf("attempt %d always waits %s, without jitter", attempt, want[i])
+		}
+	}
+
+	if delay := (RetryPolicy{}).backoff(1); delay != 0 {
+		t.Errorf("a policy without delays waits %s", delay)
+	}
+}
+
+func TestRetryWait(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: 10 * time.Millisecond, MaxDelay: 10 * time.Millisecond, MaxRetryAfter: 10 * time.Second}
+
+	rateLimited := func(retryAfter string) error {
+		return newRequestError(&http.Response{
+			StatusCode: http.StatusTooManyRequests,
+			Header:     http.Header{"Retry-After": {retryAfter}},
+		}, nil, "")
+	}
+
+	tests := []struct {
+		name      string
+		attempt   int
+		err       error
+		wantRetry bool
+		min, max  time.Duration
+	}{
+		{name: "Retry-After seconds", attempt: 1, err: rateLimited("2"), wantRetry: true, min: 2 * time.Second, max: 2 * time.Second},
+		{name: "Retry-After date", attempt: 1, err: rateLimited(time.Now().Add(5 * time.Second).UTC().Format(http.TimeFormat)), wantRetry: true, min: 3 * time.Second, max: 5 * time.Second},
+		{name: "Retry-After in the past", attempt: 1, err: rateLimited(time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)), wantRetry: true, min: 5 * time.Millisecond, max: 10 * time.Millisecond},
+		{name: "Retry-After too long", attempt: 1, err: rateLimited("60"), wantRetry: false},
+		{name: "Retry-After invalid", attempt: 1, err: rateLimited("soon"), wantRetry: true, min: 5 * time.Millisecond, max: 10 * time.Millisecond},
+		{name: "last attempt", attempt: 3, err: rateLimited("1"), wantRetry: false},
+		{name: "timeout", attempt: 1, err: &url.Error{Op: "Post", URL: "/graphql", Err: timeoutError{}}, wantRetry: true, min: 5 * time.Millisecond, max: 10 * time.Millisecond},
+		{name: "refused", attempt: 1, err: &url.Error{Op: "Post", URL: "/graphql", Err: syscall.ECONNREFUSED}, wantRetry: false},
+		{name: "unknown error", attempt: 1, err: errors.New("decoding response"), wantRetry: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			delay, retry := policy.wait(context.Background(), test.attempt, test.err)
+			if retry != test.wantRetry {
+				t.Fatalf("retry = %t, want %t", retry, test.wantRetry)
+			}
+
+			if retry && (delay < test.min || delay > test.max) {
+				t.Errorf("waits %s, want between %s and %s", delay, test.min, test.max)
+			}
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, retry := policy.wait(ctx, 1, rateLimited("1")); retry {
+		t.Error("retried after the context was canceled")
+	}
+}
+
+func TestIsTransientNetError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: &net.OpError{Op: "read", Err: timeoutError{}}, want: true},
+		{err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}, want: true},
+		{err: &net.OpError{Op: "read", Err: syscall.ECONNABORTED}, want: true},
+		{err: &net.OpError{Op: "write", Err: syscall.EPIPE}, want: true},
+		{err: io.EOF, want: true},
+		{err: io.ErrUnexpectedEOF, want: true},
+		{err: &net.DNSError{Err: "server misbehaving", IsTemporary: true}, want: true},
+		{err: &net.DNSError{Err: "no such host", IsNotFound: true}, want: false},
+		{err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, want: false},
+		{err: errors.New("x509: certificate signed by unknown authority"), want: false},
+	}
+
+	for _, test := range tests {
+		err := &url.Error{Op: "Post", URL: "https://backboard.railway.app/graphql", Err: test.err}
+
+		if got := isTransientNetError(err); got != test.want {
+			t.Errorf("isTransientNetError(%v) = %t, want %t", err, got, test.want)
+		}
+	}
+}
+
+func TestIsQuery(t *testing.T) {
+	tests := []struct {
+		document string
+		want     bool
+	}{
+		{document: "query { me { id } }", want: true},
+		{document: "\n\tquery Me($id: ID!) { me { id } }", want: true},
+		{document: "{ me { id } }", want: true},
+		{document: "mutation { logout }", want: false},
+		{document: "  mutation Logout { logout }", want: false},
+		{document: "subscription { logs }", want: false},
+	}
+
+	for _, test := range tests {
+		if got := isQuery(test.document); got != test.want {
+			t.Errorf("isQuery(%q) = %t, want %t", test.document, got, test.want)
+		}
+	}
+}
@@ -6,24 +6,28 @@ import (
 	"github.com/botwayorg/railway-api/errors"
 )
 
+type writableGithubScopesResult struct {
+	Scopes []string `json:"getWritableGithubScopes"`
+}
+
+var writableGithubScopesQuery = operation[struct{}, writableGithubScopesResult]("getWritableGithubScopes", `
+	query {
+		getWritableGithubScopes
+	}
+`)
+
 // GetWritableGithubScopes returns scopes associated with Railway user
 func (g *Gateway) GetWritableGithubScopes(ctx context.Context) ([]string, error) {
-	gqlReq, err := g.NewRequestWithAuth(`
-		query {
-			getWritableGithubScopes 
-		}
-	`)
+	gqlReq, err := g.NewRequestWithAuth(writableGithubScopesQuery.Document)
 
 	if err != nil {
 		return nil, err
 	}
 
-	var resp struct {
-		Scopes []string `json:"getWritableGithubScopes"`
-	}
+	resp, err := runOperation(ctx, gqlReq, writableGithubScopesQuery, struct{}{})
 
-	if err := gqlReq.Run(ctx, &resp); err != nil {
-		return nil, errors.ProblemFetchingWritableGithubScopes
+	if err != nil {
+		return nil, withFallback(err, errors.ProblemFetchingWritableGithubScopes)
 	}
 
 	return resp.Scopes, nil
@@ -6,26 +6,28 @@ import (
 	"github.com/botwayorg/railway-api/entity"
 )
 
-func (g *Gateway) GetStarters(ctx context.Context) ([]*entity.Starter, error) {
-	gqlReq := g.NewRequestWithoutAuth(`
-		query {
-			getAllStarters {
-				title
-				url
-				source
-			}
-		}
-	`)
+type startersResult struct {
+	Starters []*entity.Starter `json:"getAllStarters"`
+}
 
-	var resp struct {
-		Starters []*entity.Starter `json:"getAllStarters"`
+var startersQuery = operation[struct{}, startersResult]("getAllStarters", `
+	query {
+		getAllStarters {
+			title
+			url
+			source
+		}
 	}
+`)
 
-	if err := gqlReq.Run(ctx, &resp); err != nil {
+func (g *Gateway) GetStarters(ctx context.Context) ([]*entity.Starter, error) {
+	gqlReq := g.NewRequestWithoutAuth(startersQuery.Document)
+
+	resp, err := runOperation(ctx, gqlReq, startersQuery, struct{}{})
+
+	if err != nil {
 		return nil, err
 	}
 
-	starters := resp.Starters
-
-	return starters, nil
+	return resp.Starters, nil
 }
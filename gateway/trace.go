@@ -0,0 +1,490 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/botwayorg/railway-api/constants"
+)
+
+const redacted = "[REDACTED]"
+
+// Headers that never make it into a trace or HAR file in clear text
+var sensitiveHeaders = map[string]bool{
+	"authorization":        true,
+	"project-access-token": true,
+	"cookie":               true,
+	"set-cookie":           true,
+}
+
+// Variable and response keys whose values are redacted. Keys are matched
+// case-insensitively, by substring
+var sensitiveKeys = []string{
+	"token",
+	"secret",
+	"password",
+}
+
+// Keys holding maps of environment variables. Their names are kept, their
+// values are redacted
+var variableMapKeys = map[string]bool{
+	"variables":                    true,
+	"decryptedVariablesForService": true,
+}
+
+// Tracer records the requests going through the gateway. It logs a line per
+// request and can additionally keep the whole session in a HAR file
+type Tracer struct {
+	mu      sync.Mutex
+	out     io.Writer
+	harPath string
+	// harEntries is the number of entries in the HAR file, and harEnd the
+	// offset of the end of its last one, where the next one is written
+	harEntries int
+	harEnd     int64
+}
+
+// NewTracer returns a tracer logging to out, if not nil, and writing a HAR
+// file to harPath, if not empty
+func NewTracer(out io.Writer, harPath string) *Tracer {
+	return &Tracer{
+		out:     out,
+		harPath: harPath,
+	}
+}
+
+// NewTracerFromEnv returns a tracer configured with RAILWAY_TRACE and
+// RAILWAY_HAR, or nil if tracing is not enabled
+func NewTracerFromEnv() *Tracer {
+	trace := os.Getenv("RAILWAY_TRACE")
+	harPath := os.Getenv("RAILWAY_HAR")
+
+	if (trace == "" || trace == "0" || trace == "false") && harPath == "" {
+		return nil
+	}
+
+	var out io.Writer
+	if trace != "" && trace != "0" && trace != "false" {
+		out = os.Stderr
+	}
+
+	return NewTracer(out, harPath)
+}
+
+// roundTrip sends the request through base and records the exchange
+func (t *Tracer) roundTrip(base http.RoundTripper, req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+
+	// Only buffer JSON bodies, uploads are streamed and can be huge
+	if req.Body != nil && isJSON(req.Header.Get("Content-Type")) {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		reqBody = body
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	start := time.Now()
+	res, err := base.RoundTrip(req)
+	elapsed := time.Since(start)
+
+	var resBody []byte
+
+	if err == nil && isJSON(res.Header.Get("Content-Type")) {
+		body, readErr := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+
+		if readErr != nil {
+			return nil, readErr
+		}
+
+		resBody = body
+		res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	t.record(req, reqBody, res, resBody, err, start, elapsed)
+
+	return res, err
+}
+
+func (t *Tracer) record(req *http.Request, reqBody []byte, res *http.Response, resBody []byte, err error, start time.Time, elapsed time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.out != nil {
+		t.log(req, reqBody, res, err, elapsed)
+	}
+
+	if t.harPath != "" {
+		if err := t.appendHAR(newHAREntry(req, reqBody, res, resBody, start, elapsed)); err != nil && t.out != nil {
+			fmt.Fprintf(t.out, "[trace] unable to write %s: %s\n", t.harPath, err)
+		}
+	}
+}
+
+func (t *Tracer) log(req *http.Request, reqBody []byte, res *http.Response, err error, elapsed time.Duration) {
+	name := fmt.Sprintf("%s %s", req.Method, req.URL.Path)
+	vars := ""
+
+	if reqBody != nil {
+		var body struct {
+			Query     string                 `json:"query"`
+			Variables map[string]interface{} `json:"variables"`
+		}
+
+		if json.Unmarshal(reqBody, &body) == nil && body.Query != "" {
+			name = operationName(body.Query)
+
+			if len(body.Variables) > 0 {
+				encoded, _ := json.Marshal(redactValue("", body.Variables))
+				vars = " vars=" + string(encoded)
+			}
+		}
+	}
+
+	ms := elapsed.Milliseconds()
+
+	if err != nil {
+		fmt.Fprintf(t.out, "[trace] %s failed after %dms: %s%s\n", name, ms, err, vars)
+		return
+	}
+
+	reqID := requestID(res.Header)
+	if reqID == "" {
+		reqID = "-"
+	}
+
+	fmt.Fprintf(t.out, "[trace] %s status=%d time=%dms request-id=%s%s\n", name, res.StatusCode, ms, reqID, vars)
+}
+
+var (
+	namedOperationRegex = regexp.MustCompile(`^\s*(query|mutation|subscription)\s+([_A-Za-z][_0-9A-Za-z]*)`)
+	rootFieldRegex      = regexp.MustCompile(`\{\s*([_A-Za-z][_0-9A-Za-z]*)`)
+)
+
+// operationName returns the type and name of a GraphQL operation. Anonymous
+// operations are named after their first root field
+func operationName(document string) string {
+	opType := "query"
+
+	if match := operationTypeRegex.FindStringSubmatch(document); match != nil {
+		opType = match[1]
+	}
+
+	if match := namedOperationRegex.FindStringSubmatch(document); match != nil {
+		return fmt.Sprintf("%s %s", opType, match[2])
+	}
+
+	if match := rootFieldRegex.FindStringSubmatch(document); match != nil {
+		return fmt.Sprintf("%s %s", opType, match[1])
+	}
+
+	return opType
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isIDKey(key string) bool {
+	return key == "id" || strings.HasSuffix(key, "Id") || strings.HasSuffix(key, "ID")
+}
+
+// redactValue returns a copy of a decoded JSON value with the values of
+// sensitive keys replaced. Objects under sensitive keys, like projectToken,
+// keep their IDs
+func redactValue(key string, value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+	default:
+		if isSensitiveKey(key) {
+			return redacted
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+
+		for k, child := range v {
+			switch {
+			case variableMapKeys[key]:
+				out[k] = redacted
+			case isSensitiveKey(key) && !isIDKey(k):
+				out[k] = redactValue(key, child)
+			default:
+				out[k] = redactValue(k, child)
+			}
+		}
+
+		return out
+
+	case []interface{}:
+		out := make([]interface{}, len(v))
+
+		for i, child := range v {
+			out[i] = redactValue(key, child)
+		}
+
+		return out
+	}
+
+	return value
+}
+
+// redactJSON redacts a JSON document, returning it unchanged if it can't be
+// decoded
+func redactJSON(body []byte) string {
+	var value interface{}
+
+	if err := json.Unmarshal(body, &value); err != nil {
+		return string(body)
+	}
+
+	encoded, err := json.Marshal(redactValue("", value))
+	if err != nil {
+		return string(body)
+	}
+
+	return string(encoded)
+}
+
+// redactRequestBody redacts the variables of a GraphQL request body. The
+// top-level `variables` key is the request's, not a map of environment
+// variables, so it can't go through redactJSON
+func redactRequestBody(body []byte) string {
+	var value map[string]interface{}
+
+	if err := json.Unmarshal(body, &value); err != nil {
+		return string(body)
+	}
+
+	for k, v := range value {
+		if k == "variables" {
+			value[k] = redactValue("", v)
+		} else {
+			value[k] = redactValue(k, v)
+		}
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return string(body)
+	}
+
+	return string(encoded)
+}
+
+func isJSON(contentType string) bool {
+	return strings.Contains(strings.ToLower(contentType), "json")
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	Cookies     []harNameValue `json:"cookies"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	Cookies     []harNameValue `json:"cookies"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harTimings struct {
+	Send    int64 `json:"send"`
+	Wait    int64 `json:"wait"`
+	Receive int64 `json:"receive"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            int64       `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+func harHeaders(header http.Header) []harNameValue {
+	headers := []harNameValue{}
+
+	for name, values := range header {
+		for _, value := range values {
+			if sensitiveHeaders[strings.ToLower(name)] {
+				value = redacted
+			}
+
+			headers = append(headers, harNameValue{Name: name, Value: value})
+		}
+	}
+
+	return headers
+}
+
+func newHAREntry(req *http.Request, reqBody []byte, res *http.Response, resBody []byte, start time.Time, elapsed time.Duration) harEntry {
+	queryString := []harNameValue{}
+
+	for name, values := range req.URL.Query() {
+		for _, value := range values {
+			queryString = append(queryString, harNameValue{Name: name, Value: value})
+		}
+	}
+
+	entry := harEntry{
+		StartedDateTime: start.Format(time.RFC3339Nano),
+		Time:            elapsed.Milliseconds(),
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: req.Proto,
+			Headers:     harHeaders(req.Header),
+			QueryString: queryString,
+			Cookies:     []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    req.ContentLength,
+		},
+		Response: harResponse{
+			Headers:     []harNameValue{},
+			Cookies:     []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Timings: harTimings{
+			Wait: elapsed.Milliseconds(),
+		},
+	}
+
+	if reqBody != nil {
+		entry.Request.PostData = &harPostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     redactRequestBody(reqBody),
+		}
+	}
+
+	if res != nil {
+		entry.Response.Status = res.StatusCode
+		entry.Response.StatusText = http.StatusText(res.StatusCode)
+		entry.Response.HTTPVersion = res.Proto
+		entry.Response.Headers = harHeaders(res.Header)
+		entry.Response.BodySize = res.ContentLength
+		entry.Response.Content = harContent{
+			Size:     len(resBody),
+			MimeType: res.Header.Get("Content-Type"),
+		}
+
+		if resBody != nil {
+			entry.Response.Content.Text = redactJSON(resBody)
+		}
+	}
+
+	return entry
+}
+
+// harTrailer closes the entries list and the log of a HAR file
+const harTrailer = "\n    ]\n  }\n}\n"
+
+// appendHAR adds an entry to the HAR file, writing it over the trailer of the
+// previous one and closing the document again, so the file is complete even
+// if the process exits without warning
+func (t *Tracer) appendHAR(entry harEntry) error {
+	encoded, err := json.MarshalIndent(entry, "      ", "  ")
+	if err != nil {
+		return err
+	}
+
+	var chunk bytes.Buffer
+
+	flags := os.O_WRONLY
+	if t.harEntries == 0 {
+		flags |= os.O_CREATE | os.O_TRUNC
+
+		creator, err := json.MarshalIndent(map[string]string{
+			"name":    "railway-cli",
+			"version": constants.Version,
+		}, "    ", "  ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(&chunk, "{\n  \"log\": {\n    \"version\": \"1.2\",\n    \"creator\": %s,\n    \"entries\": [\n      ", creator)
+	} else {
+		chunk.WriteString(",\n      ")
+	}
+
+	chunk.Write(encoded)
+	end := t.harEnd + int64(chunk.Len())
+	chunk.WriteString(harTrailer)
+
+	f, err := os.OpenFile(t.harPath, flags, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.WriteAt(chunk.Bytes(), t.harEnd); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	t.harEntries++
+	t.harEnd = end
+
+	return nil
+}
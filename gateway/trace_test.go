@@ -0,0 +1,180 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// traceSecrets must not reach a trace or HAR file
+var traceSecrets = []string{
+	"user-token-secret",
+	"project-token-secret",
+	"variable-token-secret",
+	"response-token-secret",
+	"postgres://user:password-secret@db",
+	"api-key-secret",
+	"session-cookie-secret",
+}
+
+func traceServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=session-cookie-secret")
+		w.Header().Set("X-Request-Id", "req-1")
+
+		if strings.Contains(string(body), "mutation") {
+			_, _ = io.WriteString(w, `{"data": {"variableCollectionUpsert": true}}`)
+			return
+		}
+
+		_, _ = io.WriteString(w, `{"data": {
+			"projectToken": {"id": "token-id", "token": "response-token-secret"},
+			"decryptedVariablesForService": {"DATABASE_URL": "postgres://user:password-secret@db"}
+		}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestTracerHAR(t *testing.T) {
+	server := traceServer(t)
+	harPath := filepath.Join(t.TempDir(), "session.har")
+
+	var log bytes.Buffer
+	tracer := NewTracer(&log, harPath)
+
+	user := NewClient(WithHost(server.URL), WithToken("user-token-secret"))
+	user.SetTracer(tracer)
+
+	project := NewClient(WithHost(server.URL), WithProjectToken("project-token-secret"))
+	project.SetTracer(tracer)
+
+	query, err := user.NewRequestWithAuth("query ($projectId: ID!, $token: String!) { projectToken(projectId: $projectId, token: $token) { id token } }")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query.Var("projectId", "project-id")
+	query.Var("token", "variable-token-secret")
+
+	if err := query.Run(context.Background(), &struct{}{}); err != nil {
+		t.Fatal(err)
+	}
+
+	readHAR(t, harPath, 1)
+
+	mutation, err := project.NewRequestWithAuth("mutation ($variables: ServiceVariables!) { variableCollectionUpsert(variables: $variables) }")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mutation.Var("variables", map[string]string{"API_KEY": "api-key-secret"})
+
+	if err := mutation.Run(context.Background(), &struct{}{}); err != nil {
+		t.Fatal(err)
+	}
+
+	har := readHAR(t, harPath, 2)
+
+	encoded, err := ioutil.ReadFile(harPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, secret := range traceSecrets {
+		if bytes.Contains(encoded, []byte(secret)) {
+			t.Errorf("the HAR file holds %q", secret)
+		}
+
+		if strings.Contains(log.String(), secret) {
+			t.Errorf("the trace holds %q", secret)
+		}
+	}
+
+	// What helps debugging is kept
+	for _, kept := range []string{"project-id", "token-id", "DATABASE_URL", "API_KEY", "req-1"} {
+		if !bytes.Contains(encoded, []byte(kept)) {
+			t.Errorf("the HAR file lost %q", kept)
+		}
+	}
+
+	headers := map[string]string{}
+	for _, entry := range har.Log.Entries {
+		for _, header := range append(entry.Request.Headers, entry.Response.Headers...) {
+			headers[strings.ToLower(header.Name)] = header.Value
+		}
+	}
+
+	for _, name := range []string{"authorization", "project-access-token", "set-cookie"} {
+		if headers[name] != redacted {
+			t.Errorf("%s = %q, want it redacted", name, headers[name])
+		}
+	}
+
+	if got := strings.Count(log.String(), "[trace] "); got != 2 {
+		t.Errorf("traced %d lines, want 2:\n%s", got, log.String())
+	}
+}
+
+type harFile struct {
+	Log struct {
+		Version string     `json:"version"`
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+// readHAR decodes the HAR file at path, which must hold the given number of
+// entries
+func readHAR(t *testing.T, path string, entries int) *harFile {
+	encoded, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var har harFile
+	if err := json.Unmarshal(encoded, &har); err != nil {
+		t.Fatalf("the HAR file isn't valid JSON after %d requests: %s\n%s", entries, err, encoded)
+	}
+
+	if har.Log.Version != "1.2" || len(har.Log.Entries) != entries {
+		t.Fatalf("the HAR file has version %q and %d entries, want 1.2 and %d", har.Log.Version, len(har.Log.Entries), entries)
+	}
+
+	return &har
+}
+
+func TestRedactValue(t *testing.T) {
+	value := map[string]interface{}{
+		"projectId": "project-id",
+		"password":  "hunter2",
+		"projectToken": map[string]interface{}{
+			"id":        "token-id",
+			"projectId": "project-id",
+			"value":     "abc",
+		},
+		"variables":  map[string]interface{}{"PORT": "3000"},
+		"apiSecrets": []interface{}{"a", "b"},
+		"name":       "web",
+	}
+
+	got, err := json.Marshal(redactValue("", value))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"apiSecrets":["[REDACTED]","[REDACTED]"],"name":"web","password":"[REDACTED]","projectId":"project-id","projectToken":{"id":"token-id","projectId":"project-id","value":"[REDACTED]"},"variables":{"PORT":"[REDACTED]"}}`
+	if string(got) != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,222 @@
+package gateway
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/botwayorg/railway-api/entity"
+)
+
+// ConfigureTransport applies the TLS and proxy settings to every connection
+// the gateway opens: queries and uploads alike. Any setting replaces the
+// transport of the client passed to WithHTTPClient, while an empty config
+// changes nothing
+func (g *Gateway) ConfigureTransport(cfg *entity.TransportConfig) error {
+	if cfg == nil || *cfg == (entity.TransportConfig{}) {
+		return nil
+	}
+
+	base, err := newBaseTransport(cfg)
+	if err != nil {
+		return err
+	}
+
+	g.transport.Base = base
+
+	return nil
+}
+
+// baseTransport returns the transport requests are sent through once the
+// common headers are attached
+func (t *AttachCommonHeadersTransport) baseTransport() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+
+	return http.DefaultTransport
+}
+
+func newBaseTransport(cfg *entity.TransportConfig) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	tlsConfig := &tls.Config{}
+
+	if cfg.CAFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+
+		pem, err := ioutil.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA file: %w", err)
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in CA file %s", cfg.CAFile)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.ClientCert != "" || cfg.ClientKey != "" {
+		if cfg.ClientCert == "" || cfg.ClientKey == "" {
+			return nil, errors.New("a client certificate needs both a certificate and a key file")
+		}
+
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCert, cfg.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	proxy, err := proxyFunc(cfg.ProxyURL, cfg.NoProxy)
+	if err != nil {
+		return nil, err
+	}
+
+	transport.TLSClientConfig = tlsConfig
+	transport.Proxy = proxy
+
+	return transport, nil
+}
+
+// proxyFunc returns the proxy selection for the transport. Without an explicit
+// proxy URL the environment decides, minus the hosts in noProxy
+func proxyFunc(proxyURL string, noProxy string) (func(*http.Request) (*url.URL, error), error) {
+	if proxyURL == "" && noProxy == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+
+	var proxy *url.URL
+
+	if proxyURL != "" {
+		// Like curl, assume an HTTP proxy when no scheme is given
+		if !strings.Contains(proxyURL, "://") {
+			proxyURL = "http://" + proxyURL
+		}
+
+		u, err := url.Parse(proxyURL)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", proxyURL)
+		}
+
+		proxy = u
+	}
+
+	bypass := parseNoProxy(noProxy)
+
+	return func(req *http.Request) (*url.URL, error) {
+		if bypass.matches(req.URL) {
+			return nil, nil
+		}
+
+		if proxy != nil {
+			return proxy, nil
+		}
+
+		return http.ProxyFromEnvironment(req)
+	}, nil
+}
+
+// noProxyList is a parsed NO_PROXY style list: hosts, domains matching their
+// subdomains too, IPs and CIDRs, each optionally with a port. Loopback
+// addresses are always reached directly
+type noProxyList struct {
+	all      bool
+	networks []*net.IPNet
+	hosts    []noProxyHost
+}
+
+type noProxyHost struct {
+	// name is an IP or a domain, without its leading dot
+	name string
+	port string
+}
+
+func parseNoProxy(list string) *noProxyList {
+	bypass := &noProxyList{}
+
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+
+		switch {
+		case entry == "":
+			continue
+		case entry == "*":
+			bypass.all = true
+			continue
+		}
+
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			bypass.networks = append(bypass.networks, network)
+			continue
+		}
+
+		host, port := entry, ""
+		if h, p, err := net.SplitHostPort(entry); err == nil {
+			host, port = h, p
+		}
+
+		bypass.hosts = append(bypass.hosts, noProxyHost{
+			name: strings.TrimPrefix(host, "."),
+			port: port,
+		})
+	}
+
+	return bypass
+}
+
+func (l *noProxyList) matches(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+
+	if port == "" {
+		if u.Scheme == "https" {
+			port = "443"
+		} else {
+			port = "80"
+		}
+	}
+
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	if ip != nil && ip.IsLoopback() {
+		return true
+	}
+
+	if l.all {
+		return true
+	}
+
+	if ip != nil {
+		for _, network := range l.networks {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+	}
+
+	for _, entry := range l.hosts {
+		if entry.port != "" && entry.port != port {
+			continue
+		}
+
+		if host == entry.name || strings.HasSuffix(host, "."+entry.name) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,227 @@
+package gateway
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/botwayorg/railway-api/entity"
+)
+
+func TestConfigureTransportEmpty(t *testing.T) {
+	embedded := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, nil
+	})
+
+	for _, cfg := range []*entity.TransportConfig{nil, {}} {
+		g := NewClient(WithHTTPClient(&http.Client{Transport: embedded}))
+
+		if err := g.ConfigureTransport(cfg); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := g.transport.Base.(roundTripFunc); !ok {
+			t.Errorf("configuring %+v replaced the transport of the client with %T", cfg, g.transport.Base)
+		}
+	}
+}
+
+func TestNoProxyList(t *testing.T) {
+	tests := []struct {
+		list string
+		url  string
+		want bool
+	}{
+		{list: "", url: "https://backboard.railway.app", want: false},
+		{list: "", url: "http://localhost:8910", want: true},
+		{list: "", url: "http://127.0.0.1:8910", want: true},
+		{list: "", url: "http://[::1]/graphql", want: true},
+		{list: "*", url: "https://backboard.railway.app", want: true},
+		{list: "railway.app", url: "https://railway.app", want: true},
+		{list: "railway.app", url: "https://backboard.railway.app", want: true},
+		{list: "railway.app", url: "https://notrailway.app", want: false},
+		{list: ".railway.app", url: "https://backboard.railway.app", want: true},
+		{list: ".railway.app", url: "https://railway.app", want: true},
+		{list: "RAILWAY.app", url: "https://Backboard.Railway.App", want: true},
+		{list: "railway.app:443", url: "https://backboard.railway.app", want: true},
+		{list: "railway.app:443", url: "http://backboard.railway.app", want: false},
+		{list: "railway.app:8443", url: "https://backboard.railway.app:8443", want: true},
+		{list: "railway.app:8443", url: "https://backboard.railway.app", want: false},
+		{list: "10.0.0.0/8", url: "http://10.1.2.3", want: true},
+		{list: "10.0.0.0/8", url: "http://11.1.2.3", want: false},
+		{list: "192.168.1.10", url: "http://192.168.1.10:8080", want: true},
+		{list: " example.com , railway.app ", url: "https://backboard.railway.app", want: true},
+	}
+
+	for _, test := range tests {
+		u, err := url.Parse(test.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := parseNoProxy(test.list).matches(u); got != test.want {
+			t.Errorf("NO_PROXY=%q matches %s = %t, want %t", test.list, test.url, got, test.want)
+		}
+	}
+}
+
+func TestProxyFunc(t *testing.T) {
+	proxy, err := proxyFunc("proxy.internal:3128", "railway.app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "https://example.com", want: "http://proxy.internal:3128"},
+		{url: "https://backboard.railway.app/graphql", want: ""},
+		{url: "http://localhost:8910/graphql", want: ""},
+	}
+
+	for _, test := range tests {
+		req, _ := http.NewRequest(http.MethodGet, test.url, nil)
+
+		got, err := proxy(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if (got == nil && test.want != "") || (got != nil && got.String() != test.want) {
+			t.Errorf("proxy for %s = %v, want %q", test.url, got, test.want)
+		}
+	}
+
+	if _, err := proxyFunc("http://", ""); err == nil {
+		t.Error("a proxy URL without a host was accepted")
+	}
+}
+
+// writeCertificate writes a new self-signed certificate and its key into dir,
+// returning the paths of both files
+func writeCertificate(t *testing.T, dir, name string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+
+	return certFile, keyFile
+}
+
+func writePEM(t *testing.T, path, kind string, der []byte) {
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfigureTransportTLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if len(req.TLS.PeerCertificates) != 1 || req.TLS.PeerCertificates[0].Subject.CommonName != "cli" {
+			http.Error(w, "no client certificate", http.StatusForbidden)
+		}
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	// The handshake without the CA fails on purpose
+	server.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	writePEM(t, caFile, "CERTIFICATE", server.Certificate().Raw)
+
+	certFile, keyFile := writeCertificate(t, dir, "cli")
+
+	g := NewClient()
+	if err := g.ConfigureTransport(&entity.TransportConfig{CAFile: caFile, ClientCert: certFile, ClientKey: keyFile}); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := g.httpClient.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", res.StatusCode)
+	}
+
+	// Without the CA, the certificate of the server isn't trusted
+	g = NewClient()
+	if err := g.ConfigureTransport(&entity.TransportConfig{ClientCert: certFile, ClientKey: keyFile}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := g.httpClient.Get(server.URL); err == nil {
+		t.Error("the server was trusted without its CA")
+	}
+}
+
+func TestConfigureTransportInvalid(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCertificate(t, dir, "cli")
+
+	notPEM := filepath.Join(dir, "not.pem")
+	if err := ioutil.WriteFile(notPEM, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		cfg  entity.TransportConfig
+	}{
+		{name: "missing CA file", cfg: entity.TransportConfig{CAFile: filepath.Join(dir, "missing.pem")}},
+		{name: "CA file without certificates", cfg: entity.TransportConfig{CAFile: notPEM}},
+		{name: "certificate without key", cfg: entity.TransportConfig{ClientCert: certFile}},
+		{name: "key without certificate", cfg: entity.TransportConfig{ClientKey: keyFile}},
+		{name: "key of another certificate", cfg: entity.TransportConfig{ClientCert: certFile, ClientKey: notPEM}},
+		{name: "invalid proxy", cfg: entity.TransportConfig{ProxyURL: "http://"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := NewClient().ConfigureTransport(&test.cfg); err == nil {
+				t.Error("no error")
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package gateway
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -11,9 +12,9 @@ import (
 	"github.com/botwayorg/railway-api/entity"
 )
 
-func constructReq(ctx context.Context, req *entity.UpRequest) (*http.Request, error) {
-	url := fmt.Sprintf("%s/project/%s/environment/%s/up?serviceId=%s", GetHost(), req.ProjectID, req.EnvironmentID, req.ServiceID)
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, &req.Data)
+func constructReq(ctx context.Context, host string, req *entity.UpRequest) (*http.Request, error) {
+	url := fmt.Sprintf("%s/project/%s/environment/%s/up?serviceId=%s", host, req.ProjectID, req.EnvironmentID, req.ServiceID)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, req.Data)
 
 	if err != nil {
 		return nil, err
@@ -24,49 +25,207 @@ func constructReq(ctx context.Context, req *entity.UpRequest) (*http.Request, er
 	return httpReq, nil
 }
 
-func (g *Gateway) Up(ctx context.Context, req *entity.UpRequest) (*entity.UpResponse, error) {
-	httpReq, err := constructReq(ctx, req)
+// ErrIncrementalUploadUnsupported is returned by MissingBlobs when the
+// backboard can't build from a manifest, the project then has to be uploaded
+// as a whole with Up
+var ErrIncrementalUploadUnsupported = errors.New("incremental uploads are not supported by the server")
+
+// MissingBlobsError is returned by UpManifest when blobs of the manifest
+// aren't on the server, for instance because they expired since MissingBlobs
+type MissingBlobsError struct {
+	Hashes []string
+}
 
+func (e *MissingBlobsError) Error() string {
+	return fmt.Sprintf("the server is missing %d blobs of the upload", len(e.Hashes))
+}
+
+func (g *Gateway) Up(ctx context.Context, req *entity.UpRequest) (*entity.UpResponse, error) {
+	host, err := g.Host()
 	if err != nil {
 		return nil, err
 	}
 
-	err = g.authorize(httpReq.Header)
+	httpReq, err := constructReq(ctx, host, req)
+
 	if err != nil {
 		return nil, err
 	}
 
-	// The `up` command uses a custom HTTP Client so there is no timeout on the requests
-	client := &http.Client{
-		Transport: &AttachCommonHeadersTransport{},
+	var res entity.UpResponse
+
+	if _, err := g.sendUpRequest(httpReq, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// MissingBlobs returns which of the hashes the server has no blob for
+func (g *Gateway) MissingBlobs(ctx context.Context, req *entity.MissingBlobsRequest) ([]string, error) {
+	hashes := req.Hashes
+	if hashes == nil {
+		hashes = []string{}
 	}
 
-	resp, err := client.Do(httpReq)
+	httpReq, err := g.newUpJSONRequest(ctx, req.ProjectID, req.EnvironmentID, "up/blobs", "", map[string]interface{}{
+		"hashes": hashes,
+	})
+
 	if err != nil {
 		return nil, err
 	}
 
-	bodyBytes, err := ioutil.ReadAll(resp.Body)
+	var res struct {
+		Missing []string `json:"missing"`
+	}
+
+	status, err := g.sendUpRequest(httpReq, &res)
 	if err != nil {
+		// Whatever a server refuses the route with, short of the credentials,
+		// the whole archive may still be taken
+		if incrementalUnsupported(status) {
+			return nil, ErrIncrementalUploadUnsupported
+		}
+
 		return nil, err
 	}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
-		var res entity.UpErrorResponse
-		// Try decoding up's error response and fallback to sending body as text if decoding fails
+	return res.Missing, nil
+}
 
-		if err := json.Unmarshal(bodyBytes, &res); err != nil {
-			return nil, errors.New(string(bodyBytes))
-		}
+func incrementalUnsupported(status int) bool {
+	if status == http.StatusUnauthorized || status == http.StatusForbidden {
+		return false
+	}
+
+	return (status >= 400 && status < 500) || status == http.StatusNotImplemented
+}
+
+// UploadBlob sends the content of a file the server is missing
+func (g *Gateway) UploadBlob(ctx context.Context, req *entity.UploadBlobRequest) error {
+	host, err := g.Host()
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/project/%s/environment/%s/up/blobs/%s", host, req.ProjectID, req.EnvironmentID, req.SHA256)
+
+	httpReq, err := http.NewRequestWithContext(ctx, "PUT", url, req.Data)
+	if err != nil {
+		return err
+	}
+
+	httpReq.ContentLength = req.Size
+	httpReq.Header.Set("Content-Type", "application/octet-stream")
+
+	_, err = g.sendUpRequest(httpReq, nil)
+
+	return err
+}
 
-		return nil, errors.New(res.Message)
+// UpManifest deploys the files of the manifest, whose blobs must all be on
+// the server already. Otherwise it returns a *MissingBlobsError
+func (g *Gateway) UpManifest(ctx context.Context, req *entity.UpManifestRequest) (*entity.UpResponse, error) {
+	files := req.Files
+	if files == nil {
+		files = []entity.ManifestFile{}
+	}
+
+	httpReq, err := g.newUpJSONRequest(ctx, req.ProjectID, req.EnvironmentID, "up/manifest", req.ServiceID, map[string]interface{}{
+		"files": files,
+	})
+
+	if err != nil {
+		return nil, err
 	}
 
 	var res entity.UpResponse
 
-	if err := json.Unmarshal(bodyBytes, &res); err != nil {
+	if _, err := g.sendUpRequest(httpReq, &res); err != nil {
 		return nil, err
 	}
 
 	return &res, nil
 }
+
+func (g *Gateway) newUpJSONRequest(ctx context.Context, projectID, environmentID, route, serviceID string, body interface{}) (*http.Request, error) {
+	host, err := g.Host()
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/project/%s/environment/%s/%s?serviceId=%s", host, projectID, environmentID, route, serviceID)
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(encoded))
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	return httpReq, nil
+}
+
+// sendUpRequest sends a request to the upload endpoints, and decodes the JSON
+// response into res unless it is nil. It returns the status of the response
+func (g *Gateway) sendUpRequest(httpReq *http.Request, res interface{}) (int, error) {
+	err := g.authorize(httpReq.Header)
+	if err != nil {
+		return 0, err
+	}
+
+	// Uploads aren't bound by the request timeout, only by the context
+	resp, err := g.httpClient.Do(httpReq)
+	if err != nil {
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+
+	bodyBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		var res struct {
+			entity.UpErrorResponse
+			Missing []string `json:"missing"`
+		}
+		// Try decoding up's error response and fallback to sending body as text if decoding fails
+		err := json.Unmarshal(bodyBytes, &res)
+
+		// Blobs that went missing are listed whether or not there is a message
+		if err == nil && resp.StatusCode == http.StatusConflict && len(res.Missing) > 0 {
+			return resp.StatusCode, &MissingBlobsError{Hashes: res.Missing}
+		}
+
+		if err != nil || res.Message == "" {
+			return resp.StatusCode, newRequestError(resp, nil, string(bodyBytes))
+		}
+
+		reqID := res.RequestID
+		if reqID == "" {
+			reqID = requestID(resp.Header)
+		}
+
+		return resp.StatusCode, classifyRequestError(RequestError{
+			StatusCode: resp.StatusCode,
+			RequestID:  reqID,
+			Message:    res.Message,
+			RetryAfter: retryAfter(resp.Header),
+		})
+	}
+
+	if res == nil {
+		return resp.StatusCode, nil
+	}
+
+	return resp.StatusCode, json.Unmarshal(bodyBytes, res)
+}
@@ -0,0 +1,63 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/botwayorg/railway-api/entity"
+)
+
+func TestUpManifestErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		missing []string
+		want    string
+	}{
+		{name: "missing blobs", status: http.StatusConflict, body: `{"message": "2 blobs are missing", "missing": ["a", "b"]}`, missing: []string{"a", "b"}},
+		{name: "missing blobs without a message", status: http.StatusConflict, body: `{"missing": ["a"]}`, missing: []string{"a"}},
+		{name: "conflict without missing blobs", status: http.StatusConflict, body: `{"message": "a deployment is running"}`, want: "*gateway.RequestError"},
+		{name: "message", status: http.StatusBadRequest, body: `{"message": "invalid manifest"}`, want: "*gateway.ValidationError"},
+		{name: "text", status: http.StatusBadGateway, body: "bad gateway", want: "*gateway.ServerError"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewClient(WithHost("http://backboard.test"), WithToken("token"), WithHTTPClient(&http.Client{
+				Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+					return &http.Response{
+						StatusCode: test.status,
+						Header:     http.Header{},
+						Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+						Request:    req,
+					}, nil
+				}),
+			}))
+
+			_, err := g.UpManifest(context.Background(), &entity.UpManifestRequest{ProjectID: "p", EnvironmentID: "e"})
+			if err == nil {
+				t.Fatal("no error")
+			}
+
+			var missingErr *MissingBlobsError
+			if test.missing != nil {
+				if !errors.As(err, &missingErr) || !reflect.DeepEqual(missingErr.Hashes, test.missing) {
+					t.Fatalf("err = %#v, want the missing blobs %v", err, test.missing)
+				}
+
+				return
+			}
+
+			if got := fmt.Sprintf("%T", err); got != test.want {
+				t.Errorf("got %s (%s), want %s", got, err, test.want)
+			}
+		})
+	}
+}
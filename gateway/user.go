@@ -6,27 +6,57 @@ import (
 	"github.com/botwayorg/railway-api/entity"
 )
 
-func (g *Gateway) GetUser(ctx context.Context) (*entity.User, error) {
-	gqlReq, err := g.NewRequestWithAuth(`
-		query {
-			me {
-				id,
-				email,
-				name,
-				has2FA
-			}
+type userResult struct {
+	User *entity.User `json:"me"`
+}
+
+var userQuery = operation[struct{}, userResult]("me", `
+	query {
+		me {
+			id,
+			email,
+			name,
+			has2FA
 		}
-	`)
+	}
+`)
+
+type createLoginSessionResult struct {
+	Code string `json:"createLoginSession"`
+}
+
+var createLoginSessionMutation = operation[struct{}, createLoginSessionResult]("createLoginSession", `
+	mutation { createLoginSession }
+`)
+
+type consumeLoginSessionVars struct {
+	Code string `json:"code"`
+}
+
+type consumeLoginSessionResult struct {
+	Token string `json:"consumeLoginSession"`
+}
+
+var consumeLoginSessionMutation = operation[consumeLoginSessionVars, consumeLoginSessionResult]("consumeLoginSession", `
+	mutation($code: String!) {
+		consumeLoginSession(code: $code)
+	}
+`)
+
+var logoutMutation = operation[struct{}, struct{}]("logout", `
+	mutation { logout }
+`)
+
+func (g *Gateway) GetUser(ctx context.Context) (*entity.User, error) {
+	gqlReq, err := g.NewRequestWithAuth(userQuery.Document)
 
 	if err != nil {
 		return nil, err
 	}
 
-	var resp struct {
-		User *entity.User `json:"me"`
-	}
+	resp, err := runOperation(ctx, gqlReq, userQuery, struct{}{})
 
-	if err := gqlReq.Run(ctx, &resp); err != nil {
+	if err != nil {
 		return nil, err
 	}
 
@@ -34,13 +64,11 @@ func (g *Gateway) GetUser(ctx context.Context) (*entity.User, error) {
 }
 
 func (g *Gateway) CreateLoginSession(ctx context.Context) (string, error) {
-	gqlReq := g.NewRequestWithoutAuth(`mutation { createLoginSession } `)
+	gqlReq := g.NewRequestWithoutAuth(createLoginSessionMutation.Document)
 
-	var resp struct {
-		Code string `json:"createLoginSession"`
-	}
+	resp, err := runOperation(ctx, gqlReq, createLoginSessionMutation, struct{}{})
 
-	if err := gqlReq.Run(ctx, &resp); err != nil {
+	if err != nil {
 		return "", err
 	}
 
@@ -48,19 +76,11 @@ func (g *Gateway) CreateLoginSession(ctx context.Context) (string, error) {
 }
 
 func (g *Gateway) ConsumeLoginSession(ctx context.Context, code string) (string, error) {
-	gqlReq := g.NewRequestWithoutAuth(`
-		mutation($code: String!) { 
-			consumeLoginSession(code: $code) 
-		}
-	`)
-
-	gqlReq.Var("code", code)
+	gqlReq := g.NewRequestWithoutAuth(consumeLoginSessionMutation.Document)
 
-	var resp struct {
-		Token string `json:"consumeLoginSession"`
-	}
+	resp, err := runOperation(ctx, gqlReq, consumeLoginSessionMutation, consumeLoginSessionVars{Code: code})
 
-	if err := gqlReq.Run(ctx, &resp); err != nil {
+	if err != nil {
 		return "", err
 	}
 
@@ -68,15 +88,13 @@ func (g *Gateway) ConsumeLoginSession(ctx context.Context, code string) (string,
 }
 
 func (g *Gateway) Logout(ctx context.Context) error {
-	gqlReq, err := g.NewRequestWithAuth(`mutation { logout }`)
+	gqlReq, err := g.NewRequestWithAuth(logoutMutation.Document)
 
 	if err != nil {
 		return err
 	}
 
-	if err := gqlReq.Run(ctx, nil); err != nil {
-		return err
-	}
+	_, err = runOperation(ctx, gqlReq, logoutMutation, struct{}{})
 
-	return nil
+	return err
 }
@@ -7,27 +7,33 @@ import (
 	"github.com/botwayorg/railway-api/errors"
 )
 
-func (g *Gateway) GetWorkflowStatus(ctx context.Context, workflowID string) (entity.WorkflowStatus, error) {
-	gqlReq, err := g.NewRequestWithAuth(`
-		query($workflowId: String!) {
-			getWorkflowStatus(workflowId: $workflowId) {
-				status
-			}
+type workflowStatusVars struct {
+	WorkflowID string `json:"workflowId"`
+}
+
+type workflowStatusResult struct {
+	WorkflowStatus *entity.WorkflowStatusResponse `json:"getWorkflowStatus"`
+}
+
+var workflowStatusQuery = operation[workflowStatusVars, workflowStatusResult]("getWorkflowStatus", `
+	query($workflowId: String!) {
+		getWorkflowStatus(workflowId: $workflowId) {
+			status
 		}
-	`)
+	}
+`)
+
+func (g *Gateway) GetWorkflowStatus(ctx context.Context, workflowID string) (entity.WorkflowStatus, error) {
+	gqlReq, err := g.NewRequestWithAuth(workflowStatusQuery.Document)
 
 	if err != nil {
 		return "", err
 	}
 
-	gqlReq.Var("workflowId", workflowID)
+	resp, err := runOperation(ctx, gqlReq, workflowStatusQuery, workflowStatusVars{WorkflowID: workflowID})
 
-	var resp struct {
-		WorkflowStatus *entity.WorkflowStatusResponse `json:"getWorkflowStatus"`
-	}
-
-	if err := gqlReq.Run(ctx, &resp); err != nil {
-		return "", errors.ProjectCreateFailed
+	if err != nil {
+		return "", withFallback(err, errors.ProjectCreateFailed)
 	}
 
 	return resp.WorkflowStatus.Status, nil
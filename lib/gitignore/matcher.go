@@ -0,0 +1,261 @@
+// Package gitignore decides which files of a directory are ignored, following
+// the rules of git: ignore files in every directory, with deeper ones taking
+// precedence, then $GIT_DIR/info/exclude, then core.excludesFile.
+package gitignore
+
+import (
+	"errors"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Matcher tells whether paths below a root directory are ignored. When the
+// root is inside a git repository, the ignore files of its parents up to the
+// top of the repository apply too, like the excludes of the repository and of
+// the user.
+//
+// Like git, a Matcher doesn't look at the parents of a path: once a directory
+// is ignored, nothing inside it can be included again, so walkers shouldn't
+// descend into it
+type Matcher struct {
+	// root is the directory given to New
+	root string
+	// top is the absolute path patterns are relative to, the top of the
+	// repository or the root itself
+	top string
+	// prefix is the root relative to top, with a trailing slash
+	prefix string
+	// files are the names of the ignore files of every directory, in
+	// increasing precedence
+	files []string
+	// global are the patterns that apply to every directory, in increasing
+	// precedence
+	global []*Pattern
+	// dirs caches the patterns of the ignore files of each directory,
+	// relative to top
+	dirs map[string][]*Pattern
+}
+
+// New returns a Matcher for paths below root, which reads files, like
+// ".gitignore", in every directory. defaults are patterns with a lower
+// precedence than any file, which ignore files can override
+func New(root string, files []string, defaults []string) (*Matcher, error) {
+	return NewInProject("", root, files, defaults)
+}
+
+// NewInProject is like New for a root inside a project directory, whose
+// ignore files down to the root apply like those of a repository when the
+// project isn't in one
+func NewInProject(project, root string, files []string, defaults []string) (*Matcher, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Matcher{
+		root:  root,
+		top:   absRoot,
+		files: files,
+		dirs:  make(map[string][]*Pattern),
+	}
+
+	m.global = ParsePatterns([]byte(strings.Join(defaults, "\n")), DefaultSource, "")
+
+	repo, err := findRepository(absRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	// Outside of a repository, there's nothing more than the ignore files
+	if repo == nil {
+		if project != "" {
+			absProject, err := filepath.Abs(project)
+			if err != nil {
+				return nil, err
+			}
+
+			m.setTop(absProject, absRoot)
+		}
+
+		return m, nil
+	}
+
+	m.setTop(repo.top, absRoot)
+
+	for _, file := range []string{repo.excludesFile(), filepath.Join(repo.commonDir, "info", "exclude")} {
+		if file == "" {
+			continue
+		}
+
+		patterns, err := readPatterns(file, file, "")
+		if err != nil {
+			return nil, err
+		}
+
+		m.global = append(m.global, patterns...)
+	}
+
+	return m, nil
+}
+
+// setTop makes patterns relative to top, when it holds the root
+func (m *Matcher) setTop(top, absRoot string) {
+	rel, err := filepath.Rel(top, absRoot)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return
+	}
+
+	m.top = top
+
+	if rel != "." {
+		m.prefix = filepath.ToSlash(rel) + "/"
+	}
+}
+
+// DefaultSource is the Source of the default patterns given to New
+const DefaultSource = "default"
+
+// GitDir is reported by Walk for the .git entries, which git never looks into
+var GitDir = &Pattern{Source: "git", Text: ".git"}
+
+// Walk calls fn with every file and directory below the root in lexical order,
+// along with the pattern that ignores it, or nil. It doesn't descend into
+// ignored directories
+func (m *Matcher) Walk(fn func(path, name string, d fs.DirEntry, ignoredBy *Pattern) error) error {
+	return filepath.WalkDir(m.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if path == m.root {
+			return nil
+		}
+
+		rel, err := filepath.Rel(m.root, path)
+		if err != nil {
+			return err
+		}
+
+		name := filepath.ToSlash(rel)
+
+		ignoredBy := GitDir
+		if d.Name() != ".git" {
+			if ignoredBy, err = m.Match(name, d.IsDir()); err != nil {
+				return err
+			}
+		}
+
+		if err := fn(path, name, d, ignoredBy); err != nil {
+			return err
+		}
+
+		if ignoredBy != nil && d.IsDir() {
+			return filepath.SkipDir
+		}
+
+		return nil
+	})
+}
+
+// Match returns the pattern that ignores the path, relative to the root with
+// slashes, or nil when it isn't ignored
+func (m *Matcher) Match(name string, isDir bool) (*Pattern, error) {
+	pattern, err := m.Explain(name, isDir)
+	if err != nil || pattern == nil || pattern.negate {
+		return nil, err
+	}
+
+	return pattern, nil
+}
+
+// Explain returns the last pattern matching the path, which decides whether
+// it is ignored: it is unless the pattern is negated. It returns nil when no
+// pattern matches
+func (m *Matcher) Explain(name string, isDir bool) (*Pattern, error) {
+	name = m.prefix + name
+
+	patterns := append([]*Pattern{}, m.global...)
+
+	// The ignore files of the directories of the path, from the top down
+	for dir, rest := "", name; ; {
+		dirPatterns, err := m.dirPatterns(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		patterns = append(patterns, dirPatterns...)
+
+		i := strings.Index(rest, "/")
+		if i < 0 {
+			break
+		}
+
+		dir, rest = dir+rest[:i+1], rest[i+1:]
+	}
+
+	// The last matching pattern decides
+	for i := len(patterns) - 1; i >= 0; i-- {
+		if patterns[i].Matches(name, isDir) {
+			return patterns[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// dirPatterns returns the patterns of the ignore files in dir, relative to
+// top with a trailing slash
+func (m *Matcher) dirPatterns(dir string) ([]*Pattern, error) {
+	if patterns, ok := m.dirs[dir]; ok {
+		return patterns, nil
+	}
+
+	var patterns []*Pattern
+
+	for _, file := range m.files {
+		// Name sources relative to the root, which is what users look at
+		source := path.Join(dir, file)
+		if m.prefix != "" {
+			rel, err := filepath.Rel(filepath.FromSlash(m.prefix), filepath.FromSlash(source))
+			if err != nil {
+				return nil, err
+			}
+
+			source = filepath.ToSlash(rel)
+		}
+
+		filePatterns, err := readPatterns(filepath.Join(m.top, filepath.FromSlash(dir), file), source, dir)
+		if err != nil {
+			return nil, err
+		}
+
+		patterns = append(patterns, filePatterns...)
+	}
+
+	m.dirs[dir] = patterns
+
+	return patterns, nil
+}
+
+// readPatterns reads the patterns of an ignore file, which may not exist
+func readPatterns(file, source, base string) ([]*Pattern, error) {
+	content, err := ioutil.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		// Like git, skip ignore files that are directories
+		if info, statErr := os.Stat(file); statErr == nil && info.IsDir() {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return ParsePatterns(content, source, base), nil
+}
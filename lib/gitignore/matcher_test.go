@@ -0,0 +1,242 @@
+package gitignore
+
+import (
+	"bytes"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// ignoreCase is a tree of ignore files, and the files to check in it
+type ignoreCase struct {
+	name  string
+	line  int
+	files map[string]string
+	// paths maps the files to check to whether they are ignored
+	paths map[string]bool
+}
+
+// TestIgnores builds the trees of testdata/ignores.txt and checks what a walk
+// leaves out. Where git is installed, it checks that git agrees with every
+// expectation too, so that uploads keep leaving out exactly what git does
+func TestIgnores(t *testing.T) {
+	cases, err := parseIgnoreCases(filepath.Join("testdata", "ignores.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = exec.LookPath("git")
+	withGit := err == nil && !testing.Short()
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			c.check(t, withGit)
+		})
+	}
+
+	if !withGit {
+		t.Log("git isn't installed, or -short is set: the expectations weren't checked against git")
+	}
+}
+
+func parseIgnoreCases(file string) ([]*ignoreCase, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var cases []*ignoreCase
+	var current *ignoreCase
+
+	section := ""
+
+	for i, line := range strings.Split(strings.TrimSuffix(string(content), "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "=== "):
+			current = &ignoreCase{
+				name:  strings.TrimPrefix(line, "=== "),
+				line:  i + 1,
+				files: make(map[string]string),
+				paths: make(map[string]bool),
+			}
+
+			cases = append(cases, current)
+			section = ""
+
+		case current == nil:
+			// Comments before the first case
+
+		case strings.HasPrefix(line, "-- "):
+			section = strings.TrimPrefix(line, "-- ")
+
+			if section != "paths" {
+				current.files[section] = ""
+			}
+
+		case section == "paths":
+			if line == "" {
+				continue
+			}
+
+			path := strings.TrimSuffix(line, " ignored")
+			current.paths[path] = path != line
+
+		case section != "":
+			current.files[section] += line + "\n"
+		}
+	}
+
+	return cases, nil
+}
+
+// check builds the tree of the case in a temporary directory, and compares
+// what a walk leaves out with the expectations, and with git when asked to
+func (c *ignoreCase) check(t *testing.T, withGit bool) {
+	dir := t.TempDir()
+	home, repo := filepath.Join(dir, "home"), filepath.Join(dir, "repo")
+
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Global excludes are looked for in the home directory
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	if withGit {
+		runGit(t, repo, home, "init", "--quiet")
+	}
+
+	for name, content := range c.files {
+		path := filepath.Join(repo, filepath.FromSlash(name))
+		if strings.HasPrefix(name, "~/") {
+			path = filepath.Join(home, filepath.FromSlash(name[2:]))
+		}
+
+		writeFile(t, path, content)
+	}
+
+	for name := range c.paths {
+		writeFile(t, filepath.Join(repo, filepath.FromSlash(name)), "")
+	}
+
+	included := walk(t, repo)
+
+	var gitIncluded map[string]bool
+	if withGit {
+		gitIncluded = gitUntracked(t, repo, home)
+	}
+
+	names := make([]string, 0, len(c.paths))
+	for name := range c.paths {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		ignored := c.paths[name]
+
+		if included[name] == ignored {
+			t.Errorf("ignores.txt:%d: %s is %s, expected %s", c.line, name, describe(!included[name]), describe(ignored))
+		}
+
+		if gitIncluded != nil && gitIncluded[name] == ignored {
+			t.Errorf("ignores.txt:%d: git says %s is %s, expected %s", c.line, name, describe(!gitIncluded[name]), describe(ignored))
+		}
+	}
+}
+
+func describe(ignored bool) string {
+	if ignored {
+		return "ignored"
+	}
+
+	return "included"
+}
+
+// walk returns the files of root that aren't ignored
+func walk(t *testing.T, root string) map[string]bool {
+	matcher, err := New(root, []string{".gitignore"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	included := make(map[string]bool)
+
+	err = matcher.Walk(func(_, name string, d fs.DirEntry, ignoredBy *Pattern) error {
+		if ignoredBy == nil && !d.IsDir() {
+			included[name] = true
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return included
+}
+
+// gitUntracked returns the files git would add to the repository
+func gitUntracked(t *testing.T, repo, home string) map[string]bool {
+	cmd := exec.Command("git", "ls-files", "--others", "--exclude-standard", "-z")
+	cmd.Dir = repo
+	cmd.Env = gitEnv(home)
+
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	included := make(map[string]bool)
+
+	for _, name := range bytes.Split(out, []byte{0}) {
+		if len(name) > 0 {
+			included[string(name)] = true
+		}
+	}
+
+	return included
+}
+
+func runGit(t *testing.T, repo, home string, args ...string) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repo
+	cmd.Env = gitEnv(home)
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %s: %s", strings.Join(args, " "), err, out)
+	}
+}
+
+// gitEnv keeps the configuration of the machine out of the way
+func gitEnv(home string) []string {
+	var env []string
+
+	for _, v := range os.Environ() {
+		if !strings.HasPrefix(v, "HOME=") && !strings.HasPrefix(v, "XDG_CONFIG_HOME=") && !strings.HasPrefix(v, "GIT_") {
+			env = append(env, v)
+		}
+	}
+
+	return append(env, "HOME="+home, "GIT_CONFIG_NOSYSTEM=1")
+}
+
+func writeFile(t *testing.T, path, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,138 @@
+package gitignore
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Pattern is a rule of an ignore file, see gitignore(5)
+type Pattern struct {
+	// Source names where the pattern comes from, usually an ignore file, and
+	// Line is its 1-based line there. Text is the line as written
+	Source string
+	Line   int
+	Text   string
+
+	// base is the directory the pattern applies to, relative to the top of
+	// the Matcher with a trailing slash, or "" for the top
+	base   string
+	glob   string
+	negate bool
+	// dirOnly is set for patterns ending with a slash
+	dirOnly bool
+	// basename is set for patterns without a slash, which match the name of
+	// a file or directory at any depth
+	basename bool
+}
+
+// Negated tells whether the pattern starts with "!", so that it includes
+// again what previous patterns ignored
+func (p *Pattern) Negated() bool {
+	return p.negate
+}
+
+// ParsePatterns reads the patterns of an ignore file the way git does. base
+// is the directory of the file, relative to the top of the Matcher it is used
+// with, with slashes
+func ParsePatterns(content []byte, source, base string) []*Pattern {
+	if base != "" && !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+
+	// A byte order mark is skipped
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+
+	var patterns []*Pattern
+
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		pattern := &Pattern{
+			Source: source,
+			Line:   i + 1,
+			Text:   line,
+			base:   base,
+		}
+
+		glob := trimTrailingSpaces(line)
+
+		if strings.HasPrefix(glob, "!") {
+			pattern.negate = true
+			glob = glob[1:]
+		}
+
+		if strings.HasSuffix(glob, "/") {
+			pattern.dirOnly = true
+			glob = glob[:len(glob)-1]
+		}
+
+		if glob == "" {
+			continue
+		}
+
+		pattern.basename = !strings.Contains(glob, "/")
+		// A leading slash only anchors the pattern, which any slash does
+		pattern.glob = strings.TrimPrefix(glob, "/")
+
+		patterns = append(patterns, pattern)
+	}
+
+	return patterns
+}
+
+// trimTrailingSpaces removes the spaces ending line, unless they are escaped
+// with a backslash
+func trimTrailingSpaces(line string) string {
+	lastSpace := -1
+
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case ' ':
+			if lastSpace < 0 {
+				lastSpace = i
+			}
+
+		case '\\':
+			i++
+			if i == len(line) {
+				return line
+			}
+
+			lastSpace = -1
+
+		default:
+			lastSpace = -1
+		}
+	}
+
+	if lastSpace >= 0 {
+		return line[:lastSpace]
+	}
+
+	return line
+}
+
+// Matches tells whether the pattern matches path, regardless of negation. The
+// path is relative to the top of the Matcher the pattern comes from, or to
+// the directory its patterns were parsed for
+func (p *Pattern) Matches(path string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+
+	if !strings.HasPrefix(path, p.base) {
+		return false
+	}
+
+	path = path[len(p.base):]
+
+	if p.basename {
+		return Wildmatch(p.glob, path[strings.LastIndex(path, "/")+1:], false)
+	}
+
+	return Wildmatch(p.glob, path, true)
+}
@@ -0,0 +1,182 @@
+package gitignore
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// repository locates the files of a git repository that hold excludes
+type repository struct {
+	// top is the directory holding .git
+	top string
+	// gitDir is where .git points, and commonDir the directory shared by
+	// its worktrees, which holds info/exclude and the config
+	gitDir    string
+	commonDir string
+}
+
+// findRepository returns the repository dir is in, or nil if there's none
+func findRepository(dir string) (*repository, error) {
+	for {
+		dotGit := filepath.Join(dir, ".git")
+
+		info, err := os.Stat(dotGit)
+		if err == nil {
+			repo := &repository{top: dir, gitDir: dotGit}
+
+			// Worktrees and submodules have a file pointing at the real
+			// git directory
+			if !info.IsDir() {
+				if repo.gitDir, err = readGitFile(dotGit); err != nil {
+					return nil, err
+				}
+			}
+
+			repo.commonDir = repo.gitDir
+
+			if commonDir, err := ioutil.ReadFile(filepath.Join(repo.gitDir, "commondir")); err == nil {
+				repo.commonDir = resolve(repo.gitDir, strings.TrimSpace(string(commonDir)))
+			}
+
+			return repo, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+
+		dir = parent
+	}
+}
+
+// readGitFile reads the "gitdir: <path>" line of a .git file
+func readGitFile(file string) (string, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+
+	line := strings.TrimSpace(string(content))
+	if !strings.HasPrefix(line, "gitdir: ") {
+		return "", errors.New(file + " doesn't point at a git directory")
+	}
+
+	return resolve(filepath.Dir(file), strings.TrimPrefix(line, "gitdir: ")), nil
+}
+
+func resolve(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(dir, path)
+}
+
+// excludesFile returns the path of core.excludesFile, or of its default when
+// it isn't configured. Later config files override earlier ones, like git
+// reads them
+func (r *repository) excludesFile() string {
+	home, _ := os.UserHomeDir()
+
+	xdgConfig := os.Getenv("XDG_CONFIG_HOME")
+	if xdgConfig == "" && home != "" {
+		xdgConfig = filepath.Join(home, ".config")
+	}
+
+	excludesFile := ""
+	if xdgConfig != "" {
+		excludesFile = filepath.Join(xdgConfig, "git", "ignore")
+	}
+
+	configs := []string{"/etc/gitconfig"}
+
+	if xdgConfig != "" {
+		configs = append(configs, filepath.Join(xdgConfig, "git", "config"))
+	}
+
+	if home != "" {
+		configs = append(configs, filepath.Join(home, ".gitconfig"))
+	}
+
+	configs = append(configs, filepath.Join(r.commonDir, "config"))
+
+	for _, config := range configs {
+		if value, ok := readConfigValue(config, "core", "excludesfile"); ok {
+			excludesFile = value
+		}
+	}
+
+	if strings.HasPrefix(excludesFile, "~/") && home != "" {
+		excludesFile = filepath.Join(home, excludesFile[2:])
+	}
+
+	return excludesFile
+}
+
+// readConfigValue returns the last value of key in section of a git config
+// file. It understands the plain "key = value" lines excludes are set with,
+// not includes or subsections
+func readConfigValue(file, section, key string) (string, bool) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", false
+	}
+
+	value, found := "", false
+	inSection := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if line[0] == '[' {
+			end := strings.Index(line, "]")
+			if end < 0 {
+				continue
+			}
+
+			inSection = strings.EqualFold(strings.TrimSpace(line[1:end]), section)
+			line = strings.TrimSpace(line[end+1:])
+
+			if line == "" {
+				continue
+			}
+		}
+
+		if !inSection {
+			continue
+		}
+
+		name, val, hasValue := strings.Cut(line, "=")
+		if !hasValue || !strings.EqualFold(strings.TrimSpace(name), key) {
+			continue
+		}
+
+		val = strings.TrimSpace(val)
+
+		if strings.HasPrefix(val, `"`) {
+			if end := strings.Index(val[1:], `"`); end >= 0 {
+				val = val[1 : end+1]
+			}
+		} else if i := strings.IndexAny(val, "#;"); i >= 0 {
+			val = strings.TrimSpace(val[:i])
+		}
+
+		value, found = val, true
+	}
+
+	return value, found
+}
@@ -0,0 +1,331 @@
+package gitignore
+
+// This is a port of wildmatch.c from git, which matches the patterns of
+// ignore files. Keeping the structure of the original makes it possible to
+// compare both when git changes.
+
+const (
+	wmMatch           = 0
+	wmNoMatch         = 1
+	wmAbortAll        = -1
+	wmAbortToStarStar = -2
+)
+
+// Wildmatch tells whether text matches the glob pattern. With pathname set,
+// wildcards don't match slashes, except for "**" between slashes, like in
+// git's WM_PATHNAME mode used by ignore files
+func Wildmatch(pattern, text string, pathname bool) bool {
+	return dowild([]byte(pattern), []byte(text), pathname) == wmMatch
+}
+
+// at returns the byte at i, or 0 past the end, like reading the terminating
+// NUL of a C string
+func at(s []byte, i int) byte {
+	if i < len(s) {
+		return s[i]
+	}
+
+	return 0
+}
+
+func isGlobSpecial(c byte) bool {
+	return c == '*' || c == '?' || c == '[' || c == '\\'
+}
+
+func dowild(pattern, text []byte, pathname bool) int {
+	p, t := 0, 0
+
+	for ; at(pattern, p) != 0; t, p = t+1, p+1 {
+		pCh := at(pattern, p)
+		tCh := at(text, t)
+
+		if tCh == 0 && pCh != '*' {
+			return wmAbortAll
+		}
+
+		switch pCh {
+		case '\\':
+			// Literal match with the following character, a trailing
+			// backslash never matches
+			p++
+			pCh = at(pattern, p)
+
+			if tCh != pCh {
+				return wmNoMatch
+			}
+
+			continue
+
+		case '?':
+			// Match anything but '/'
+			if pathname && tCh == '/' {
+				return wmNoMatch
+			}
+
+			continue
+
+		case '*':
+			var matchSlash bool
+
+			p++
+			if at(pattern, p) == '*' {
+				prevP := p - 2
+				for p++; at(pattern, p) == '*'; p++ {
+				}
+
+				if !pathname {
+					// Without pathname, '*' is '**'
+					matchSlash = true
+				} else if (prevP < 0 || pattern[prevP] == '/') &&
+					(at(pattern, p) == 0 || at(pattern, p) == '/' ||
+						(at(pattern, p) == '\\' && at(pattern, p+1) == '/')) {
+					// Assuming we already match "foo/" and are at "**/",
+					// try matching nothing, so that foo/**/bar matches
+					// both foo/bar and foo/a/bar
+					if at(pattern, p) == '/' && dowild(pattern[p+1:], text[t:], pathname) == wmMatch {
+						return wmMatch
+					}
+
+					matchSlash = true
+				} else {
+					matchSlash = false
+				}
+			} else {
+				// Without pathname, '*' is '**'
+				matchSlash = !pathname
+			}
+
+			if at(pattern, p) == 0 {
+				// A trailing "**" matches everything, a trailing "*" only
+				// if there are no more slashes
+				if !matchSlash {
+					for _, c := range text[t:] {
+						if c == '/' {
+							return wmNoMatch
+						}
+					}
+				}
+
+				return wmMatch
+			} else if !matchSlash && at(pattern, p) == '/' {
+				// A single asterisk followed by a slash matches the next
+				// directory
+				slash := -1
+
+				for i := t; i < len(text); i++ {
+					if text[i] == '/' {
+						slash = i
+						break
+					}
+				}
+
+				if slash < 0 {
+					return wmNoMatch
+				}
+
+				// The slash is consumed by the loop
+				t = slash
+				continue
+			}
+
+			for {
+				if tCh == 0 {
+					break
+				}
+
+				// Advance faster when the asterisk is followed by a
+				// literal, the text before it must belong to the asterisk.
+				// Without matchSlash, don't look past the first slash
+				if !isGlobSpecial(at(pattern, p)) {
+					pCh = at(pattern, p)
+
+					for {
+						tCh = at(text, t)
+						if tCh == 0 || (!matchSlash && tCh == '/') || tCh == pCh {
+							break
+						}
+
+						t++
+					}
+
+					if tCh != pCh {
+						return wmNoMatch
+					}
+				}
+
+				if matched := dowild(pattern[p:], text[t:], pathname); matched != wmNoMatch {
+					if !matchSlash || matched != wmAbortToStarStar {
+						return matched
+					}
+				} else if !matchSlash && tCh == '/' {
+					return wmAbortToStarStar
+				}
+
+				t++
+				tCh = at(text, t)
+			}
+
+			return wmAbortAll
+
+		case '[':
+			p++
+			pCh = at(pattern, p)
+
+			if pCh == '^' {
+				pCh = '!'
+			}
+
+			negated := pCh == '!'
+			if negated {
+				// Inverted character class
+				p++
+				pCh = at(pattern, p)
+			}
+
+			var prevCh byte
+			matched := false
+
+			for {
+				if pCh == 0 {
+					return wmAbortAll
+				}
+
+				if pCh == '\\' {
+					p++
+					pCh = at(pattern, p)
+
+					if pCh == 0 {
+						return wmAbortAll
+					}
+
+					if tCh == pCh {
+						matched = true
+					}
+				} else if pCh == '-' && prevCh != 0 && at(pattern, p+1) != 0 && at(pattern, p+1) != ']' {
+					p++
+					pCh = at(pattern, p)
+
+					if pCh == '\\' {
+						p++
+						pCh = at(pattern, p)
+
+						if pCh == 0 {
+							return wmAbortAll
+						}
+					}
+
+					if tCh <= pCh && tCh >= prevCh {
+						matched = true
+					}
+
+					// Makes prevCh 0
+					pCh = 0
+				} else if pCh == '[' && at(pattern, p+1) == ':' {
+					p += 2
+					s := p
+
+					for pCh = at(pattern, p); pCh != 0 && pCh != ']'; pCh = at(pattern, p) {
+						p++
+					}
+
+					if pCh == 0 {
+						return wmAbortAll
+					}
+
+					i := p - s - 1
+					if i < 0 || pattern[p-1] != ':' {
+						// No ":]", treat it like a normal set
+						p = s - 2
+						pCh = '['
+
+						if tCh == pCh {
+							matched = true
+						}
+					} else {
+						class, ok := charClass(string(pattern[s:p-1]), tCh)
+						if !ok {
+							// Malformed [:class:]
+							return wmAbortAll
+						}
+
+						if class {
+							matched = true
+						}
+
+						// Makes prevCh 0
+						pCh = 0
+					}
+				} else if tCh == pCh {
+					matched = true
+				}
+
+				prevCh = pCh
+				p++
+				pCh = at(pattern, p)
+
+				if pCh == ']' {
+					break
+				}
+			}
+
+			if matched == negated || (pathname && tCh == '/') {
+				return wmNoMatch
+			}
+
+			continue
+
+		default:
+			if tCh != pCh {
+				return wmNoMatch
+			}
+
+			continue
+		}
+	}
+
+	if t < len(text) {
+		return wmNoMatch
+	}
+
+	return wmMatch
+}
+
+// charClass tells whether c is in the POSIX class named name, and whether
+// the class exists
+func charClass(name string, c byte) (bool, bool) {
+	isDigit := c >= '0' && c <= '9'
+	isUpper := c >= 'A' && c <= 'Z'
+	isLower := c >= 'a' && c <= 'z'
+	isAlpha := isUpper || isLower
+	isPunct := (c >= '!' && c <= '/') || (c >= ':' && c <= '@') || (c >= '[' && c <= '`') || (c >= '{' && c <= '~')
+
+	switch name {
+	case "alnum":
+		return isAlpha || isDigit, true
+	case "alpha":
+		return isAlpha, true
+	case "blank":
+		return c == ' ' || c == '\t', true
+	case "cntrl":
+		return c < ' ' || c == 0x7f, true
+	case "digit":
+		return isDigit, true
+	case "graph":
+		return c > ' ' && c < 0x7f, true
+	case "lower":
+		return isLower, true
+	case "print":
+		return c >= ' ' && c < 0x7f, true
+	case "punct":
+		return isPunct, true
+	case "space":
+		// git's isspace leaves out \v and \f
+		return c == ' ' || c == '\t' || c == '\n' || c == '\r', true
+	case "upper":
+		return isUpper, true
+	case "xdigit":
+		return isDigit || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F'), true
+	}
+
+	return false, false
+}
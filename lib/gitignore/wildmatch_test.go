@@ -0,0 +1,89 @@
+package gitignore
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestWildmatch runs the "match <glob> <pathmatch> <text> <pattern>" lines of
+// testdata/wildmatch.txt
+func TestWildmatch(t *testing.T) {
+	content, err := ioutil.ReadFile(filepath.Join("testdata", "wildmatch.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, line := range strings.Split(string(content), "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields, err := splitFields(line)
+		if err != nil || len(fields) != 5 || fields[0] != "match" {
+			t.Fatalf("wildmatch.txt:%d: expected match <glob> <pathmatch> <text> <pattern>", i+1)
+		}
+
+		text, pattern := fields[3], fields[4]
+
+		t.Run(fmt.Sprintf("%d", i+1), func(t *testing.T) {
+			for _, mode := range []struct {
+				expected string
+				pathname bool
+				name     string
+			}{{fields[1], true, "glob"}, {fields[2], false, "pathmatch"}} {
+				if got := Wildmatch(pattern, text, mode.pathname); got != (mode.expected == "1") {
+					t.Errorf("%s of %q against %q is %t, expected %s", mode.name, pattern, text, got, mode.expected)
+				}
+			}
+		})
+	}
+}
+
+// splitFields splits line like a shell, understanding single quotes and
+// backslashes outside of them
+func splitFields(line string) ([]string, error) {
+	var fields []string
+	var field strings.Builder
+
+	inField, quoted := false, false
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+
+		switch {
+		case quoted && c == '\'':
+			quoted = false
+		case quoted:
+			field.WriteByte(c)
+		case c == '\'':
+			quoted, inField = true, true
+		case c == '\\' && i+1 < len(line):
+			i++
+			field.WriteByte(line[i])
+			inField = true
+		case c == ' ' || c == '\t':
+			if inField {
+				fields = append(fields, field.String())
+				field.Reset()
+				inField = false
+			}
+		default:
+			field.WriteByte(c)
+			inField = true
+		}
+	}
+
+	if quoted {
+		return nil, errors.New("unterminated quote")
+	}
+
+	if inField {
+		fields = append(fields, field.String())
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,191 @@
+package gql
+
+import "strings"
+
+// TypeRef is a reference to a type, e.g. `[String!]!`
+type TypeRef struct {
+	Name    string
+	NonNull bool
+	Elem    *TypeRef
+}
+
+// Named returns the name of the type with all list and non-null wrappers removed
+func (t *TypeRef) Named() string {
+	if t.Elem != nil {
+		return t.Elem.Named()
+	}
+
+	return t.Name
+}
+
+func (t *TypeRef) String() string {
+	s := t.Name
+
+	if t.Elem != nil {
+		s = "[" + t.Elem.String() + "]"
+	}
+
+	if t.NonNull {
+		s += "!"
+	}
+
+	return s
+}
+
+// Value is an argument value: a variable, a literal, a list or an object
+type Value struct {
+	// Variable is the name of the variable, for variables
+	Variable string
+	// Literal is the raw text of scalar and enum literals
+	Literal string
+	// List holds the items of list values
+	List []*Value
+	// Fields holds the fields of object values, in order
+	Fields []*ObjectField
+	// IsList and IsObject tell empty lists and objects apart from literals
+	IsList   bool
+	IsObject bool
+	// IsNull is set for the `null` literal
+	IsNull bool
+}
+
+type ObjectField struct {
+	Name  string
+	Value *Value
+}
+
+type Argument struct {
+	Name  string
+	Value *Value
+}
+
+type Directive struct {
+	Name      string
+	Arguments []*Argument
+}
+
+// Selection is a *Field, a *FragmentSpread or an *InlineFragment
+type Selection interface {
+	isSelection()
+}
+
+type Field struct {
+	Alias      string
+	Name       string
+	Arguments  []*Argument
+	Directives []*Directive
+	Selections []Selection
+}
+
+// ResponseKey is the key the field is returned under
+func (f *Field) ResponseKey() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+
+	return f.Name
+}
+
+type FragmentSpread struct {
+	Name       string
+	Directives []*Directive
+}
+
+type InlineFragment struct {
+	TypeCondition string
+	Directives    []*Directive
+	Selections    []Selection
+}
+
+func (*Field) isSelection()          {}
+func (*FragmentSpread) isSelection() {}
+func (*InlineFragment) isSelection() {}
+
+type VariableDefinition struct {
+	Name    string
+	Type    *TypeRef
+	Default *Value
+}
+
+type OperationDefinition struct {
+	// Type is "query", "mutation" or "subscription"
+	Type       string
+	Name       string
+	Variables  []*VariableDefinition
+	Directives []*Directive
+	Selections []Selection
+}
+
+type FragmentDefinition struct {
+	Name          string
+	TypeCondition string
+	Directives    []*Directive
+	Selections    []Selection
+}
+
+// Document is a parsed executable document
+type Document struct {
+	Operations []*OperationDefinition
+	Fragments  map[string]*FragmentDefinition
+}
+
+// TypeKind is the kind of a type defined in a schema
+type TypeKind string
+
+const (
+	KindScalar      TypeKind = "SCALAR"
+	KindObject      TypeKind = "OBJECT"
+	KindInterface   TypeKind = "INTERFACE"
+	KindUnion       TypeKind = "UNION"
+	KindEnum        TypeKind = "ENUM"
+	KindInputObject TypeKind = "INPUT_OBJECT"
+)
+
+type InputValueDefinition struct {
+	Name    string
+	Type    *TypeRef
+	Default *Value
+}
+
+type FieldDefinition struct {
+	Name      string
+	Arguments map[string]*InputValueDefinition
+	Type      *TypeRef
+}
+
+type TypeDefinition struct {
+	Kind   TypeKind
+	Name   string
+	Fields map[string]*FieldDefinition
+	// InputFields holds the fields of input objects
+	InputFields map[string]*InputValueDefinition
+	EnumValues  map[string]bool
+	// Types holds the members of unions and the interfaces of objects
+	Types []string
+}
+
+// Schema is a parsed schema definition document
+type Schema struct {
+	Types        map[string]*TypeDefinition
+	Query        string
+	Mutation     string
+	Subscription string
+}
+
+// RootType returns the root type for an operation type
+func (s *Schema) RootType(operation string) *TypeDefinition {
+	name := ""
+
+	switch strings.ToLower(operation) {
+	case "query":
+		name = s.Query
+	case "mutation":
+		name = s.Mutation
+	case "subscription":
+		name = s.Subscription
+	}
+
+	return s.Types[name]
+}
+
+var builtinScalars = []string{"Int", "Float", "String", "Boolean", "ID"}
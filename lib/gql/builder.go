@@ -0,0 +1,470 @@
+package gql
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// Build renders an operation document from Go types. Every field of V
+// declares a variable and every field of R is selected, in struct order, so
+// the same types always produce byte-identical documents.
+//
+// Fields are named after their json tag. A `gql` tag describes the field the
+// way it is written in a selection set, with arguments, an alias and
+// directives:
+//
+//	Deployment *Deployment `json:"deploymentById" gql:"deploymentById(projectId: $projectId)"`
+//	Logs       string      `json:"logs" gql:"logs: buildLogs @include(if: $buildLogs)"`
+//
+// Other tag forms are:
+//
+//	gql:"-"                    never select the field
+//	gql:"meta,scalar"          select the field without subfields, e.g. for JSON scalars
+//	gql:"... on Type"          inline fragment, on an embedded struct
+//	gql:"...Name on Type"      named fragment, on an embedded struct
+//
+// On V, a `gql` tag sets the type of the variable, e.g. gql:"ID!". Without
+// one the type is inferred from the Go type; pointers, slices, arrays and
+// fields with omitempty declare nullable variables
+func Build[V, R any](operationType, name string) (string, error) {
+	b := &builder{
+		fragments:     make(map[string]*FragmentDefinition),
+		fragmentTypes: make(map[string]reflect.Type),
+	}
+
+	variables, err := b.variables(reflect.TypeOf((*V)(nil)).Elem())
+	if err != nil {
+		return "", err
+	}
+
+	selections, err := b.selections(reflect.TypeOf((*R)(nil)).Elem())
+	if err != nil {
+		return "", err
+	}
+
+	doc := &Document{
+		Operations: []*OperationDefinition{{
+			Type:       operationType,
+			Name:       name,
+			Variables:  variables,
+			Selections: selections,
+		}},
+		Fragments: b.fragments,
+	}
+
+	return Print(doc), nil
+}
+
+// MustBuild is like Build but panics if the types can't be rendered. It is
+// meant for package level operation declarations
+func MustBuild[V, R any](operationType, name string) string {
+	document, err := Build[V, R](operationType, name)
+	if err != nil {
+		panic(err)
+	}
+
+	return document
+}
+
+type builder struct {
+	fragments     map[string]*FragmentDefinition
+	fragmentTypes map[string]reflect.Type
+	// path holds the struct types being built, to catch recursive types
+	path []reflect.Type
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+func (b *builder) variables(typ reflect.Type) ([]*VariableDefinition, error) {
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("variables must be a struct, not %s", typ)
+	}
+
+	var variables []*VariableDefinition
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+
+		name, omitempty := jsonName(field)
+		if name == "-" {
+			continue
+		}
+
+		// Untagged embedded structs are flattened, like encoding/json does
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			embedded, err := b.variables(field.Type)
+			if err != nil {
+				return nil, err
+			}
+
+			variables = append(variables, embedded...)
+
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		def := &VariableDefinition{Name: name}
+
+		if tag := field.Tag.Get("gql"); tag != "" {
+			ref, err := parseTypeExpr(tag)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", typ, field.Name, err)
+			}
+
+			def.Type = ref
+		} else {
+			ref, err := inferType(field.Type)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", typ, field.Name, err)
+			}
+
+			if omitempty {
+				ref.NonNull = false
+			}
+
+			def.Type = ref
+		}
+
+		variables = append(variables, def)
+	}
+
+	return variables, nil
+}
+
+// inferType maps a Go type onto a built-in GraphQL scalar
+func inferType(typ reflect.Type) (*TypeRef, error) {
+	switch typ.Kind() {
+	case reflect.Ptr:
+		ref, err := inferType(typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		ref.NonNull = false
+
+		return ref, nil
+
+	case reflect.Slice, reflect.Array:
+		elem, err := inferType(typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		return &TypeRef{Elem: elem}, nil
+
+	case reflect.String:
+		return &TypeRef{Name: "String", NonNull: true}, nil
+
+	case reflect.Bool:
+		return &TypeRef{Name: "Boolean", NonNull: true}, nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &TypeRef{Name: "Int", NonNull: true}, nil
+
+	case reflect.Float32, reflect.Float64:
+		return &TypeRef{Name: "Float", NonNull: true}, nil
+	}
+
+	return nil, fmt.Errorf("can't infer a GraphQL type for %s, set one with a gql tag", typ)
+}
+
+func (b *builder) selections(typ reflect.Type) ([]Selection, error) {
+	typ = elemType(typ)
+
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%s can't be selected from, it is not a struct", typ)
+	}
+
+	for _, t := range b.path {
+		if t == typ {
+			return nil, fmt.Errorf("%s selects itself", typ)
+		}
+	}
+
+	b.path = append(b.path, typ)
+	defer func() { b.path = b.path[:len(b.path)-1] }()
+
+	selections := []Selection{}
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		expr, options := splitTag(field.Tag.Get("gql"))
+		if expr == "-" {
+			continue
+		}
+
+		name, _ := jsonName(field)
+		if name == "-" {
+			continue
+		}
+
+		switch {
+		// Untagged embedded structs are flattened, like encoding/json does
+		case field.Anonymous && expr == "" && name == "":
+			embedded, err := b.selections(field.Type)
+			if err != nil {
+				return nil, err
+			}
+
+			selections = append(selections, embedded...)
+
+		case strings.HasPrefix(expr, "..."):
+			if !field.Anonymous || name != "" {
+				return nil, fmt.Errorf("%s.%s: fragments must be untagged embedded structs", typ, field.Name)
+			}
+
+			fragment, err := b.fragment(expr, field.Type)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", typ, field.Name, err)
+			}
+
+			selections = append(selections, fragment)
+
+		default:
+			if !field.IsExported() {
+				continue
+			}
+
+			selection, err := b.field(field, name, expr, options)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", typ, field.Name, err)
+			}
+
+			selections = append(selections, selection)
+		}
+	}
+
+	if len(selections) == 0 {
+		return nil, fmt.Errorf("%s has no fields to select", typ)
+	}
+
+	return selections, nil
+}
+
+func (b *builder) field(field reflect.StructField, name, expr string, options []string) (*Field, error) {
+	selection := &Field{Name: name}
+
+	if selection.Name == "" {
+		selection.Name = lowerFirst(field.Name)
+	}
+
+	if expr != "" {
+		parsed, err := parseFieldExpr(expr)
+		if err != nil {
+			return nil, err
+		}
+
+		if name != "" && parsed.ResponseKey() != name {
+			return nil, fmt.Errorf("gql tag returns %q but the json tag decodes %q", parsed.ResponseKey(), name)
+		}
+
+		selection = parsed
+	}
+
+	if hasOption(options, "scalar") || !isComposite(field.Type) {
+		return selection, nil
+	}
+
+	selections, err := b.selections(field.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	selection.Selections = selections
+
+	return selection, nil
+}
+
+func (b *builder) fragment(expr string, typ reflect.Type) (Selection, error) {
+	p, err := newParser(strings.TrimPrefix(expr, "..."))
+	if err != nil {
+		return nil, err
+	}
+
+	fragmentName := ""
+
+	if p.tok.kind == tokenName && p.tok.value != "on" {
+		fragmentName = p.tok.value
+
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := p.expectKeyword("on"); err != nil {
+		return nil, err
+	}
+
+	typeCondition, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+
+	directives, err := p.parseDirectives()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind != tokenEOF {
+		return nil, p.errorf("unexpected %s", p.tok)
+	}
+
+	selections, err := b.selections(typ)
+	if err != nil {
+		return nil, err
+	}
+
+	if fragmentName == "" {
+		return &InlineFragment{
+			TypeCondition: typeCondition,
+			Directives:    directives,
+			Selections:    selections,
+		}, nil
+	}
+
+	if existing, ok := b.fragmentTypes[fragmentName]; ok && existing != typ {
+		return nil, fmt.Errorf("fragment %s is declared by both %s and %s", fragmentName, existing, typ)
+	}
+
+	b.fragmentTypes[fragmentName] = typ
+	b.fragments[fragmentName] = &FragmentDefinition{
+		Name:          fragmentName,
+		TypeCondition: typeCondition,
+		Selections:    selections,
+	}
+
+	return &FragmentSpread{Name: fragmentName, Directives: directives}, nil
+}
+
+// parseFieldExpr parses a single field without subfields, e.g.
+// `alias: name(arg: $var) @include(if: $flag)`
+func parseFieldExpr(expr string) (*Field, error) {
+	p, err := newParser(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	selection, err := p.parseSelection()
+	if err != nil {
+		return nil, err
+	}
+
+	field, ok := selection.(*Field)
+	if !ok || len(field.Selections) > 0 || p.tok.kind != tokenEOF {
+		return nil, fmt.Errorf("invalid field %q", expr)
+	}
+
+	return field, nil
+}
+
+func parseTypeExpr(expr string) (*TypeRef, error) {
+	p, err := newParser(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	ref, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind != tokenEOF {
+		return nil, fmt.Errorf("invalid type %q", expr)
+	}
+
+	return ref, nil
+}
+
+// splitTag splits a tag into its expression and options at the commas that
+// are not inside parentheses, brackets or braces
+func splitTag(tag string) (string, []string) {
+	var parts []string
+
+	depth, start := 0, 0
+
+	for i := 0; i < len(tag); i++ {
+		switch tag[i] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(tag[start:i]))
+				start = i + 1
+			}
+		}
+	}
+
+	parts = append(parts, strings.TrimSpace(tag[start:]))
+
+	return parts[0], parts[1:]
+}
+
+func hasOption(options []string, option string) bool {
+	for _, o := range options {
+		if o == option {
+			return true
+		}
+	}
+
+	return false
+}
+
+// jsonName returns the name in the json tag of a field and whether it has
+// the omitempty option
+func jsonName(field reflect.StructField) (string, bool) {
+	parts := strings.Split(field.Tag.Get("json"), ",")
+
+	return parts[0], hasOption(parts[1:], "omitempty")
+}
+
+func elemType(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array {
+		typ = typ.Elem()
+	}
+
+	return typ
+}
+
+// isComposite reports whether a value of typ is decoded from an object with
+// subfields rather than from a scalar
+func isComposite(typ reflect.Type) bool {
+	typ = elemType(typ)
+
+	if typ.Kind() != reflect.Struct {
+		return false
+	}
+
+	ptr := reflect.PtrTo(typ)
+
+	return !ptr.Implements(jsonUnmarshalerType) && !ptr.Implements(textUnmarshalerType)
+}
+
+func lowerFirst(s string) string {
+	for i, r := range s {
+		return string(unicode.ToLower(r)) + s[i+len(string(r)):]
+	}
+
+	return s
+}
@@ -0,0 +1,185 @@
+package gql
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type builderVars struct {
+	ProjectID string   `json:"projectId" gql:"ID!"`
+	Logs      bool     `json:"logs"`
+	Limit     *int     `json:"limit"`
+	Offset    int      `json:"offset,omitempty"`
+	Ratio     float64  `json:"ratio"`
+	Names     []string `json:"names"`
+	Pair      [2]int   `json:"pair"`
+	Ignored   string   `json:"-"`
+	builderPage
+}
+
+type builderPage struct {
+	After *string `json:"after"`
+}
+
+type builderDeployment struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+type builderUser struct {
+	Email string `json:"email"`
+}
+
+type builderNode struct {
+	Typename string `json:"__typename"`
+
+	builderDeploymentFields `gql:"...DeploymentFields on Deployment"`
+	builderUserFields       `gql:"... on User @include(if: $logs)"`
+}
+
+type builderDeploymentFields struct {
+	ID string `json:"id"`
+}
+
+type builderUserFields struct {
+	Email string `json:"email"`
+}
+
+type builderResult struct {
+	Deployment *builderDeployment  `json:"deployment" gql:"deployment(projectId: $projectId)"`
+	Latest     []builderDeployment `json:"latest" gql:"latest: deployments(first: $limit)"`
+	Logs       string              `json:"logs" gql:"logs: buildLogs @include(if: $logs)"`
+	Meta       builderMeta         `json:"meta" gql:"meta,scalar"`
+	CreatedAt  time.Time           `json:"createdAt"`
+	Hidden     string              `json:"hidden" gql:"-"`
+	Node       *builderNode        `json:"node"`
+	Me         builderUser
+}
+
+type builderMeta struct {
+	Branch string `json:"branch"`
+}
+
+func TestBuild(t *testing.T) {
+	got, err := Build[builderVars, builderResult]("query", "Deployment")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "query Deployment ($projectId: ID!, $logs: Boolean!, $limit: Int, $offset: Int, $ratio: Float!, $names: [String!], $pair: [Int!], $after: String) { " +
+		"deployment(projectId: $projectId) { id status } " +
+		"latest: deployments(first: $limit) { id status } " +
+		"logs: buildLogs @include(if: $logs) " +
+		"meta " +
+		"createdAt " +
+		"node { __typename ...DeploymentFields ... on User @include(if: $logs) { email } } " +
+		"me { email } " +
+		"} fragment DeploymentFields on Deployment { id }"
+
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	// The same types always render the same document
+	for i := 0; i < 10; i++ {
+		again, err := Build[builderVars, builderResult]("query", "Deployment")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if again != got {
+			t.Fatalf("the document changed between builds:\n%s\n%s", got, again)
+		}
+	}
+}
+
+func TestBuildNoVariables(t *testing.T) {
+	got, err := Build[struct{}, struct {
+		Me builderUser `json:"me"`
+	}]("query", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "query { me { email } }"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+type builderLoop struct {
+	Parent *builderLoop `json:"parent"`
+}
+
+type builderTwice struct {
+	builderDeploymentFields `gql:"...Fields on Deployment"`
+	Node                    struct {
+		builderUserFields `gql:"...Fields on User"`
+	} `json:"node"`
+}
+
+func TestBuildErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func() (string, error)
+		want  string
+	}{
+		{
+			name: "variables that aren't a struct",
+			build: func() (string, error) {
+				return Build[string, builderUser]("query", "")
+			},
+			want: "variables must be a struct",
+		},
+		{
+			name: "uninferable variable",
+			build: func() (string, error) {
+				return Build[struct {
+					Where map[string]string `json:"where"`
+				}, builderUser]("query", "")
+			},
+			want: "can't infer a GraphQL type for map[string]string",
+		},
+		{
+			name: "recursive result",
+			build: func() (string, error) {
+				return Build[struct{}, builderLoop]("query", "")
+			},
+			want: "selects itself",
+		},
+		{
+			name: "alias not matching the json tag",
+			build: func() (string, error) {
+				return Build[struct{}, struct {
+					Logs string `json:"logs" gql:"deployLogs"`
+				}]("query", "")
+			},
+			want: `gql tag returns "deployLogs" but the json tag decodes "logs"`,
+		},
+		{
+			name: "fragment declared twice",
+			build: func() (string, error) {
+				return Build[struct{}, builderTwice]("query", "")
+			},
+			want: "fragment Fields is declared by both",
+		},
+		{
+			name: "fragment on a named field",
+			build: func() (string, error) {
+				return Build[struct{}, struct {
+					Node builderUserFields `json:"node" gql:"... on User"`
+				}]("query", "")
+			},
+			want: "fragments must be untagged embedded structs",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.build()
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("err = %v, want it to contain %q", err, test.want)
+			}
+		})
+	}
+}
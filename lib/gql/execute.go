@@ -0,0 +1,637 @@
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Object is the value of an object type: its field names mapped to values,
+// or to Resolvers computing them from the field arguments. An object of an
+// interface or union type names its concrete type under "__typename"
+type Object map[string]interface{}
+
+// Resolver computes the value of a field from its coerced arguments
+type Resolver func(ctx context.Context, args map[string]interface{}) (interface{}, error)
+
+// Request is a GraphQL request as sent over HTTP
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Response is a GraphQL response as sent over HTTP
+type Response struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []*Error               `json:"errors,omitempty"`
+}
+
+// Error is an error of a GraphQL response
+type Error struct {
+	Message    string                 `json:"message"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// FieldError is returned by resolvers to set the code of the error in the
+// response
+type FieldError struct {
+	Code    string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+// Execute validates the request against the schema and runs it, resolving the
+// fields of the root operation type from root. Subscriptions are not
+// supported
+func (s *Schema) Execute(ctx context.Context, req *Request, root Object) *Response {
+	doc, err := ParseDocument(req.Query)
+	if err != nil {
+		return errorResponse("GRAPHQL_PARSE_FAILED", err.Error())
+	}
+
+	if err := s.Validate(doc); err != nil {
+		res := &Response{}
+
+		for _, problem := range err.(ValidationErrors) {
+			res.Errors = append(res.Errors, codedError("GRAPHQL_VALIDATION_FAILED", problem, nil))
+		}
+
+		return res
+	}
+
+	op, err := selectOperation(doc, req.OperationName)
+	if err != nil {
+		return errorResponse("BAD_USER_INPUT", err.Error())
+	}
+
+	rootType := s.RootType(op.Type)
+	if rootType == nil || op.Type == "subscription" {
+		return errorResponse("BAD_USER_INPUT", fmt.Sprintf("%s operations are not supported", op.Type))
+	}
+
+	e := &executor{schema: s, doc: doc}
+
+	e.variables, err = e.coerceVariables(op.Variables, req.Variables)
+	if err != nil {
+		return errorResponse("BAD_USER_INPUT", err.Error())
+	}
+
+	data, ok := e.executeSelections(ctx, rootType, root, op.Selections, nil)
+
+	res := &Response{Errors: e.errors}
+	if ok {
+		res.Data = data
+	}
+
+	return res
+}
+
+func errorResponse(code, message string) *Response {
+	return &Response{Errors: []*Error{codedError(code, message, nil)}}
+}
+
+func codedError(code, message string, path []interface{}) *Error {
+	return &Error{
+		Message:    message,
+		Path:       path,
+		Extensions: map[string]interface{}{"code": code},
+	}
+}
+
+func selectOperation(doc *Document, name string) (*OperationDefinition, error) {
+	if name == "" {
+		if len(doc.Operations) != 1 {
+			return nil, fmt.Errorf("the document holds %d operations, name the one to run", len(doc.Operations))
+		}
+
+		return doc.Operations[0], nil
+	}
+
+	for _, op := range doc.Operations {
+		if op.Name == name {
+			return op, nil
+		}
+	}
+
+	return nil, fmt.Errorf("operation %q not found", name)
+}
+
+type executor struct {
+	schema    *Schema
+	doc       *Document
+	variables map[string]interface{}
+	errors    []*Error
+}
+
+func (e *executor) fieldError(path []interface{}, err error) {
+	code := "INTERNAL_SERVER_ERROR"
+
+	if fieldErr, ok := err.(*FieldError); ok {
+		code = fieldErr.Code
+	}
+
+	e.errors = append(e.errors, codedError(code, err.Error(), append([]interface{}{}, path...)))
+}
+
+// executeSelections resolves the selected fields of an object. It reports
+// false when a non-null field failed, making the object itself null
+func (e *executor) executeSelections(ctx context.Context, typ *TypeDefinition, obj Object, selections []Selection, path []interface{}) (map[string]interface{}, bool) {
+	result := make(map[string]interface{})
+	ok := true
+
+	for _, group := range e.collectFields(typ, selections, nil) {
+		field := group[0]
+		fieldPath := append(path, field.ResponseKey())
+
+		if field.Name == "__typename" {
+			result[field.ResponseKey()] = typ.Name
+			continue
+		}
+
+		def := typ.Fields[field.Name]
+
+		value, err := e.resolve(ctx, obj, def, field)
+		if err != nil {
+			e.fieldError(fieldPath, err)
+
+			if def.Type.NonNull {
+				ok = false
+			}
+
+			result[field.ResponseKey()] = nil
+
+			continue
+		}
+
+		// Merge the subfields of every field sharing the response key
+		var subselections []Selection
+		for _, f := range group {
+			subselections = append(subselections, f.Selections...)
+		}
+
+		completed, fieldOK := e.completeValue(ctx, def.Type, value, subselections, fieldPath)
+		if !fieldOK {
+			ok = false
+		}
+
+		result[field.ResponseKey()] = completed
+	}
+
+	return result, ok
+}
+
+func (e *executor) resolve(ctx context.Context, obj Object, def *FieldDefinition, field *Field) (interface{}, error) {
+	value := obj[field.Name]
+
+	var resolver Resolver
+
+	switch fn := value.(type) {
+	case Resolver:
+		resolver = fn
+	case func(context.Context, map[string]interface{}) (interface{}, error):
+		resolver = fn
+	default:
+		return value, nil
+	}
+
+	args, err := e.coerceArguments(def.Arguments, field.Arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	return resolver(ctx, args)
+}
+
+// collectFields flattens fragments and applies @include and @skip, grouping
+// fields by response key in the order they first appear
+func (e *executor) collectFields(typ *TypeDefinition, selections []Selection, groups [][]*Field) [][]*Field {
+	for _, selection := range selections {
+		switch sel := selection.(type) {
+		case *Field:
+			if !e.included(sel.Directives) {
+				continue
+			}
+
+			merged := false
+
+			for i, group := range groups {
+				if group[0].ResponseKey() == sel.ResponseKey() {
+					groups[i] = append(group, sel)
+					merged = true
+					break
+				}
+			}
+
+			if !merged {
+				groups = append(groups, []*Field{sel})
+			}
+
+		case *FragmentSpread:
+			fragment := e.doc.Fragments[sel.Name]
+
+			if e.included(sel.Directives) && e.applies(fragment.TypeCondition, typ) {
+				groups = e.collectFields(typ, fragment.Selections, groups)
+			}
+
+		case *InlineFragment:
+			if e.included(sel.Directives) && (sel.TypeCondition == "" || e.applies(sel.TypeCondition, typ)) {
+				groups = e.collectFields(typ, sel.Selections, groups)
+			}
+		}
+	}
+
+	return groups
+}
+
+func (e *executor) included(directives []*Directive) bool {
+	for _, directive := range directives {
+		if directive.Name != "include" && directive.Name != "skip" {
+			continue
+		}
+
+		condition := false
+
+		for _, arg := range directive.Arguments {
+			if arg.Name == "if" {
+				value, _ := e.valueFromAST(arg.Value, &TypeRef{Name: "Boolean", NonNull: true})
+				condition, _ = value.(bool)
+			}
+		}
+
+		if (directive.Name == "include") != condition {
+			return false
+		}
+	}
+
+	return true
+}
+
+// applies reports whether a fragment on the named type applies to objects of
+// type typ
+func (e *executor) applies(condition string, typ *TypeDefinition) bool {
+	if condition == typ.Name {
+		return true
+	}
+
+	// Objects list the interfaces they implement, unions their members
+	for _, name := range typ.Types {
+		if name == condition {
+			return true
+		}
+	}
+
+	if union := e.schema.Types[condition]; union != nil && union.Kind == KindUnion {
+		for _, name := range union.Types {
+			if name == typ.Name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (e *executor) completeValue(ctx context.Context, typ *TypeRef, value interface{}, selections []Selection, path []interface{}) (interface{}, bool) {
+	if isNil(value) {
+		if typ.NonNull {
+			e.fieldError(path, fmt.Errorf("cannot return null for non-nullable field of type %s", typ))
+			return nil, false
+		}
+
+		return nil, true
+	}
+
+	if typ.Elem != nil {
+		items := reflect.ValueOf(value)
+		if items.Kind() != reflect.Slice && items.Kind() != reflect.Array {
+			e.fieldError(path, fmt.Errorf("expected a list for %s, got %T", typ, value))
+			return nil, !typ.NonNull
+		}
+
+		list := make([]interface{}, items.Len())
+
+		for i := range list {
+			item, ok := e.completeValue(ctx, typ.Elem, items.Index(i).Interface(), selections, append(path, i))
+			if !ok {
+				return nil, !typ.NonNull
+			}
+
+			list[i] = item
+		}
+
+		return list, true
+	}
+
+	def := e.schema.Types[typ.Name]
+
+	if !def.Kind.composite() {
+		return value, true
+	}
+
+	obj, isObject := toObject(value)
+	if !isObject {
+		e.fieldError(path, fmt.Errorf("expected an object for %s, got %T", typ, value))
+		return nil, !typ.NonNull
+	}
+
+	if def.Kind != KindObject {
+		concrete, _ := obj["__typename"].(string)
+
+		if def = e.schema.Types[concrete]; def == nil || def.Kind != KindObject {
+			e.fieldError(path, fmt.Errorf("object of abstract type %s has no valid __typename", typ.Name))
+			return nil, !typ.NonNull
+		}
+	}
+
+	result, ok := e.executeSelections(ctx, def, obj, selections, path)
+	if !ok {
+		return nil, !typ.NonNull
+	}
+
+	return result, true
+}
+
+func toObject(value interface{}) (Object, bool) {
+	switch obj := value.(type) {
+	case Object:
+		return obj, true
+	case map[string]interface{}:
+		return obj, true
+	}
+
+	return nil, false
+}
+
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+
+	switch v := reflect.ValueOf(value); v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func:
+		return v.IsNil()
+	}
+
+	return false
+}
+
+func (e *executor) coerceVariables(defs []*VariableDefinition, values map[string]interface{}) (map[string]interface{}, error) {
+	coerced := make(map[string]interface{})
+
+	for _, def := range defs {
+		value, provided := values[def.Name]
+
+		if !provided && def.Default != nil {
+			defaultValue, err := e.valueFromAST(def.Default, def.Type)
+			if err != nil {
+				return nil, fmt.Errorf("variable $%s: %w", def.Name, err)
+			}
+
+			coerced[def.Name] = defaultValue
+
+			continue
+		}
+
+		if !provided || value == nil {
+			if def.Type.NonNull {
+				return nil, fmt.Errorf("variable $%s of type %s must not be null", def.Name, def.Type)
+			}
+
+			if provided {
+				coerced[def.Name] = nil
+			}
+
+			continue
+		}
+
+		value, err := e.coerceInput(def.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("variable $%s: %w", def.Name, err)
+		}
+
+		coerced[def.Name] = value
+	}
+
+	return coerced, nil
+}
+
+// coerceInput converts a JSON decoded input value to the Go value resolvers
+// get: ints for Int, and lists for list types given a single item
+func (e *executor) coerceInput(typ *TypeRef, value interface{}) (interface{}, error) {
+	if value == nil {
+		if typ.NonNull {
+			return nil, fmt.Errorf("expected %s, got null", typ)
+		}
+
+		return nil, nil
+	}
+
+	if typ.Elem != nil {
+		items, ok := value.([]interface{})
+		if !ok {
+			items = []interface{}{value}
+		}
+
+		list := make([]interface{}, len(items))
+
+		for i, item := range items {
+			coerced, err := e.coerceInput(typ.Elem, item)
+			if err != nil {
+				return nil, err
+			}
+
+			list[i] = coerced
+		}
+
+		return list, nil
+	}
+
+	def := e.schema.Types[typ.Name]
+
+	switch {
+	case typ.Name == "Int":
+		number, ok := value.(float64)
+		if !ok || number != float64(int(number)) {
+			return nil, fmt.Errorf("expected an Int, got %v", value)
+		}
+
+		return int(number), nil
+
+	case typ.Name == "ID":
+		// IDs may be sent as numbers
+		if number, ok := value.(float64); ok {
+			return strconv.FormatFloat(number, 'f', -1, 64), nil
+		}
+
+		return value, nil
+
+	case def != nil && def.Kind == KindInputObject:
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected an object for %s, got %T", typ.Name, value)
+		}
+
+		coerced := make(map[string]interface{})
+
+		for name, fieldDef := range def.InputFields {
+			fieldValue, provided := fields[name]
+			if !provided {
+				continue
+			}
+
+			fieldValue, err := e.coerceInput(fieldDef.Type, fieldValue)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", typ.Name, name, err)
+			}
+
+			coerced[name] = fieldValue
+		}
+
+		return coerced, nil
+	}
+
+	return value, nil
+}
+
+func (e *executor) coerceArguments(defs map[string]*InputValueDefinition, args []*Argument) (map[string]interface{}, error) {
+	coerced := make(map[string]interface{})
+
+	for _, arg := range args {
+		def := defs[arg.Name]
+
+		// Variables that weren't provided leave the argument unset
+		if arg.Value.Variable != "" {
+			if _, provided := e.variables[arg.Value.Variable]; !provided {
+				continue
+			}
+		}
+
+		value, err := e.valueFromAST(arg.Value, def.Type)
+		if err != nil {
+			return nil, &FieldError{Code: "BAD_USER_INPUT", Message: fmt.Sprintf("argument %s: %s", arg.Name, err)}
+		}
+
+		coerced[arg.Name] = value
+	}
+
+	for name, def := range defs {
+		if _, set := coerced[name]; set || def.Default == nil {
+			continue
+		}
+
+		value, err := e.valueFromAST(def.Default, def.Type)
+		if err != nil {
+			return nil, err
+		}
+
+		coerced[name] = value
+	}
+
+	return coerced, nil
+}
+
+func (e *executor) valueFromAST(value *Value, typ *TypeRef) (interface{}, error) {
+	switch {
+	case value.Variable != "":
+		return e.variables[value.Variable], nil
+
+	case value.IsNull:
+		return nil, nil
+
+	case value.IsList:
+		elem := typ
+		if typ.Elem != nil {
+			elem = typ.Elem
+		}
+
+		list := make([]interface{}, len(value.List))
+
+		for i, item := range value.List {
+			coerced, err := e.valueFromAST(item, elem)
+			if err != nil {
+				return nil, err
+			}
+
+			list[i] = coerced
+		}
+
+		return list, nil
+
+	case value.IsObject:
+		def := e.schema.Types[typ.Named()]
+		fields := make(map[string]interface{})
+
+		for _, field := range value.Fields {
+			fieldType := &TypeRef{Name: "String"}
+
+			if def != nil {
+				if fieldDef, ok := def.InputFields[field.Name]; ok {
+					fieldType = fieldDef.Type
+				}
+			}
+
+			coerced, err := e.valueFromAST(field.Value, fieldType)
+			if err != nil {
+				return nil, err
+			}
+
+			fields[field.Name] = coerced
+		}
+
+		return fields, nil
+	}
+
+	if typ.Elem != nil {
+		// A single item stands for a list of one
+		item, err := e.valueFromAST(value, typ.Elem)
+		if err != nil {
+			return nil, err
+		}
+
+		return []interface{}{item}, nil
+	}
+
+	return scalarFromLiteral(typ.Name, value.Literal)
+}
+
+func scalarFromLiteral(typeName, literal string) (interface{}, error) {
+	if strings.HasPrefix(literal, `"`) {
+		var s string
+		if err := json.Unmarshal([]byte(literal), &s); err != nil {
+			return nil, err
+		}
+
+		return s, nil
+	}
+
+	switch typeName {
+	case "Int":
+		return strconv.Atoi(literal)
+	case "Float":
+		return strconv.ParseFloat(literal, 64)
+	case "ID":
+		return literal, nil
+	}
+
+	switch literal {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+
+	// Enum values, and numbers given to custom scalars
+	if number, err := strconv.ParseFloat(literal, 64); err == nil {
+		return number, nil
+	}
+
+	return literal, nil
+}
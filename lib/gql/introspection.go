@@ -0,0 +1,281 @@
+package gql
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// IntrospectionQuery asks a server for its schema, in the shape
+// SchemaFromIntrospection reads. Descriptions and deprecations are left out,
+// as schemas don't keep them
+const IntrospectionQuery = `
+	query IntrospectionQuery {
+		__schema {
+			queryType { name }
+			mutationType { name }
+			subscriptionType { name }
+			types { ...FullType }
+		}
+	}
+
+	fragment FullType on __Type {
+		kind
+		name
+		fields(includeDeprecated: true) {
+			name
+			args { ...InputValue }
+			type { ...TypeRef }
+		}
+		inputFields { ...InputValue }
+		interfaces { ...TypeRef }
+		enumValues(includeDeprecated: true) { name }
+		possibleTypes { ...TypeRef }
+	}
+
+	fragment InputValue on __InputValue {
+		name
+		type { ...TypeRef }
+		defaultValue
+	}
+
+	fragment TypeRef on __Type {
+		kind
+		name
+		ofType {
+			kind
+			name
+			ofType {
+				kind
+				name
+				ofType {
+					kind
+					name
+					ofType {
+						kind
+						name
+						ofType {
+							kind
+							name
+							ofType {
+								kind
+								name
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+`
+
+type introspectionTypeRef struct {
+	Kind   string                `json:"kind"`
+	Name   string                `json:"name"`
+	OfType *introspectionTypeRef `json:"ofType"`
+}
+
+type introspectionInputValue struct {
+	Name         string                `json:"name"`
+	Type         *introspectionTypeRef `json:"type"`
+	DefaultValue *string               `json:"defaultValue"`
+}
+
+type introspectionType struct {
+	Kind   TypeKind `json:"kind"`
+	Name   string   `json:"name"`
+	Fields []struct {
+		Name string                    `json:"name"`
+		Args []introspectionInputValue `json:"args"`
+		Type *introspectionTypeRef     `json:"type"`
+	} `json:"fields"`
+	InputFields []introspectionInputValue `json:"inputFields"`
+	Interfaces  []introspectionTypeRef    `json:"interfaces"`
+	EnumValues  []struct {
+		Name string `json:"name"`
+	} `json:"enumValues"`
+	PossibleTypes []introspectionTypeRef `json:"possibleTypes"`
+}
+
+type introspectionRootType struct {
+	Name string `json:"name"`
+}
+
+// SchemaFromIntrospection builds a schema from the data of a response to
+// IntrospectionQuery. Introspection types, whose names start with "__", are
+// left out
+func SchemaFromIntrospection(data []byte) (*Schema, error) {
+	var resp struct {
+		Schema *struct {
+			QueryType        *introspectionRootType `json:"queryType"`
+			MutationType     *introspectionRootType `json:"mutationType"`
+			SubscriptionType *introspectionRootType `json:"subscriptionType"`
+			Types            []introspectionType    `json:"types"`
+		} `json:"__schema"`
+	}
+
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Schema == nil {
+		return nil, fmt.Errorf("no __schema in the introspection response")
+	}
+
+	schema := &Schema{
+		Types:        make(map[string]*TypeDefinition),
+		Query:        "Query",
+		Mutation:     "Mutation",
+		Subscription: "Subscription",
+	}
+
+	if resp.Schema.QueryType != nil {
+		schema.Query = resp.Schema.QueryType.Name
+	}
+
+	if resp.Schema.MutationType != nil {
+		schema.Mutation = resp.Schema.MutationType.Name
+	}
+
+	if resp.Schema.SubscriptionType != nil {
+		schema.Subscription = resp.Schema.SubscriptionType.Name
+	}
+
+	for _, name := range builtinScalars {
+		schema.Types[name] = &TypeDefinition{Kind: KindScalar, Name: name}
+	}
+
+	for _, typ := range resp.Schema.Types {
+		if strings.HasPrefix(typ.Name, "__") {
+			continue
+		}
+
+		// Built-in scalars are listed too
+		if builtin, ok := schema.Types[typ.Name]; ok && builtin.Kind == KindScalar && typ.Kind == KindScalar {
+			continue
+		}
+
+		def, err := typ.definition()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", typ.Name, err)
+		}
+
+		if _, exists := schema.Types[def.Name]; exists {
+			return nil, fmt.Errorf("type %q is defined more than once", def.Name)
+		}
+
+		schema.Types[def.Name] = def
+	}
+
+	return schema, schema.check()
+}
+
+func (t *introspectionType) definition() (*TypeDefinition, error) {
+	def := &TypeDefinition{Kind: t.Kind, Name: t.Name}
+
+	switch t.Kind {
+	case KindScalar:
+
+	case KindObject, KindInterface:
+		def.Fields = make(map[string]*FieldDefinition)
+
+		for _, field := range t.Fields {
+			args, err := inputValueDefinitions(field.Args)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", field.Name, err)
+			}
+
+			typ, err := field.Type.typeRef()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", field.Name, err)
+			}
+
+			def.Fields[field.Name] = &FieldDefinition{Name: field.Name, Arguments: args, Type: typ}
+		}
+
+		for _, iface := range t.Interfaces {
+			def.Types = append(def.Types, iface.Name)
+		}
+
+	case KindUnion:
+		for _, member := range t.PossibleTypes {
+			def.Types = append(def.Types, member.Name)
+		}
+
+	case KindEnum:
+		def.EnumValues = make(map[string]bool)
+
+		for _, value := range t.EnumValues {
+			def.EnumValues[value.Name] = true
+		}
+
+	case KindInputObject:
+		fields, err := inputValueDefinitions(t.InputFields)
+		if err != nil {
+			return nil, err
+		}
+
+		def.InputFields = fields
+
+	default:
+		return nil, fmt.Errorf("unsupported kind %q", t.Kind)
+	}
+
+	return def, nil
+}
+
+func inputValueDefinitions(values []introspectionInputValue) (map[string]*InputValueDefinition, error) {
+	defs := make(map[string]*InputValueDefinition)
+
+	for _, value := range values {
+		typ, err := value.Type.typeRef()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", value.Name, err)
+		}
+
+		def := &InputValueDefinition{Name: value.Name, Type: typ}
+
+		if value.DefaultValue != nil {
+			p, err := newParser(*value.DefaultValue)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", value.Name, err)
+			}
+
+			if def.Default, err = p.parseValue(true); err != nil {
+				return nil, fmt.Errorf("%s: %w", value.Name, err)
+			}
+		}
+
+		defs[value.Name] = def
+	}
+
+	return defs, nil
+}
+
+func (r *introspectionTypeRef) typeRef() (*TypeRef, error) {
+	if r == nil {
+		return nil, fmt.Errorf("type reference is too deep")
+	}
+
+	switch r.Kind {
+	case "NON_NULL":
+		elem, err := r.OfType.typeRef()
+		if err != nil {
+			return nil, err
+		}
+
+		elem.NonNull = true
+
+		return elem, nil
+
+	case "LIST":
+		elem, err := r.OfType.typeRef()
+		if err != nil {
+			return nil, err
+		}
+
+		return &TypeRef{Elem: elem}, nil
+	}
+
+	return &TypeRef{Name: r.Name}, nil
+}
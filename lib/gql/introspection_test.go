@@ -0,0 +1,140 @@
+package gql
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "Rewrite the golden files in testdata")
+
+// golden compares got with the content of a file in testdata, or rewrites the
+// file with -update
+func golden(t *testing.T, name, got string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+
+	if *update {
+		if err := ioutil.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got != string(want) {
+		t.Errorf("%s differs, got:\n%s", path, got)
+	}
+}
+
+func introspectedSchema(t *testing.T) *Schema {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "introspection.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := SchemaFromIntrospection(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return schema
+}
+
+func TestSchemaFromIntrospection(t *testing.T) {
+	printed := PrintSchema(introspectedSchema(t))
+	golden(t, "introspection.graphql", printed)
+
+	// The printed schema parses back to itself
+	reparsed, err := ParseSchema(printed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if again := PrintSchema(reparsed); again != printed {
+		t.Errorf("the printed schema doesn't print back the same:\n%s", again)
+	}
+}
+
+func TestSchemaFromIntrospectionErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{"no schema", `{"me": null}`, "no __schema in the introspection response"},
+		{"unknown type", `{"__schema": {"types": [{"kind": "OBJECT", "name": "Query", "fields": [{"name": "me", "args": [], "type": {"kind": "OBJECT", "name": "User"}}]}]}}`, `Query.me: unknown type "User"`},
+		{"unknown kind", `{"__schema": {"types": [{"kind": "DIRECTIVE", "name": "auth"}]}}`, `auth: unsupported kind "DIRECTIVE"`},
+		{"invalid default", `{"__schema": {"types": [{"kind": "INPUT_OBJECT", "name": "Filter", "inputFields": [{"name": "first", "type": {"kind": "SCALAR", "name": "Int"}, "defaultValue": "$first"}]}]}}`, "Filter: first: 1:1: unexpected variable in constant value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := SchemaFromIntrospection([]byte(tt.data))
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSubset(t *testing.T) {
+	schema := introspectedSchema(t)
+
+	tests := []struct {
+		name string
+		docs []string
+	}{
+		{
+			name: "fields",
+			docs: []string{`{ me { email } }`},
+		},
+		{
+			name: "arguments",
+			docs: []string{
+				`query ($filter: ProjectFilter) { projects(filter: $filter) { id } }`,
+				`mutation { updateProject(id: 1) { name } }`,
+			},
+		},
+		{
+			name: "abstract-types",
+			docs: []string{`{ search(text: "a") { ... on User { id } } node(id: 1) { ...Project } } fragment Project on Project { updatedAt }`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var docs []*Document
+
+			for _, src := range tt.docs {
+				doc, err := ParseDocument(src)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				docs = append(docs, doc)
+			}
+
+			subset, err := schema.Subset(docs...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden(t, "subset-"+tt.name+".graphql", PrintSchema(subset))
+		})
+	}
+
+	doc, err := ParseDocument(`{ me { password } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := schema.Subset(doc); err == nil {
+		t.Error("the subset for an invalid document was made")
+	}
+}
@@ -0,0 +1,248 @@
+package gql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	line  int
+	col   int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of document"
+	}
+
+	return fmt.Sprintf("%q", t.value)
+}
+
+// lexer splits a GraphQL document into tokens, skipping whitespace, commas
+// and comments as the spec says
+type lexer struct {
+	src  string
+	pos  int
+	line int
+	col  int
+}
+
+func newLexer(src string) *lexer {
+	return &lexer{
+		src:  src,
+		line: 1,
+		col:  1,
+	}
+}
+
+func (l *lexer) advance(n int) {
+	for i := 0; i < n && l.pos < len(l.src); i++ {
+		if l.src[l.pos] == '\n' {
+			l.line++
+			l.col = 1
+		} else {
+			l.col++
+		}
+
+		l.pos++
+	}
+}
+
+func (l *lexer) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%d:%d: %s", l.line, l.col, fmt.Sprintf(format, args...))
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func (l *lexer) next() (token, error) {
+	// Skip ignored tokens
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' {
+			l.advance(1)
+			continue
+		}
+
+		if c == '#' {
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.advance(1)
+			}
+
+			continue
+		}
+
+		break
+	}
+
+	tok := token{line: l.line, col: l.col}
+
+	if l.pos >= len(l.src) {
+		tok.kind = tokenEOF
+		return tok, nil
+	}
+
+	c := l.src[l.pos]
+
+	switch {
+	case strings.HasPrefix(l.src[l.pos:], "..."):
+		tok.kind = tokenPunct
+		tok.value = "..."
+		l.advance(3)
+
+	case strings.ContainsRune("!$&():=@[]{}|", rune(c)):
+		tok.kind = tokenPunct
+		tok.value = string(c)
+		l.advance(1)
+
+	case isNameStart(c):
+		start := l.pos
+
+		for l.pos < len(l.src) && (isNameStart(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.advance(1)
+		}
+
+		tok.kind = tokenName
+		tok.value = l.src[start:l.pos]
+
+	case c == '-' || isDigit(c):
+		start := l.pos
+		tok.kind = tokenInt
+
+		if c == '-' {
+			l.advance(1)
+		}
+
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.advance(1)
+		}
+
+		if l.pos < len(l.src) && l.src[l.pos] == '.' {
+			tok.kind = tokenFloat
+			l.advance(1)
+
+			for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+				l.advance(1)
+			}
+		}
+
+		if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+			tok.kind = tokenFloat
+			l.advance(1)
+
+			if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+				l.advance(1)
+			}
+
+			for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+				l.advance(1)
+			}
+		}
+
+		tok.value = l.src[start:l.pos]
+
+		if tok.value == "-" {
+			return tok, l.errorf("invalid number")
+		}
+
+	case strings.HasPrefix(l.src[l.pos:], `"""`):
+		l.advance(3)
+		end := strings.Index(l.src[l.pos:], `"""`)
+
+		if end < 0 {
+			return tok, l.errorf("unterminated block string")
+		}
+
+		tok.kind = tokenString
+		tok.value = l.src[l.pos : l.pos+end]
+		l.advance(end + 3)
+
+	case c == '"':
+		l.advance(1)
+
+		var sb strings.Builder
+
+		for {
+			if l.pos >= len(l.src) || l.src[l.pos] == '\n' {
+				return tok, l.errorf("unterminated string")
+			}
+
+			ch := l.src[l.pos]
+
+			if ch == '"' {
+				l.advance(1)
+				break
+			}
+
+			if ch == '\\' {
+				if err := l.unescape(&sb); err != nil {
+					return tok, err
+				}
+
+				continue
+			}
+
+			sb.WriteByte(ch)
+			l.advance(1)
+		}
+
+		tok.kind = tokenString
+		tok.value = sb.String()
+
+	default:
+		return tok, l.errorf("unexpected character %q", c)
+	}
+
+	return tok, nil
+}
+
+var escapes = map[byte]byte{'"': '"', '\\': '\\', '/': '/', 'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t'}
+
+// unescape writes the character escaped at the current position, a backslash,
+// to sb
+func (l *lexer) unescape(sb *strings.Builder) error {
+	if l.pos+1 >= len(l.src) {
+		return l.errorf("unterminated string")
+	}
+
+	if c, ok := escapes[l.src[l.pos+1]]; ok {
+		sb.WriteByte(c)
+		l.advance(2)
+
+		return nil
+	}
+
+	if l.src[l.pos+1] != 'u' || l.pos+6 > len(l.src) {
+		return l.errorf("invalid escape sequence")
+	}
+
+	code, err := strconv.ParseUint(l.src[l.pos+2:l.pos+6], 16, 16)
+	if err != nil {
+		return l.errorf("invalid escape sequence %s", l.src[l.pos:l.pos+6])
+	}
+
+	sb.WriteRune(rune(code))
+	l.advance(6)
+
+	return nil
+}
@@ -0,0 +1,229 @@
+package gql
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Operation is a GraphQL document together with the Go types of its
+// variables (V) and of its response data (R). Both are structs whose json
+// tags must line up with the document, which Check verifies against a schema
+type Operation[V, R any] struct {
+	Document string
+}
+
+// NewOperation declares an operation. Use struct{} for V when the operation
+// takes no variables, and for R when the response is not read
+func NewOperation[V, R any](document string) *Operation[V, R] {
+	return &Operation[V, R]{Document: document}
+}
+
+// Variables encodes vars into the variables map sent with the request
+func (o *Operation[V, R]) Variables(vars V) (map[string]interface{}, error) {
+	data, err := json.Marshal(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	variables := make(map[string]interface{})
+
+	if err := json.Unmarshal(data, &variables); err != nil {
+		return nil, err
+	}
+
+	return variables, nil
+}
+
+// Check parses the document, validates it against the schema and makes sure
+// every variable in V is declared, every required variable is in V, every
+// field of R is selected and every selected field has somewhere to go in R
+func (o *Operation[V, R]) Check(schema *Schema) error {
+	doc, err := ParseDocument(o.Document)
+	if err != nil {
+		return err
+	}
+
+	if len(doc.Operations) != 1 {
+		return fmt.Errorf("expected exactly one operation, found %d", len(doc.Operations))
+	}
+
+	if err := schema.Validate(doc); err != nil {
+		return err
+	}
+
+	op := doc.Operations[0]
+
+	var errs ValidationErrors
+
+	errs = append(errs, checkVariables(op, reflect.TypeOf((*V)(nil)).Elem())...)
+	errs = append(errs, checkResult(doc, op, reflect.TypeOf((*R)(nil)).Elem())...)
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func checkVariables(op *OperationDefinition, typ reflect.Type) ValidationErrors {
+	var errs ValidationErrors
+
+	fields := jsonFields(typ)
+	declared := make(map[string]bool)
+
+	for _, def := range op.Variables {
+		declared[def.Name] = true
+
+		if _, ok := fields[def.Name]; !ok && def.Type.NonNull && def.Default == nil {
+			errs = append(errs, fmt.Sprintf("required variable $%s is missing from %s", def.Name, typ))
+		}
+	}
+
+	for name := range fields {
+		if !declared[name] {
+			errs = append(errs, fmt.Sprintf("%s sets undeclared variable $%s", typ, name))
+		}
+	}
+
+	return errs
+}
+
+func checkResult(doc *Document, op *OperationDefinition, typ reflect.Type) ValidationErrors {
+	// struct{} means the response data is ignored
+	if typ.Kind() == reflect.Struct && typ.NumField() == 0 {
+		return nil
+	}
+
+	selected := make(map[string]bool)
+
+	for _, field := range collectFields(doc, op.Selections) {
+		selected[field.ResponseKey()] = true
+	}
+
+	var errs ValidationErrors
+
+	for name := range jsonFields(typ) {
+		if !selected[name] {
+			errs = append(errs, fmt.Sprintf("%s decodes %q, which is not selected", typ, name))
+		}
+	}
+
+	return append(errs, checkSelections(doc, op.Selections, typ, "data")...)
+}
+
+// checkSelections makes sure every selected field is decoded into typ
+func checkSelections(doc *Document, selections []Selection, typ reflect.Type, path string) ValidationErrors {
+	for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array {
+		typ = typ.Elem()
+	}
+
+	// Maps and interfaces take whatever they are given
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields := jsonFields(typ)
+
+	var errs ValidationErrors
+
+	for _, field := range collectFields(doc, selections) {
+		key := field.ResponseKey()
+
+		goField, ok := lookupJSONField(fields, key)
+		if !ok {
+			errs = append(errs, fmt.Sprintf("%s.%s is selected but %s has no field for it", path, key, typ))
+			continue
+		}
+
+		if len(field.Selections) > 0 {
+			errs = append(errs, checkSelections(doc, field.Selections, goField.Type, path+"."+key)...)
+		}
+	}
+
+	return errs
+}
+
+// collectFields flattens fragments into the fields they select
+func collectFields(doc *Document, selections []Selection) []*Field {
+	var fields []*Field
+
+	for _, selection := range selections {
+		switch sel := selection.(type) {
+		case *Field:
+			fields = append(fields, sel)
+		case *InlineFragment:
+			fields = append(fields, collectFields(doc, sel.Selections)...)
+		case *FragmentSpread:
+			if fragment, ok := doc.Fragments[sel.Name]; ok {
+				fields = append(fields, collectFields(doc, fragment.Selections)...)
+			}
+		}
+	}
+
+	return fields
+}
+
+// jsonFields returns the struct fields of typ keyed by the name encoding/json
+// uses for them, including the fields of embedded structs
+func jsonFields(typ reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	if typ.Kind() != reflect.Struct {
+		return fields
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+
+		if field.Anonymous && name == "" {
+			for k, v := range jsonFields(field.Type) {
+				if _, exists := fields[k]; !exists {
+					fields[k] = v
+				}
+			}
+
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		fields[name] = field
+	}
+
+	return fields
+}
+
+// lookupJSONField finds the field encoding/json would decode key into: an
+// exact match first, then a case-insensitive one
+func lookupJSONField(fields map[string]reflect.StructField, key string) (reflect.StructField, bool) {
+	if field, ok := fields[key]; ok {
+		return field, true
+	}
+
+	for name, field := range fields {
+		if strings.EqualFold(name, key) {
+			return field, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
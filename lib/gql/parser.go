@@ -0,0 +1,539 @@
+package gql
+
+import (
+	"fmt"
+)
+
+type parser struct {
+	lex *lexer
+	tok token
+}
+
+func newParser(src string) (*parser, error) {
+	p := &parser{lex: newLexer(src)}
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+
+	p.tok = tok
+
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%d:%d: %s", p.tok.line, p.tok.col, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) peek(punct string) bool {
+	return p.tok.kind == tokenPunct && p.tok.value == punct
+}
+
+func (p *parser) peekName(name string) bool {
+	return p.tok.kind == tokenName && p.tok.value == name
+}
+
+// skip consumes the punctuator if it is next
+func (p *parser) skip(punct string) (bool, error) {
+	if !p.peek(punct) {
+		return false, nil
+	}
+
+	return true, p.advance()
+}
+
+func (p *parser) expect(punct string) error {
+	if !p.peek(punct) {
+		return p.errorf("expected %q, found %s", punct, p.tok)
+	}
+
+	return p.advance()
+}
+
+func (p *parser) expectName() (string, error) {
+	if p.tok.kind != tokenName {
+		return "", p.errorf("expected name, found %s", p.tok)
+	}
+
+	name := p.tok.value
+
+	return name, p.advance()
+}
+
+func (p *parser) expectKeyword(keyword string) error {
+	if !p.peekName(keyword) {
+		return p.errorf("expected %q, found %s", keyword, p.tok)
+	}
+
+	return p.advance()
+}
+
+// ParseDocument parses an executable document: operations and fragments
+func ParseDocument(src string) (*Document, error) {
+	p, err := newParser(src)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{
+		Fragments: make(map[string]*FragmentDefinition),
+	}
+
+	for p.tok.kind != tokenEOF {
+		switch {
+		case p.peek("{"):
+			selections, err := p.parseSelectionSet()
+			if err != nil {
+				return nil, err
+			}
+
+			doc.Operations = append(doc.Operations, &OperationDefinition{
+				Type:       "query",
+				Selections: selections,
+			})
+
+		case p.peekName("query") || p.peekName("mutation") || p.peekName("subscription"):
+			op, err := p.parseOperation()
+			if err != nil {
+				return nil, err
+			}
+
+			doc.Operations = append(doc.Operations, op)
+
+		case p.peekName("fragment"):
+			fragment, err := p.parseFragment()
+			if err != nil {
+				return nil, err
+			}
+
+			if _, exists := doc.Fragments[fragment.Name]; exists {
+				return nil, p.errorf("fragment %q is defined more than once", fragment.Name)
+			}
+
+			doc.Fragments[fragment.Name] = fragment
+
+		default:
+			return nil, p.errorf("unexpected %s", p.tok)
+		}
+	}
+
+	return doc, nil
+}
+
+func (p *parser) parseOperation() (*OperationDefinition, error) {
+	op := &OperationDefinition{Type: p.tok.value}
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind == tokenName {
+		op.Name = p.tok.value
+
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.peek("(") {
+		vars, err := p.parseVariableDefinitions()
+		if err != nil {
+			return nil, err
+		}
+
+		op.Variables = vars
+	}
+
+	directives, err := p.parseDirectives()
+	if err != nil {
+		return nil, err
+	}
+
+	op.Directives = directives
+
+	selections, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+
+	op.Selections = selections
+
+	return op, nil
+}
+
+func (p *parser) parseVariableDefinitions() ([]*VariableDefinition, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	vars := []*VariableDefinition{}
+
+	for !p.peek(")") {
+		if err := p.expect("$"); err != nil {
+			return nil, err
+		}
+
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+
+		typ, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+
+		def := &VariableDefinition{Name: name, Type: typ}
+
+		if ok, err := p.skip("="); err != nil {
+			return nil, err
+		} else if ok {
+			if def.Default, err = p.parseValue(true); err != nil {
+				return nil, err
+			}
+		}
+
+		vars = append(vars, def)
+	}
+
+	return vars, p.expect(")")
+}
+
+func (p *parser) parseType() (*TypeRef, error) {
+	var typ *TypeRef
+
+	if ok, err := p.skip("["); err != nil {
+		return nil, err
+	} else if ok {
+		elem, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+
+		typ = &TypeRef{Elem: elem}
+	} else {
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+
+		typ = &TypeRef{Name: name}
+	}
+
+	nonNull, err := p.skip("!")
+	if err != nil {
+		return nil, err
+	}
+
+	typ.NonNull = nonNull
+
+	return typ, nil
+}
+
+func (p *parser) parseValue(constant bool) (*Value, error) {
+	switch p.tok.kind {
+	case tokenPunct:
+		switch p.tok.value {
+		case "$":
+			if constant {
+				return nil, p.errorf("unexpected variable in constant value")
+			}
+
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+
+			name, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+
+			return &Value{Variable: name}, nil
+
+		case "[":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+
+			value := &Value{IsList: true}
+
+			for !p.peek("]") {
+				item, err := p.parseValue(constant)
+				if err != nil {
+					return nil, err
+				}
+
+				value.List = append(value.List, item)
+			}
+
+			return value, p.expect("]")
+
+		case "{":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+
+			value := &Value{IsObject: true}
+
+			for !p.peek("}") {
+				name, err := p.expectName()
+				if err != nil {
+					return nil, err
+				}
+
+				if err := p.expect(":"); err != nil {
+					return nil, err
+				}
+
+				fieldValue, err := p.parseValue(constant)
+				if err != nil {
+					return nil, err
+				}
+
+				value.Fields = append(value.Fields, &ObjectField{Name: name, Value: fieldValue})
+			}
+
+			return value, p.expect("}")
+		}
+
+	case tokenString:
+		value := &Value{Literal: quoteString(p.tok.value)}
+		return value, p.advance()
+
+	case tokenInt, tokenFloat:
+		value := &Value{Literal: p.tok.value}
+		return value, p.advance()
+
+	case tokenName:
+		value := &Value{Literal: p.tok.value, IsNull: p.tok.value == "null"}
+		return value, p.advance()
+	}
+
+	return nil, p.errorf("expected value, found %s", p.tok)
+}
+
+func (p *parser) parseArguments() ([]*Argument, error) {
+	if !p.peek("(") {
+		return nil, nil
+	}
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	args := []*Argument{}
+
+	for !p.peek(")") {
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+
+		value, err := p.parseValue(false)
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, &Argument{Name: name, Value: value})
+	}
+
+	return args, p.expect(")")
+}
+
+func (p *parser) parseDirectives() ([]*Directive, error) {
+	directives := []*Directive{}
+
+	for p.peek("@") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+
+		args, err := p.parseArguments()
+		if err != nil {
+			return nil, err
+		}
+
+		directives = append(directives, &Directive{Name: name, Arguments: args})
+	}
+
+	return directives, nil
+}
+
+func (p *parser) parseSelectionSet() ([]Selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	selections := []Selection{}
+
+	for !p.peek("}") {
+		if p.tok.kind == tokenEOF {
+			return nil, p.errorf("unterminated selection set")
+		}
+
+		selection, err := p.parseSelection()
+		if err != nil {
+			return nil, err
+		}
+
+		selections = append(selections, selection)
+	}
+
+	if len(selections) == 0 {
+		return nil, p.errorf("empty selection set")
+	}
+
+	return selections, p.expect("}")
+}
+
+func (p *parser) parseSelection() (Selection, error) {
+	if ok, err := p.skip("..."); err != nil {
+		return nil, err
+	} else if ok {
+		if p.tok.kind == tokenName && p.tok.value != "on" {
+			spread := &FragmentSpread{Name: p.tok.value}
+
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+
+			directives, err := p.parseDirectives()
+			if err != nil {
+				return nil, err
+			}
+
+			spread.Directives = directives
+
+			return spread, nil
+		}
+
+		fragment := &InlineFragment{}
+
+		if p.peekName("on") {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+
+			name, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+
+			fragment.TypeCondition = name
+		}
+
+		directives, err := p.parseDirectives()
+		if err != nil {
+			return nil, err
+		}
+
+		fragment.Directives = directives
+
+		selections, err := p.parseSelectionSet()
+		if err != nil {
+			return nil, err
+		}
+
+		fragment.Selections = selections
+
+		return fragment, nil
+	}
+
+	field := &Field{}
+
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+
+	if ok, err := p.skip(":"); err != nil {
+		return nil, err
+	} else if ok {
+		field.Alias = name
+
+		if name, err = p.expectName(); err != nil {
+			return nil, err
+		}
+	}
+
+	field.Name = name
+
+	if field.Arguments, err = p.parseArguments(); err != nil {
+		return nil, err
+	}
+
+	if field.Directives, err = p.parseDirectives(); err != nil {
+		return nil, err
+	}
+
+	if p.peek("{") {
+		if field.Selections, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+
+	return field, nil
+}
+
+func (p *parser) parseFragment() (*FragmentDefinition, error) {
+	if err := p.expectKeyword("fragment"); err != nil {
+		return nil, err
+	}
+
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.expectKeyword("on"); err != nil {
+		return nil, err
+	}
+
+	typeCondition, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+
+	directives, err := p.parseDirectives()
+	if err != nil {
+		return nil, err
+	}
+
+	selections, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+
+	return &FragmentDefinition{
+		Name:          name,
+		TypeCondition: typeCondition,
+		Directives:    directives,
+		Selections:    selections,
+	}, nil
+}
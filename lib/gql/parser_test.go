@@ -0,0 +1,166 @@
+package gql
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDocument(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		// want is the document printed back, or the start of the error
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "shorthand query",
+			src:  "{ me { id } }",
+			want: "query { me { id } }",
+		},
+		{
+			name: "variables with defaults and lists",
+			src:  "query Q($id: ID!, $first: Int = 10, $names: [String!]) { node(id: $id, first: $first, names: $names) { id } }",
+			want: "query Q ($id: ID!, $first: Int = 10, $names: [String!]) { node(id: $id, first: $first, names: $names) { id } }",
+		},
+		{
+			name: "commas and comments are ignored",
+			src:  "mutation {\n  # log out\n  logout,\n}",
+			want: "mutation { logout }",
+		},
+		{
+			name: "aliases, directives and object values",
+			src:  `query ($logs: Boolean!) { latest: deployment(where: { status: "SUCCESS", tags: [] }) { logs @include(if: $logs) } }`,
+			want: `query ($logs: Boolean!) { latest: deployment(where: { status: "SUCCESS", tags: [] }) { logs @include(if: $logs) } }`,
+		},
+		{
+			name: "fragments",
+			src:  "query { me { ...UserFields ... on User { email } } } fragment UserFields on User { id }",
+			want: "query { me { ...UserFields ... on User { email } } } fragment UserFields on User { id }",
+		},
+		{
+			name: "escaped strings",
+			src:  `{ echo(text: "a \"b\"\u00e9\/") }`,
+			want: `query { echo(text: "a \"b\"é/") }`,
+		},
+		{
+			name:    "unclosed selection set",
+			src:     "query { me { id }",
+			want:    "1:18: unterminated selection set",
+			wantErr: true,
+		},
+		{
+			name:    "variable in a default value",
+			src:     "query ($a: Int = $b) { me }",
+			want:    "1:18: unexpected variable in constant value",
+			wantErr: true,
+		},
+		{
+			name:    "fragment defined twice",
+			src:     "{ me { ...F } } fragment F on User { id } fragment F on User { id }",
+			want:    `1:68: fragment "F" is defined more than once`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid escape",
+			src:     `{ echo(text: "\x") }`,
+			want:    "1:15: invalid escape sequence",
+			wantErr: true,
+		},
+		{
+			name:    "unterminated string",
+			src:     `{ echo(text: "abc) }`,
+			want:    "1:21: unterminated string",
+			wantErr: true,
+		},
+		{
+			name:    "schema definitions",
+			src:     "type User { id: ID! }",
+			want:    "1:1: unexpected",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := ParseDocument(tt.src)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parsed %q as %q, want an error", tt.src, Print(doc))
+				}
+
+				if !strings.HasPrefix(err.Error(), tt.want) {
+					t.Errorf("error = %q, want it to start with %q", err, tt.want)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := Print(doc); got != tt.want {
+				t.Errorf("Print() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{
+			name: "every kind of definition",
+			src: `
+				"""A user"""
+				schema { query: RootQuery }
+				directive @auth(role: String) repeatable on FIELD_DEFINITION | OBJECT
+				scalar DateTime
+				interface Node { id: ID! }
+				type User implements Node @auth { id: ID! name(upper: Boolean = false): String }
+				union Actor = User
+				enum Role { ADMIN MEMBER }
+				input UserFilter { role: Role = MEMBER }
+				type RootQuery { users(filter: UserFilter): [User!]! }
+			`,
+		},
+		{
+			name:    "unknown type",
+			src:     "type Query { me: User }",
+			wantErr: `Query.me: unknown type "User"`,
+		},
+		{
+			name:    "type defined twice",
+			src:     "scalar Json scalar Json",
+			wantErr: `type "Json" is defined more than once`,
+		},
+		{
+			name:    "extensions",
+			src:     "extend type Query { me: String }",
+			wantErr: `unsupported definition "extend"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSchema(tt.src)
+
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				return
+			}
+
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
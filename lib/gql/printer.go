@@ -0,0 +1,376 @@
+package gql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Print renders a document on a single line. The output only depends on the
+// document, so identical documents always print to identical strings
+func Print(doc *Document) string {
+	var sb strings.Builder
+
+	for i, op := range doc.Operations {
+		if i > 0 {
+			sb.WriteString(" ")
+		}
+
+		printOperation(&sb, op)
+	}
+
+	names := make([]string, 0, len(doc.Fragments))
+
+	for name := range doc.Fragments {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		fragment := doc.Fragments[name]
+
+		if sb.Len() > 0 {
+			sb.WriteString(" ")
+		}
+
+		sb.WriteString("fragment " + fragment.Name + " on " + fragment.TypeCondition)
+		printDirectives(&sb, fragment.Directives)
+		printSelections(&sb, fragment.Selections)
+	}
+
+	return sb.String()
+}
+
+func printOperation(sb *strings.Builder, op *OperationDefinition) {
+	sb.WriteString(op.Type)
+
+	if op.Name != "" {
+		sb.WriteString(" " + op.Name)
+	}
+
+	if len(op.Variables) > 0 {
+		sb.WriteString(" (")
+
+		for i, def := range op.Variables {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+
+			sb.WriteString("$" + def.Name + ": " + def.Type.String())
+
+			if def.Default != nil {
+				sb.WriteString(" = ")
+				printValue(sb, def.Default)
+			}
+		}
+
+		sb.WriteString(")")
+	}
+
+	printDirectives(sb, op.Directives)
+	printSelections(sb, op.Selections)
+}
+
+func printSelections(sb *strings.Builder, selections []Selection) {
+	if len(selections) == 0 {
+		return
+	}
+
+	sb.WriteString(" {")
+
+	for _, selection := range selections {
+		sb.WriteString(" ")
+
+		switch sel := selection.(type) {
+		case *Field:
+			if sel.Alias != "" {
+				sb.WriteString(sel.Alias + ": ")
+			}
+
+			sb.WriteString(sel.Name)
+			printArguments(sb, sel.Arguments)
+			printDirectives(sb, sel.Directives)
+			printSelections(sb, sel.Selections)
+
+		case *FragmentSpread:
+			sb.WriteString("..." + sel.Name)
+			printDirectives(sb, sel.Directives)
+
+		case *InlineFragment:
+			sb.WriteString("...")
+
+			if sel.TypeCondition != "" {
+				sb.WriteString(" on " + sel.TypeCondition)
+			}
+
+			printDirectives(sb, sel.Directives)
+			printSelections(sb, sel.Selections)
+		}
+	}
+
+	sb.WriteString(" }")
+}
+
+func printArguments(sb *strings.Builder, args []*Argument) {
+	if len(args) == 0 {
+		return
+	}
+
+	sb.WriteString("(")
+
+	for i, arg := range args {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+
+		sb.WriteString(arg.Name + ": ")
+		printValue(sb, arg.Value)
+	}
+
+	sb.WriteString(")")
+}
+
+func printDirectives(sb *strings.Builder, directives []*Directive) {
+	for _, directive := range directives {
+		sb.WriteString(" @" + directive.Name)
+		printArguments(sb, directive.Arguments)
+	}
+}
+
+func printValue(sb *strings.Builder, value *Value) {
+	switch {
+	case value.Variable != "":
+		sb.WriteString("$" + value.Variable)
+
+	case value.IsList:
+		sb.WriteString("[")
+
+		for i, item := range value.List {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+
+			printValue(sb, item)
+		}
+
+		sb.WriteString("]")
+
+	case value.IsObject:
+		sb.WriteString("{")
+
+		for i, field := range value.Fields {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+
+			sb.WriteString(" " + field.Name + ": ")
+			printValue(sb, field.Value)
+		}
+
+		sb.WriteString(" }")
+
+	default:
+		sb.WriteString(value.Literal)
+	}
+}
+
+// quoteString renders s as a GraphQL string literal
+func quoteString(s string) string {
+	var sb strings.Builder
+
+	sb.WriteByte('"')
+
+	for _, r := range s {
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\b':
+			sb.WriteString(`\b`)
+		case '\f':
+			sb.WriteString(`\f`)
+		default:
+			if r < 0x20 {
+				sb.WriteString(fmt.Sprintf(`\u%04x`, r))
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+
+	sb.WriteByte('"')
+
+	return sb.String()
+}
+
+// PrintSchema renders a schema definition document: custom scalars, the root
+// types, then the other types, each sorted by name, as are their fields,
+// arguments and values. Like Print, equal schemas print to equal strings
+func PrintSchema(schema *Schema) string {
+	var scalars, roots, others []string
+
+	for name, def := range schema.Types {
+		switch {
+		case def.Kind == KindScalar:
+			if !isBuiltinScalar(name) {
+				scalars = append(scalars, name)
+			}
+		case name == schema.Query || name == schema.Mutation || name == schema.Subscription:
+		default:
+			others = append(others, name)
+		}
+	}
+
+	for _, name := range []string{schema.Query, schema.Mutation, schema.Subscription} {
+		if _, ok := schema.Types[name]; ok {
+			roots = append(roots, name)
+		}
+	}
+
+	sort.Strings(scalars)
+	sort.Strings(others)
+
+	var blocks []string
+
+	if schema.Query != "Query" || schema.Mutation != "Mutation" || schema.Subscription != "Subscription" {
+		var sb strings.Builder
+
+		sb.WriteString("schema {\n")
+
+		for _, root := range []struct{ operation, name string }{
+			{"query", schema.Query}, {"mutation", schema.Mutation}, {"subscription", schema.Subscription},
+		} {
+			if _, ok := schema.Types[root.name]; ok {
+				sb.WriteString("  " + root.operation + ": " + root.name + "\n")
+			}
+		}
+
+		sb.WriteString("}")
+		blocks = append(blocks, sb.String())
+	}
+
+	if len(scalars) > 0 {
+		lines := make([]string, len(scalars))
+
+		for i, name := range scalars {
+			lines[i] = "scalar " + name
+		}
+
+		blocks = append(blocks, strings.Join(lines, "\n"))
+	}
+
+	for _, name := range append(roots, others...) {
+		blocks = append(blocks, printTypeDefinition(schema.Types[name]))
+	}
+
+	return strings.Join(blocks, "\n\n") + "\n"
+}
+
+func printTypeDefinition(def *TypeDefinition) string {
+	var sb strings.Builder
+
+	switch def.Kind {
+	case KindUnion:
+		return "union " + def.Name + " = " + strings.Join(sortedStrings(def.Types), " | ")
+
+	case KindEnum:
+		sb.WriteString("enum " + def.Name + " {\n")
+
+		values := make([]string, 0, len(def.EnumValues))
+
+		for value := range def.EnumValues {
+			values = append(values, value)
+		}
+
+		for _, value := range sortedStrings(values) {
+			sb.WriteString("  " + value + "\n")
+		}
+
+	case KindInputObject:
+		sb.WriteString("input " + def.Name + " {\n")
+
+		for _, name := range sortedKeys(def.InputFields) {
+			sb.WriteString("  " + printInputValueDefinition(def.InputFields[name]) + "\n")
+		}
+
+	default:
+		keyword := "type"
+		if def.Kind == KindInterface {
+			keyword = "interface"
+		}
+
+		sb.WriteString(keyword + " " + def.Name)
+
+		if len(def.Types) > 0 {
+			sb.WriteString(" implements " + strings.Join(sortedStrings(def.Types), " & "))
+		}
+
+		sb.WriteString(" {\n")
+
+		names := make([]string, 0, len(def.Fields))
+
+		for name := range def.Fields {
+			names = append(names, name)
+		}
+
+		for _, name := range sortedStrings(names) {
+			field := def.Fields[name]
+
+			sb.WriteString("  " + field.Name)
+
+			if len(field.Arguments) > 0 {
+				args := make([]string, 0, len(field.Arguments))
+
+				for _, arg := range sortedKeys(field.Arguments) {
+					args = append(args, printInputValueDefinition(field.Arguments[arg]))
+				}
+
+				sb.WriteString("(" + strings.Join(args, ", ") + ")")
+			}
+
+			sb.WriteString(": " + field.Type.String() + "\n")
+		}
+	}
+
+	sb.WriteString("}")
+
+	return sb.String()
+}
+
+func printInputValueDefinition(def *InputValueDefinition) string {
+	s := def.Name + ": " + def.Type.String()
+
+	if def.Default != nil {
+		var sb strings.Builder
+
+		printValue(&sb, def.Default)
+		s += " = " + sb.String()
+	}
+
+	return s
+}
+
+func sortedStrings(s []string) []string {
+	sorted := append([]string{}, s...)
+	sort.Strings(sorted)
+
+	return sorted
+}
+
+func isBuiltinScalar(name string) bool {
+	for _, builtin := range builtinScalars {
+		if name == builtin {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,585 @@
+package gql
+
+import "fmt"
+
+// ParseSchema parses a schema definition document. Descriptions and
+// directives are accepted but not kept
+func ParseSchema(src string) (*Schema, error) {
+	p, err := newParser(src)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := &Schema{
+		Types:        make(map[string]*TypeDefinition),
+		Query:        "Query",
+		Mutation:     "Mutation",
+		Subscription: "Subscription",
+	}
+
+	for _, name := range builtinScalars {
+		schema.Types[name] = &TypeDefinition{Kind: KindScalar, Name: name}
+	}
+
+	for p.tok.kind != tokenEOF {
+		if err := p.skipDescription(); err != nil {
+			return nil, err
+		}
+
+		keyword, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+
+		var def *TypeDefinition
+
+		switch keyword {
+		case "schema":
+			if err := p.parseSchemaDefinition(schema); err != nil {
+				return nil, err
+			}
+
+			continue
+
+		case "directive":
+			if err := p.skipDirectiveDefinition(); err != nil {
+				return nil, err
+			}
+
+			continue
+
+		case "scalar":
+			def, err = p.parseTypeHeader(KindScalar)
+
+		case "type":
+			if def, err = p.parseTypeHeader(KindObject); err == nil {
+				def.Fields, err = p.parseFieldDefinitions()
+			}
+
+		case "interface":
+			if def, err = p.parseTypeHeader(KindInterface); err == nil {
+				def.Fields, err = p.parseFieldDefinitions()
+			}
+
+		case "input":
+			if def, err = p.parseTypeHeader(KindInputObject); err == nil {
+				def.InputFields, err = p.parseInputFieldDefinitions()
+			}
+
+		case "enum":
+			if def, err = p.parseTypeHeader(KindEnum); err == nil {
+				def.EnumValues, err = p.parseEnumValues()
+			}
+
+		case "union":
+			if def, err = p.parseTypeHeader(KindUnion); err == nil {
+				def.Types, err = p.parseUnionMembers()
+			}
+
+		default:
+			return nil, fmt.Errorf("unsupported definition %q", keyword)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if _, exists := schema.Types[def.Name]; exists {
+			return nil, fmt.Errorf("type %q is defined more than once", def.Name)
+		}
+
+		schema.Types[def.Name] = def
+	}
+
+	return schema, schema.check()
+}
+
+// check makes sure every type referenced by the schema is defined
+func (s *Schema) check() error {
+	known := func(ref *TypeRef) error {
+		if _, ok := s.Types[ref.Named()]; !ok {
+			return fmt.Errorf("unknown type %q", ref.Named())
+		}
+
+		return nil
+	}
+
+	for _, def := range s.Types {
+		for _, field := range def.Fields {
+			if err := known(field.Type); err != nil {
+				return fmt.Errorf("%s.%s: %w", def.Name, field.Name, err)
+			}
+
+			for _, arg := range field.Arguments {
+				if err := known(arg.Type); err != nil {
+					return fmt.Errorf("%s.%s(%s): %w", def.Name, field.Name, arg.Name, err)
+				}
+			}
+		}
+
+		for _, field := range def.InputFields {
+			if err := known(field.Type); err != nil {
+				return fmt.Errorf("%s.%s: %w", def.Name, field.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *parser) skipDescription() error {
+	if p.tok.kind == tokenString {
+		return p.advance()
+	}
+
+	return nil
+}
+
+func (p *parser) parseSchemaDefinition(schema *Schema) error {
+	if _, err := p.parseDirectives(); err != nil {
+		return err
+	}
+
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+
+	for !p.peek("}") {
+		operation, err := p.expectName()
+		if err != nil {
+			return err
+		}
+
+		if err := p.expect(":"); err != nil {
+			return err
+		}
+
+		name, err := p.expectName()
+		if err != nil {
+			return err
+		}
+
+		switch operation {
+		case "query":
+			schema.Query = name
+		case "mutation":
+			schema.Mutation = name
+		case "subscription":
+			schema.Subscription = name
+		default:
+			return p.errorf("unknown operation type %q", operation)
+		}
+	}
+
+	return p.expect("}")
+}
+
+func (p *parser) skipDirectiveDefinition() error {
+	if err := p.expect("@"); err != nil {
+		return err
+	}
+
+	if _, err := p.expectName(); err != nil {
+		return err
+	}
+
+	if p.peek("(") {
+		if _, err := p.parseInputValueDefinitions("(", ")"); err != nil {
+			return err
+		}
+	}
+
+	if p.peekName("repeatable") {
+		if err := p.advance(); err != nil {
+			return err
+		}
+	}
+
+	if err := p.expectKeyword("on"); err != nil {
+		return err
+	}
+
+	if _, err := p.skip("|"); err != nil {
+		return err
+	}
+
+	for {
+		if _, err := p.expectName(); err != nil {
+			return err
+		}
+
+		if ok, err := p.skip("|"); err != nil {
+			return err
+		} else if !ok {
+			return nil
+		}
+	}
+}
+
+func (p *parser) parseTypeHeader(kind TypeKind) (*TypeDefinition, error) {
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+
+	def := &TypeDefinition{Kind: kind, Name: name}
+
+	if p.peekName("implements") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		if _, err := p.skip("&"); err != nil {
+			return nil, err
+		}
+
+		for p.tok.kind == tokenName {
+			def.Types = append(def.Types, p.tok.value)
+
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+
+			if _, err := p.skip("&"); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if _, err := p.parseDirectives(); err != nil {
+		return nil, err
+	}
+
+	return def, nil
+}
+
+func (p *parser) parseFieldDefinitions() (map[string]*FieldDefinition, error) {
+	fields := make(map[string]*FieldDefinition)
+
+	if !p.peek("{") {
+		return fields, nil
+	}
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	for !p.peek("}") {
+		if err := p.skipDescription(); err != nil {
+			return nil, err
+		}
+
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+
+		field := &FieldDefinition{
+			Name:      name,
+			Arguments: make(map[string]*InputValueDefinition),
+		}
+
+		if p.peek("(") {
+			if field.Arguments, err = p.parseInputValueDefinitions("(", ")"); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+
+		if field.Type, err = p.parseType(); err != nil {
+			return nil, err
+		}
+
+		if _, err := p.parseDirectives(); err != nil {
+			return nil, err
+		}
+
+		fields[name] = field
+	}
+
+	return fields, p.expect("}")
+}
+
+func (p *parser) parseInputFieldDefinitions() (map[string]*InputValueDefinition, error) {
+	if !p.peek("{") {
+		return make(map[string]*InputValueDefinition), nil
+	}
+
+	return p.parseInputValueDefinitions("{", "}")
+}
+
+func (p *parser) parseInputValueDefinitions(open, close string) (map[string]*InputValueDefinition, error) {
+	if err := p.expect(open); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]*InputValueDefinition)
+
+	for !p.peek(close) {
+		if err := p.skipDescription(); err != nil {
+			return nil, err
+		}
+
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+
+		value := &InputValueDefinition{Name: name}
+
+		if value.Type, err = p.parseType(); err != nil {
+			return nil, err
+		}
+
+		if ok, err := p.skip("="); err != nil {
+			return nil, err
+		} else if ok {
+			if value.Default, err = p.parseValue(true); err != nil {
+				return nil, err
+			}
+		}
+
+		if _, err := p.parseDirectives(); err != nil {
+			return nil, err
+		}
+
+		values[name] = value
+	}
+
+	return values, p.expect(close)
+}
+
+func (p *parser) parseEnumValues() (map[string]bool, error) {
+	values := make(map[string]bool)
+
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	for !p.peek("}") {
+		if err := p.skipDescription(); err != nil {
+			return nil, err
+		}
+
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := p.parseDirectives(); err != nil {
+			return nil, err
+		}
+
+		values[name] = true
+	}
+
+	return values, p.expect("}")
+}
+
+func (p *parser) parseUnionMembers() ([]string, error) {
+	if err := p.expect("="); err != nil {
+		return nil, err
+	}
+
+	if _, err := p.skip("|"); err != nil {
+		return nil, err
+	}
+
+	members := []string{}
+
+	for {
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+
+		members = append(members, name)
+
+		if ok, err := p.skip("|"); err != nil {
+			return nil, err
+		} else if !ok {
+			return members, nil
+		}
+	}
+}
+
+// Subset returns the part of the schema the documents use: the fields they
+// select, with the arguments they pass and the required ones, and the types
+// of those. Enums and input objects are kept whole, since any of their values
+// may be sent. The documents must be valid against the schema
+func (s *Schema) Subset(docs ...*Document) (*Schema, error) {
+	sub := &subsetter{
+		from: s,
+		to: &Schema{
+			Types:        make(map[string]*TypeDefinition),
+			Query:        s.Query,
+			Mutation:     s.Mutation,
+			Subscription: s.Subscription,
+		},
+	}
+
+	for _, name := range builtinScalars {
+		sub.to.Types[name] = s.Types[name]
+	}
+
+	for _, doc := range docs {
+		if err := s.Validate(doc); err != nil {
+			return nil, err
+		}
+
+		sub.doc = doc
+
+		for _, op := range doc.Operations {
+			sub.selections(s.RootType(op.Type), op.Selections)
+		}
+	}
+
+	sub.interfaces()
+
+	return sub.to, sub.to.check()
+}
+
+type subsetter struct {
+	from *Schema
+	to   *Schema
+	doc  *Document
+}
+
+// composite returns the copy of a composite type, adding it without fields
+// the first time
+func (s *subsetter) composite(name string) *TypeDefinition {
+	if def, ok := s.to.Types[name]; ok {
+		return def
+	}
+
+	from := s.from.Types[name]
+	def := &TypeDefinition{
+		Kind:  from.Kind,
+		Name:  from.Name,
+		Types: append([]string{}, from.Types...),
+	}
+
+	if from.Fields != nil {
+		def.Fields = make(map[string]*FieldDefinition)
+	}
+
+	s.to.Types[name] = def
+
+	return def
+}
+
+// whole adds a leaf or input type as it is, with the types of its fields
+func (s *subsetter) whole(name string) {
+	if _, ok := s.to.Types[name]; ok {
+		return
+	}
+
+	def := s.from.Types[name]
+	s.to.Types[name] = def
+
+	for _, field := range def.InputFields {
+		s.whole(field.Type.Named())
+	}
+}
+
+// interfaces gives interfaces their leaf fields, so that none is empty, and
+// the types implementing them every field they kept. Interfaces and union
+// members that didn't make it in are dropped
+func (s *subsetter) interfaces() {
+	for _, def := range s.to.Types {
+		if def.Kind != KindInterface {
+			continue
+		}
+
+		for _, field := range s.from.Types[def.Name].Fields {
+			if _, ok := def.Fields[field.Name]; !ok && !s.from.Types[field.Type.Named()].Kind.composite() {
+				s.field(s.from.Types[def.Name], def, &Field{Name: field.Name})
+			}
+		}
+	}
+
+	for _, def := range s.to.Types {
+		var kept []string
+
+		for _, name := range def.Types {
+			iface, ok := s.to.Types[name]
+			if !ok {
+				continue
+			}
+
+			kept = append(kept, name)
+
+			if iface.Kind != KindInterface {
+				continue
+			}
+
+			for fieldName, field := range iface.Fields {
+				if _, ok := def.Fields[fieldName]; !ok {
+					def.Fields[fieldName] = field
+				}
+			}
+		}
+
+		def.Types = kept
+	}
+}
+
+func (s *subsetter) selections(parent *TypeDefinition, selections []Selection) {
+	into := s.composite(parent.Name)
+
+	for _, selection := range selections {
+		switch sel := selection.(type) {
+		case *Field:
+			if sel.Name != "__typename" {
+				s.field(parent, into, sel)
+			}
+
+		case *FragmentSpread:
+			fragment := s.doc.Fragments[sel.Name]
+			s.selections(s.from.Types[fragment.TypeCondition], fragment.Selections)
+
+		case *InlineFragment:
+			typ := parent
+			if sel.TypeCondition != "" {
+				typ = s.from.Types[sel.TypeCondition]
+			}
+
+			s.selections(typ, sel.Selections)
+		}
+	}
+}
+
+func (s *subsetter) field(parent, into *TypeDefinition, field *Field) {
+	def := parent.Fields[field.Name]
+
+	copied, ok := into.Fields[field.Name]
+	if !ok {
+		copied = &FieldDefinition{Name: def.Name, Type: def.Type, Arguments: make(map[string]*InputValueDefinition)}
+		into.Fields[field.Name] = copied
+
+		for _, arg := range def.Arguments {
+			if arg.Type.NonNull && arg.Default == nil {
+				copied.Arguments[arg.Name] = arg
+				s.whole(arg.Type.Named())
+			}
+		}
+	}
+
+	for _, arg := range field.Arguments {
+		copied.Arguments[arg.Name] = def.Arguments[arg.Name]
+		s.whole(def.Arguments[arg.Name].Type.Named())
+	}
+
+	if typ := s.from.Types[def.Type.Named()]; typ.Kind.composite() {
+		s.selections(typ, field.Selections)
+	} else {
+		s.whole(typ.Name)
+	}
+}
@@ -0,0 +1,432 @@
+package gql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ValidationErrors lists every problem found while validating a document
+type ValidationErrors []string
+
+func (e ValidationErrors) Error() string {
+	return strings.Join(e, "\n")
+}
+
+type validator struct {
+	schema *Schema
+	doc    *Document
+	errs   ValidationErrors
+
+	// State of the operation being validated
+	operation string
+	variables map[string]*VariableDefinition
+	used      map[string]bool
+	visiting  map[string]bool
+
+	usedFragments map[string]bool
+}
+
+// Validate checks that every field, argument, variable and fragment in the
+// document exists in the schema and is used with a compatible type
+func (s *Schema) Validate(doc *Document) error {
+	v := &validator{
+		schema:        s,
+		doc:           doc,
+		usedFragments: make(map[string]bool),
+	}
+
+	for _, op := range doc.Operations {
+		v.validateOperation(op)
+	}
+
+	names := make([]string, 0, len(doc.Fragments))
+
+	for name := range doc.Fragments {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !v.usedFragments[name] {
+			v.errorf("fragment %q is never used", name)
+		}
+	}
+
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+
+	return nil
+}
+
+func (v *validator) errorf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+
+	if v.operation != "" {
+		msg = v.operation + ": " + msg
+	}
+
+	v.errs = append(v.errs, msg)
+}
+
+func (v *validator) validateOperation(op *OperationDefinition) {
+	v.operation = op.Type
+	if op.Name != "" {
+		v.operation += " " + op.Name
+	}
+
+	v.variables = make(map[string]*VariableDefinition)
+	v.used = make(map[string]bool)
+	v.visiting = make(map[string]bool)
+
+	root := v.schema.RootType(op.Type)
+	if root == nil {
+		v.errorf("schema does not support %s operations", op.Type)
+		return
+	}
+
+	for _, def := range op.Variables {
+		if _, exists := v.variables[def.Name]; exists {
+			v.errorf("variable $%s is declared more than once", def.Name)
+			continue
+		}
+
+		v.variables[def.Name] = def
+
+		typ, ok := v.schema.Types[def.Type.Named()]
+
+		switch {
+		case !ok:
+			v.errorf("variable $%s has unknown type %s", def.Name, def.Type)
+		case typ.Kind != KindScalar && typ.Kind != KindEnum && typ.Kind != KindInputObject:
+			v.errorf("variable $%s must have an input type, not %s", def.Name, def.Type)
+		}
+	}
+
+	v.validateDirectives(op.Directives)
+	v.validateSelections(root, op.Selections)
+
+	for _, def := range op.Variables {
+		if !v.used[def.Name] {
+			v.errorf("variable $%s is never used", def.Name)
+		}
+	}
+
+	v.operation = ""
+}
+
+func (v *validator) validateSelections(parent *TypeDefinition, selections []Selection) {
+	for _, selection := range selections {
+		switch sel := selection.(type) {
+		case *Field:
+			v.validateField(parent, sel)
+
+		case *FragmentSpread:
+			v.validateDirectives(sel.Directives)
+
+			fragment, ok := v.doc.Fragments[sel.Name]
+			if !ok {
+				v.errorf("fragment %q is not defined", sel.Name)
+				continue
+			}
+
+			v.usedFragments[sel.Name] = true
+
+			if v.visiting[sel.Name] {
+				v.errorf("fragment %q spreads itself", sel.Name)
+				continue
+			}
+
+			if typ := v.fragmentType(fragment.TypeCondition); typ != nil {
+				v.visiting[sel.Name] = true
+				v.validateDirectives(fragment.Directives)
+				v.validateSelections(typ, fragment.Selections)
+				delete(v.visiting, sel.Name)
+			}
+
+		case *InlineFragment:
+			v.validateDirectives(sel.Directives)
+
+			typ := parent
+			if sel.TypeCondition != "" {
+				typ = v.fragmentType(sel.TypeCondition)
+			}
+
+			if typ != nil {
+				v.validateSelections(typ, sel.Selections)
+			}
+		}
+	}
+}
+
+func (v *validator) fragmentType(name string) *TypeDefinition {
+	typ, ok := v.schema.Types[name]
+	if !ok {
+		v.errorf("fragment on unknown type %s", name)
+		return nil
+	}
+
+	if !typ.Kind.composite() {
+		v.errorf("fragment on %s, which is not an object, interface or union", name)
+		return nil
+	}
+
+	return typ
+}
+
+func (v *validator) validateField(parent *TypeDefinition, field *Field) {
+	v.validateDirectives(field.Directives)
+
+	if field.Name == "__typename" {
+		if len(field.Arguments) > 0 || len(field.Selections) > 0 {
+			v.errorf("__typename takes no arguments or subfields")
+		}
+
+		return
+	}
+
+	def, ok := parent.Fields[field.Name]
+	if !ok {
+		v.errorf("type %s has no field %q", parent.Name, field.Name)
+		return
+	}
+
+	where := parent.Name + "." + field.Name
+	v.validateArguments(where, def.Arguments, field.Arguments)
+
+	typ := v.schema.Types[def.Type.Named()]
+
+	switch {
+	case typ.Kind.composite() && len(field.Selections) == 0:
+		v.errorf("field %s of type %s must select subfields", where, def.Type)
+	case !typ.Kind.composite() && len(field.Selections) > 0:
+		v.errorf("field %s of type %s cannot select subfields", where, def.Type)
+	case typ.Kind.composite():
+		v.validateSelections(typ, field.Selections)
+	}
+}
+
+var builtinDirectives = map[string]map[string]*InputValueDefinition{
+	"include": {"if": {Name: "if", Type: &TypeRef{Name: "Boolean", NonNull: true}}},
+	"skip":    {"if": {Name: "if", Type: &TypeRef{Name: "Boolean", NonNull: true}}},
+}
+
+func (v *validator) validateDirectives(directives []*Directive) {
+	for _, directive := range directives {
+		args, ok := builtinDirectives[directive.Name]
+		if !ok {
+			v.errorf("unknown directive @%s", directive.Name)
+			continue
+		}
+
+		v.validateArguments("@"+directive.Name, args, directive.Arguments)
+	}
+}
+
+func (v *validator) validateArguments(where string, defs map[string]*InputValueDefinition, args []*Argument) {
+	seen := make(map[string]bool)
+
+	for _, arg := range args {
+		def, ok := defs[arg.Name]
+		if !ok {
+			v.errorf("%s has no argument %q", where, arg.Name)
+			continue
+		}
+
+		if seen[arg.Name] {
+			v.errorf("argument %q of %s is given more than once", arg.Name, where)
+		}
+
+		seen[arg.Name] = true
+
+		v.validateValue(where+"("+arg.Name+")", def, arg.Value)
+	}
+
+	for _, name := range sortedKeys(defs) {
+		def := defs[name]
+
+		if def.Type.NonNull && def.Default == nil && !seen[name] {
+			v.errorf("%s is missing required argument %q", where, name)
+		}
+	}
+}
+
+func (v *validator) validateValue(where string, def *InputValueDefinition, value *Value) {
+	if value.Variable != "" {
+		variable, ok := v.variables[value.Variable]
+		if !ok {
+			v.errorf("variable $%s is not declared", value.Variable)
+			return
+		}
+
+		v.used[value.Variable] = true
+
+		hasDefault := variable.Default != nil || def.Default != nil
+
+		if !variableAllowed(variable.Type, hasDefault, def.Type) {
+			v.errorf("variable $%s of type %s cannot be used for %s of type %s", value.Variable, variable.Type, where, def.Type)
+		}
+
+		return
+	}
+
+	v.validateLiteral(where, def.Type, value)
+}
+
+func (v *validator) validateLiteral(where string, expected *TypeRef, value *Value) {
+	if value.IsNull {
+		if expected.NonNull {
+			v.errorf("%s cannot be null", where)
+		}
+
+		return
+	}
+
+	if expected.Elem != nil {
+		if !value.IsList {
+			// A single value is coerced to a list of one
+			v.validateLiteral(where, expected.Elem, value)
+			return
+		}
+
+		for _, item := range value.List {
+			v.validateLiteralOrVariable(where, expected.Elem, item)
+		}
+
+		return
+	}
+
+	typ := v.schema.Types[expected.Name]
+
+	switch typ.Kind {
+	case KindInputObject:
+		if !value.IsObject {
+			v.errorf("%s expects an object of type %s", where, typ.Name)
+			return
+		}
+
+		seen := make(map[string]bool)
+
+		for _, field := range value.Fields {
+			def, ok := typ.InputFields[field.Name]
+			if !ok {
+				v.errorf("%s: input type %s has no field %q", where, typ.Name, field.Name)
+				continue
+			}
+
+			seen[field.Name] = true
+
+			v.validateValue(where+"."+field.Name, def, field.Value)
+		}
+
+		for _, name := range sortedKeys(typ.InputFields) {
+			def := typ.InputFields[name]
+
+			if def.Type.NonNull && def.Default == nil && !seen[name] {
+				v.errorf("%s is missing required field %q of %s", where, name, typ.Name)
+			}
+		}
+
+	case KindEnum:
+		if !typ.EnumValues[value.Literal] {
+			v.errorf("%s: %s is not a value of enum %s", where, value.Literal, typ.Name)
+		}
+
+	case KindScalar:
+		if !scalarLiteralAllowed(typ.Name, value) {
+			v.errorf("%s: invalid %s value %s", where, typ.Name, value.Literal)
+		}
+	}
+}
+
+func (v *validator) validateLiteralOrVariable(where string, expected *TypeRef, value *Value) {
+	if value.Variable != "" {
+		v.validateValue(where, &InputValueDefinition{Type: expected}, value)
+		return
+	}
+
+	v.validateLiteral(where, expected, value)
+}
+
+// variableAllowed reports whether a variable can be used where a value of
+// type expected is accepted. A nullable variable may only be passed to a
+// non-null location when either side has a default
+func variableAllowed(variable *TypeRef, hasDefault bool, expected *TypeRef) bool {
+	if expected.NonNull && !variable.NonNull {
+		if !hasDefault {
+			return false
+		}
+
+		nullable := *expected
+		nullable.NonNull = false
+		expected = &nullable
+	}
+
+	return typeCompatible(variable, expected)
+}
+
+func typeCompatible(variable, expected *TypeRef) bool {
+	if expected.NonNull && !variable.NonNull {
+		return false
+	}
+
+	if expected.Elem != nil {
+		return variable.Elem != nil && typeCompatible(variable.Elem, expected.Elem)
+	}
+
+	return variable.Elem == nil && variable.Name == expected.Name
+}
+
+// scalarLiteralAllowed checks literals of built-in scalars. Custom scalars
+// accept any value
+func scalarLiteralAllowed(scalar string, value *Value) bool {
+	isString := strings.HasPrefix(value.Literal, `"`)
+	isInt := value.Literal != "" && (isDigitString(value.Literal) || value.Literal[0] == '-' && isDigitString(value.Literal[1:]))
+
+	switch scalar {
+	case "String":
+		return isString
+	case "ID":
+		return isString || isInt
+	case "Int":
+		return isInt
+	case "Float":
+		return isInt || value.Literal != "" && !isString && !value.IsList && !value.IsObject && strings.ContainsAny(value.Literal, "0123456789")
+	case "Boolean":
+		return value.Literal == "true" || value.Literal == "false"
+	}
+
+	return true
+}
+
+func isDigitString(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (k TypeKind) composite() bool {
+	return k == KindObject || k == KindInterface || k == KindUnion
+}
+
+func sortedKeys(m map[string]*InputValueDefinition) []string {
+	keys := make([]string, 0, len(m))
+
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
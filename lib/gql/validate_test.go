@@ -0,0 +1,149 @@
+package gql
+
+import (
+	"strings"
+	"testing"
+)
+
+const testSchema = `
+	scalar Json
+
+	type Query {
+		me: User!
+		project(id: ID!, withServices: Boolean = false): Project
+		projects(first: Int, filter: ProjectFilter): [Project!]!
+	}
+
+	type Mutation {
+		setVariables(projectId: String!, variables: Json!): Boolean!
+	}
+
+	type User {
+		id: ID!
+		name: String
+		role: Role!
+	}
+
+	type Project {
+		id: ID!
+		name: String!
+		services: [Service!]!
+	}
+
+	type Service {
+		id: ID!
+	}
+
+	enum Role {
+		ADMIN
+		MEMBER
+	}
+
+	input ProjectFilter {
+		name: String!
+		role: Role
+	}
+`
+
+func TestValidate(t *testing.T) {
+	schema, err := ParseSchema(testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		doc  string
+		// wantErrs are the validation errors, none for valid documents
+		wantErrs []string
+	}{
+		{
+			name: "fields and variables",
+			doc:  `query ($id: ID!) { me { id name role } project(id: $id) { id services { id } } }`,
+		},
+		{
+			name: "literals and defaults",
+			doc:  `{ projects(first: 10, filter: { name: "web", role: ADMIN }) { id } project(id: 42) { __typename } }`,
+		},
+		{
+			name: "custom scalars take any literal",
+			doc:  `mutation { setVariables(projectId: "p", variables: { PORT: 3000 }) }`,
+		},
+		{
+			name: "a non-null variable for a nullable argument",
+			doc:  `query ($first: Int!) { projects(first: $first) { id } }`,
+		},
+		{
+			name: "fragments and directives",
+			doc:  `query ($full: Boolean!) { me { ...User @include(if: $full) } } fragment User on User { id }`,
+		},
+		{
+			name:     "unknown field",
+			doc:      `{ me { email } }`,
+			wantErrs: []string{`query: type User has no field "email"`},
+		},
+		{
+			name:     "missing required argument",
+			doc:      `{ project { id } }`,
+			wantErrs: []string{`query: Query.project is missing required argument "id"`},
+		},
+		{
+			name:     "unknown argument",
+			doc:      `{ projects(last: 1) { id } }`,
+			wantErrs: []string{`query: Query.projects has no argument "last"`},
+		},
+		{
+			name:     "nullable variable for a required argument",
+			doc:      `query ($id: ID) { project(id: $id) { id } }`,
+			wantErrs: []string{`query: variable $id of type ID cannot be used for Query.project(id) of type ID!`},
+		},
+		{
+			name:     "undeclared variable",
+			doc:      `{ project(id: $id) { id } }`,
+			wantErrs: []string{`query: variable $id is not declared`},
+		},
+		{
+			name:     "unused variable",
+			doc:      `query ($id: ID!) { me { id } }`,
+			wantErrs: []string{`query: variable $id is never used`},
+		},
+		{
+			name:     "leaf with subfields and object without",
+			doc:      `{ me { id { value } } project(id: 1) }`,
+			wantErrs: []string{`query: field User.id of type ID! cannot select subfields`, `query: field Query.project of type Project must select subfields`},
+		},
+		{
+			name:     "invalid literals",
+			doc:      `{ projects(first: "ten", filter: { role: OWNER }) { id } }`,
+			wantErrs: []string{`query: Query.projects(first): invalid Int value "ten"`, `query: Query.projects(filter).role: OWNER is not a value of enum Role`, `query: Query.projects(filter) is missing required field "name" of ProjectFilter`},
+		},
+		{
+			name:     "unused fragment",
+			doc:      `{ me { id } } fragment User on User { id }`,
+			wantErrs: []string{`fragment "User" is never used`},
+		},
+		{
+			name:     "unknown directive",
+			doc:      `{ me { id @defer } }`,
+			wantErrs: []string{`query: unknown directive @defer`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := ParseDocument(tt.doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			if err := schema.Validate(doc); err != nil {
+				got = err.(ValidationErrors)
+			}
+
+			if strings.Join(got, "\n") != strings.Join(tt.wantErrs, "\n") {
+				t.Errorf("errors:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.wantErrs, "\n"))
+			}
+		})
+	}
+}
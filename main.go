@@ -2,15 +2,20 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"runtime"
 	"runtime/debug"
 	"strings"
+	"syscall"
 
 	"github.com/botwayorg/railway-api/cmd"
 	"github.com/botwayorg/railway-api/constants"
 	"github.com/botwayorg/railway-api/entity"
+	CLIErrors "github.com/botwayorg/railway-api/errors"
+	"github.com/botwayorg/railway-api/gateway"
 	"github.com/botwayorg/railway-api/ui"
 	"github.com/spf13/cobra"
 )
@@ -30,10 +35,30 @@ func addRootCmd(cmd *cobra.Command) *cobra.Command {
 	return cmd
 }
 
+// commandContext returns the context a command runs in. It is cancelled on
+// SIGINT or SIGTERM, and once the --timeout elapses when one is set
+func commandContext(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	// Commands that parse their own flags, like run, can't take a timeout
+	timeout, err := cmd.Flags().GetDuration("timeout")
+	if err != nil || timeout <= 0 {
+		return ctx, stop
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
 // contextualize converts a HandlerFunction to a cobra function
 func contextualize(fn entity.HandlerFunction, panicFn entity.PanicFunction) entity.CobraFunction {
 	return func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
 
 		defer func() {
 			// Skip recover during development, so we can see the panic stack traces instead of going
@@ -43,7 +68,8 @@ func contextualize(fn entity.HandlerFunction, panicFn entity.PanicFunction) enti
 			}
 
 			if r := recover(); r != nil {
-				err := panicFn(ctx, fmt.Sprint(r), string(debug.Stack()), cmd.Name(), args)
+				// The command context may be what failed, relay with a fresh one
+				err := panicFn(context.Background(), fmt.Sprint(r), string(debug.Stack()), cmd.Name(), args)
 				if err != nil {
 					fmt.Println("Unable to relay panic to server. Are you connected to the internet?")
 				}
@@ -58,6 +84,23 @@ func contextualize(fn entity.HandlerFunction, panicFn entity.PanicFunction) enti
 		err := fn(ctx, req)
 
 		if err != nil {
+			// Whatever failed after the context ended failed because of it
+			switch ctx.Err() {
+			case context.Canceled:
+				cancel()
+				fmt.Print(ui.AlertWarning(CLIErrors.CommandInterrupted.Error()))
+				os.Exit(130)
+			case context.DeadlineExceeded:
+				err = CLIErrors.CommandTimedOut
+			}
+
+			// Requests failing because the user is unauthenticated get a message telling
+			// them to log in, instead of whatever the server said
+			var authErr *gateway.AuthError
+			if errors.As(err, &authErr) {
+				err = CLIErrors.UserNotAuthorized
+			}
+
 			fmt.Println(ui.AlertDanger(err.Error()))
 			os.Exit(1) // Set non-success exit code on error
 		}
@@ -71,6 +114,20 @@ func init() {
 	handler := cmd.New()
 
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "Print verbose output")
+	rootCmd.PersistentFlags().Bool("trace", false, "Log every API request with its latency, status and request ID")
+	rootCmd.PersistentFlags().String("har", "", "Write every API request of the session to a HAR file")
+	rootCmd.PersistentFlags().String("record", "", "Record every API request and response to a cassette file, with secrets redacted")
+	rootCmd.PersistentFlags().String("replay", "", "Answer API requests from a recorded cassette file instead of the network")
+	rootCmd.PersistentFlags().Bool("refresh", false, "Ignore cached projects and templates and fetch them again")
+	rootCmd.PersistentFlags().Duration("timeout", 0, "Give up on the command after this long, e.g. 5m")
+	rootCmd.PersistentFlags().Duration("request-timeout", gateway.DefaultRequestTimeout, "Give up on a single API request after this long")
+
+	cobra.OnInitialize(func() {
+		if err := handler.Configure(rootCmd.PersistentFlags()); err != nil {
+			fmt.Println(ui.AlertDanger(err.Error()))
+			os.Exit(1)
+		}
+	})
 
 	loginCmd := addRootCmd(&cobra.Command{
 		Use:   "login",
@@ -92,6 +149,7 @@ func init() {
 		Short:             "Associate existing project with current directory, may specify projectId as an argument",
 		PersistentPreRunE: contextualize(handler.CheckVersion, handler.Panic),
 		RunE:              contextualize(handler.Link, handler.Panic),
+		ValidArgsFunction: handler.CompleteProjects,
 	})
 
 	addRootCmd(&cobra.Command{
@@ -178,6 +236,66 @@ func init() {
 	upCmd.Flags().BoolP("detach", "d", false, "Detach from cloud build/deploy logs")
 	upCmd.Flags().StringP("environment", "e", "", "Specify an environment to up onto")
 	upCmd.Flags().StringP("service", "s", "", "Fetch variables accessible to a specific service")
+	upCmd.Flags().Bool("dry-run", false, "List the files that would be uploaded, without uploading them")
+	upCmd.Flags().Bool("explain", false, "With --dry-run, also show the ignore file and line that excluded each skipped path")
+	upCmd.Flags().String("symlinks", "", "How to upload symlinks: follow-within-root (default), preserve, follow or error. Overrides the policy of the project")
+	upCmd.Flags().Bool("strict", false, "Refuse to upload files that look like secrets or are too large, unless they are allowed")
+	upCmd.Flags().StringArray("allow", nil, "Upload a flagged file anyway, given as an ignore-style pattern. Can be repeated")
+	upCmd.Flags().String("max-file-size", "", "Flag files larger than this, e.g. 500MB (default 100MB)")
+	upCmd.Flags().Bool("incremental", false, "Only send the files the server doesn't have yet, falling back to a whole archive on servers without incremental uploads (experimental)")
+	upCmd.Flags().String("path", "", "Upload this directory instead of the project path or the directory of the service")
+
+	pathsCmd := addRootCmd(&cobra.Command{
+		Use:   "paths",
+		Short: "Show the directories uploaded for the services of a monorepo",
+		RunE:  contextualize(handler.Paths, handler.Panic),
+	})
+
+	pathsSetCmd := &cobra.Command{
+		Use:     "set service dir",
+		Short:   "Upload a service from a directory of the project, along with shared directories",
+		Long:    "Upload a service from a directory of the project. The directory becomes the root of the upload, and each shared directory is added to it under its path in the project.",
+		RunE:    contextualize(handler.PathsSet, handler.Panic),
+		Args:    cobra.ExactArgs(2),
+		Example: "  railway paths set worker worker --shared libs/common",
+	}
+
+	pathsSetCmd.Flags().StringArray("shared", nil, "Directory of the project to upload along with the service. Can be repeated")
+	pathsCmd.AddCommand(pathsSetCmd)
+
+	pathsCmd.AddCommand(&cobra.Command{
+		Use:     "unset service",
+		Short:   "Upload a service from the project path again",
+		RunE:    contextualize(handler.PathsUnset, handler.Panic),
+		Args:    cobra.ExactArgs(1),
+		Example: "  railway paths unset worker",
+	})
+
+	addRootCmd(&cobra.Command{
+		Use:       "symlinks [policy]",
+		Short:     "Show or set how uploads of the linked project treat symlinks",
+		Long:      "Show or set how uploads of the linked project treat symlinks:\n\n  follow-within-root  upload the targets of links inside the project, leave out the others (default)\n  preserve            upload links inside the project as links, leave out the others\n  follow              upload the targets of every link, even outside of the project\n  error               fail when the project holds links that aren't ignored\n\nLinks that loop back to a directory they are in are always left out.",
+		RunE:      contextualize(handler.Symlinks, handler.Panic),
+		ValidArgs: []string{"follow-within-root", "preserve", "follow", "error"},
+		Args:      cobra.MaximumNArgs(1),
+		Example:   "  railway symlinks preserve",
+	})
+
+	listCmd := addRootCmd(&cobra.Command{
+		Use:   "list",
+		Short: "List all projects in your Railway account",
+		RunE:  contextualize(handler.List, handler.Panic),
+	})
+
+	listCmd.Flags().Int("limit", 0, "Only list the first n projects")
+
+	deploymentsCmd := addRootCmd(&cobra.Command{
+		Use:   "deployments",
+		Short: "List the deployments of the current environment, newest first",
+		RunE:  contextualize(handler.Deployments, handler.Panic),
+	})
+
+	deploymentsCmd.Flags().Int("limit", 20, "Only list the first n deployments, 0 for all of them")
 
 	downCmd := addRootCmd(&cobra.Command{
 		Use:   "down",
@@ -188,6 +306,44 @@ func init() {
 	downCmd.Flags().StringP("environment", "e", "", "Specify an environment to delete from")
 	downCmd.Flags().Bool("yes", false, "Skip all confirmation dialogs")
 
+	profileCmd := addRootCmd(&cobra.Command{
+		Use:   "profile",
+		Short: "Show the API endpoint profiles",
+		RunE:  contextualize(handler.Profile, handler.Panic),
+	})
+
+	profileCmd.AddCommand(&cobra.Command{
+		Use:     "set name api-url",
+		Short:   "Create or update an endpoint profile",
+		RunE:    contextualize(handler.ProfileSet, handler.Panic),
+		Args:    cobra.ExactArgs(2),
+		Example: "  railway profile set local http://localhost:8082",
+	})
+
+	profileCmd.AddCommand(&cobra.Command{
+		Use:     "use [name]",
+		Short:   "Switch to an endpoint profile, or back to the default API when no name is given",
+		RunE:    contextualize(handler.ProfileUse, handler.Panic),
+		Args:    cobra.MaximumNArgs(1),
+		Example: "  railway profile use local",
+	})
+
+	devCmd := addRootCmd(&cobra.Command{
+		Use:   "dev",
+		Short: "Tools for working on the CLI and programs built with it",
+	})
+
+	mockBackendCmd := &cobra.Command{
+		Use:     "mock-backend",
+		Short:   "Serve an in-memory fake of the Railway API for offline use",
+		RunE:    contextualize(handler.MockBackend, handler.Panic),
+		Example: "  railway dev mock-backend --addr 127.0.0.1:8910",
+	}
+
+	devCmd.AddCommand(mockBackendCmd)
+	mockBackendCmd.Flags().String("addr", "127.0.0.1:8910", "Address to listen on")
+	mockBackendCmd.Flags().Bool("no-incremental", false, "Refuse incremental uploads, to try the fallback to whole archives")
+
 	addRootCmd(&cobra.Command{
 		Use:   "connect",
 		Short: "Open an interactive shell to a database",
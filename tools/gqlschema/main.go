@@ -0,0 +1,120 @@
+// Command gqlschema regenerates gateway/schema.graphql. It introspects the
+// backboard, or reads a saved introspection response with -introspection, and
+// keeps the part of the schema the operations of the gateway use. Run it with
+// `go generate ./gateway`
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/botwayorg/railway-api/gateway"
+	"github.com/botwayorg/railway-api/lib/gql"
+)
+
+var (
+	host          = flag.String("host", defaultHost(), "Backboard to introspect")
+	introspection = flag.String("introspection", "", "Read the introspection response from this file instead of the backboard")
+	output        = flag.String("o", "", "File to write the schema to, instead of stdout")
+)
+
+func defaultHost() string {
+	if host := os.Getenv("RAILWAY_API_URL"); host != "" {
+		return host
+	}
+
+	return gateway.DefaultHost
+}
+
+func main() {
+	flag.Parse()
+
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	data, err := introspect()
+	if err != nil {
+		return err
+	}
+
+	schema, err := gql.SchemaFromIntrospection(data)
+	if err != nil {
+		return err
+	}
+
+	generated, err := gateway.GenerateSchema(schema)
+	if err != nil {
+		return fmt.Errorf("the operations don't match the backboard: %w", err)
+	}
+
+	if *output == "" {
+		_, err := os.Stdout.WriteString(generated)
+		return err
+	}
+
+	return ioutil.WriteFile(*output, []byte(generated), 0644)
+}
+
+// introspect returns the data of the introspection response
+func introspect() ([]byte, error) {
+	var body []byte
+
+	if *introspection != "" {
+		content, err := ioutil.ReadFile(*introspection)
+		if err != nil {
+			return nil, err
+		}
+
+		body = content
+	} else {
+		query, err := json.Marshal(map[string]string{"query": gql.IntrospectionQuery})
+		if err != nil {
+			return nil, err
+		}
+
+		client := &http.Client{Timeout: time.Minute}
+
+		res, err := client.Post(strings.TrimSuffix(*host, "/")+"/graphql", "application/json", bytes.NewReader(query))
+		if err != nil {
+			return nil, err
+		}
+
+		defer res.Body.Close()
+
+		if body, err = ioutil.ReadAll(res.Body); err != nil {
+			return nil, err
+		}
+
+		if res.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("introspection failed with %s: %s", res.Status, body)
+		}
+	}
+
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("introspection failed: %s", resp.Errors[0].Message)
+	}
+
+	return resp.Data, nil
+}
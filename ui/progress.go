@@ -0,0 +1,39 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ProgressBar draws a bar width characters wide, filled up to fraction
+func ProgressBar(fraction float64, width int) string {
+	if fraction < 0 {
+		fraction = 0
+	}
+
+	if fraction > 1 {
+		fraction = 1
+	}
+
+	filled := int(fraction * float64(width))
+
+	return "[" + strings.Repeat("=", filled) + strings.Repeat(" ", width-filled) + "]"
+}
+
+// HumanBytes formats a byte count with a binary unit, e.g. 1.5 MB
+func HumanBytes(n int64) string {
+	const unit = 1024
+
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}